@@ -1,19 +1,28 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
 	"github.com/BimoAtaullahR/ai-customer-support/internal/handler"
 	"github.com/BimoAtaullahR/ai-customer-support/internal/middleware"
 	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/prompt"
 	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
 	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/chat"
 	"github.com/BimoAtaullahR/ai-customer-support/pkg/firebase"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/mail"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ratelimit"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/storage"
 	"github.com/gin-gonic/gin"
 
 	"github.com/joho/godotenv"
+	"google.golang.org/api/option"
 )
 
 func main() {
@@ -40,26 +49,58 @@ func main() {
 	}
 	defer firestoreClient.Close()
 
-	//inisialisasi Gemini
-	geminiClient, err := ai.InitGemini(ctx)
+	//inisialisasi provider AI (Gemini + fallback) dengan timeout, retry, dan circuit breaker
+	aiCfg := config.LoadAIConfig()
+	aiProvider, err := ai.NewProviderChain(ctx, aiCfg.Providers, ai.ResilienceConfig{
+		Timeout:          aiCfg.CallTimeout,
+		MaxRetries:       aiCfg.MaxRetries,
+		BaseBackoff:      500 * time.Millisecond,
+		MaxBackoff:       5 * time.Second,
+		BreakerThreshold: aiCfg.BreakerThreshold,
+		BreakerCooldown:  aiCfg.BreakerCooldown,
+	})
+	if err != nil {
+		log.Fatalf("Gagal inisialisasi provider AI: %v", err)
+	}
+	log.Printf("Provider AI: %s", aiProvider.Name())
+
+	//inisialisasi pencatatan pemakaian token & budget AI
+	usageService := service.NewUsageService(firestoreClient, aiCfg)
+	if err := usageService.LoadToday(ctx); err != nil {
+		log.Printf("Gagal memuat pemakaian AI hari ini: %v", err)
+	}
+
+	//inisialisasi registry template prompt (embedded + PROMPT_DIR + firestore)
+	promptRegistry, err := prompt.NewRegistry(ctx, firestoreClient, aiCfg.PromptDir)
 	if err != nil {
-		log.Fatalf("Gagal inisialisasi Gemini: %v", err)
+		log.Fatalf("Gagal memuat template prompt: %v", err)
 	}
+	if aiCfg.PromptReload > 0 {
+		go promptRegistry.RunReload(ctx, aiCfg.PromptReload)
+	}
+
+	//webhook event tiket ke sistem luar (LMS, tools internal) dengan retry di background
+	webhookService := service.NewWebhookService(firestoreClient)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	go webhookService.RunDeliveries(ctx, 15*time.Second)
 
 	//inisialisasi AI Service
-	aiSvc := service.NewAIService(geminiClient, firestoreClient)
+	aiSvc := service.NewAIService(aiProvider, firestoreClient, aiCfg, usageService, promptRegistry, webhookService)
 
 	//setup auth client
 	authClient, _ := app.Auth(ctx)
 
 	//setup Gin Router
 	r := gin.Default()
+	if err := r.SetTrustedProxies(config.LoadTrustedProxies()); err != nil {
+		log.Fatalf("TRUSTED_PROXIES tidak valid: %v", err)
+	}
 
 	//middleware sederhana untuk CORS agar frontend bisa akses
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key, X-Widget-Token")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -67,25 +108,150 @@ func main() {
 		c.Next()
 	})
 
-	//inisialisasi handler auth
-	authHandler := handler.NewAuthHandler(authClient, firestoreClient)
+	//inisialisasi blob storage untuk lampiran (lokal atau Cloud Storage)
+	storageCfg := config.LoadStorageConfig()
+	var blobStore storage.Store
+	switch storageCfg.Backend {
+	case "gcs":
+		blobStore, err = storage.NewGCSStore(ctx, storageCfg.Bucket, option.WithCredentialsFile(cfg.ServiceAccountPath))
+	case "local":
+		blobStore, err = storage.NewLocalStore(storageCfg.LocalDir)
+	default:
+		err = fmt.Errorf("STORAGE_BACKEND tidak dikenal: %s", storageCfg.Backend)
+	}
+	if err != nil {
+		log.Fatalf("Gagal inisialisasi storage lampiran: %v", err)
+	}
+	attachmentService := service.NewAttachmentService(blobStore, storageCfg)
+	attachmentHandler := handler.NewAttachmentHandler(firestoreClient, attachmentService)
+
+	//inisialisasi notifikasi & moderasi
+	notificationService := service.NewNotificationService(firestoreClient)
+	moderationService := service.NewModerationService(firestoreClient, notificationService)
+
+	//worker untuk analisis yang tertunda (budget habis / AI gagal)
+	go aiSvc.RunAnalysisQueue(ctx, 5*time.Minute, moderationService)
+
+	//pipeline keluhan bersama untuk form web & email
+	complaintService := service.NewComplaintService(firestoreClient, aiSvc, moderationService, attachmentService, webhookService)
+
+	//mata kuliah, tenggat, dan pendaftaran mahasiswa (import CSV)
+	courseService := service.NewCourseService(firestoreClient)
+	courseHandler := handler.NewCourseHandler(courseService)
+
+	//API key & endpoint integrasi untuk sistem luar (LMS)
+	apiKeyService := service.NewAPIKeyService(firestoreClient)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	integrationHandler := handler.NewIntegrationHandler(service.NewIntegrationService(firestoreClient, complaintService, courseService))
+
+	//channel email: polling IMAP dan/atau penerima SMTP, aktif jika alamatnya diisi
+	emailCfg := config.LoadEmailConfig()
+	emailService := service.NewEmailService(firestoreClient, complaintService, emailCfg)
+	emailHandler := handler.NewEmailHandler(emailService)
+	if emailCfg.IMAPAddr != "" {
+		go emailService.RunIMAPPoller(ctx)
+		log.Printf("Polling IMAP %s setiap %s", emailCfg.IMAPAddr, emailCfg.PollInterval)
+	}
+	if emailCfg.SMTPListen != "" {
+		smtpServer := &mail.SMTPServer{
+			Addr:       emailCfg.SMTPListen,
+			Domain:     emailCfg.SMTPDomain,
+			MaxSize:    emailCfg.MaxSize,
+			Recipients: emailCfg.Recipients,
+			Deliver:    emailService.Deliver,
+		}
+		go func() {
+			log.Printf("Penerima SMTP berjalan di %s", emailCfg.SMTPListen)
+			if err := smtpServer.ListenAndServe(); err != nil {
+				log.Printf("Penerima SMTP berhenti: %v", err)
+			}
+		}()
+	}
+
+	//notifikasi email ke mahasiswa (balasan agent & perubahan status), aktif jika relay SMTP diisi
+	emailNotifier, err := service.NewEmailNotifier(firestoreClient, emailCfg)
+	if err != nil {
+		log.Fatalf("Gagal inisialisasi notifikasi email: %v", err)
+	}
+	if emailNotifier.Enabled() {
+		go emailNotifier.RunOutbox(ctx, 30*time.Second)
+		log.Printf("Notifikasi email dikirim lewat %s", emailCfg.OutboundAddr)
+	}
+	notificationHandler := handler.NewNotificationHandler(notificationService, emailNotifier)
+
+	//survei kepuasan (CSAT) yang diminta saat tiket resolved
+	csatService := service.NewCSATService(firestoreClient, aiSvc, emailNotifier)
 
 	//inisialisasi student handler
-	studentHandler := handler.NewStudentHandler(firestoreClient, aiSvc)
+	studentHandler := handler.NewStudentHandler(complaintService, courseService, csatService)
+
+	//channel aplikasi chat (Telegram), aktif jika token bot dan secret webhook diisi
+	chatCfg := config.LoadChatConfig()
+	var chatChannels []chat.Channel
+	switch {
+	case chatCfg.TelegramToken != "" && chatCfg.TelegramSecret == "":
+		log.Printf("Channel Telegram tidak diaktifkan: TELEGRAM_WEBHOOK_SECRET wajib diisi agar webhook tidak bisa dipalsukan")
+	case chatCfg.TelegramToken != "":
+		telegram := chat.NewTelegramChannel(chatCfg.TelegramToken, chatCfg.TelegramSecret, chatCfg.TelegramAPIURL)
+		chatChannels = append(chatChannels, telegram)
+		if chatCfg.TelegramWebhookURL != "" {
+			if err := telegram.SetWebhook(ctx, chatCfg.TelegramWebhookURL); err != nil {
+				log.Printf("Gagal mendaftarkan webhook Telegram: %v", err)
+			}
+		}
+		log.Printf("Channel Telegram aktif lewat %s", chatCfg.TelegramAPIURL)
+	}
+	chatService := service.NewChatService(firestoreClient, complaintService, chatCfg, chatChannels...)
+	chatHandler := handler.NewChatHandler(chatService)
+
+	//widget chat untuk pengunjung anonim, aktif jika WIDGET_SESSION_SECRET diisi
+	widgetCfg := config.LoadWidgetConfig()
+	widgetService := service.NewWidgetService(firestoreClient, complaintService, widgetCfg)
+	widgetHandler := handler.NewWidgetHandler(widgetService, csatService)
+	widgetSessionLimit := ratelimit.New(widgetCfg.SessionsPerIPHour, time.Hour)
+	widgetMessageLimit := ratelimit.New(widgetCfg.MessagesPerSessionHour, time.Hour)
+
+	//inisialisasi handler auth
+	authHandler := handler.NewAuthHandler(authClient, firestoreClient, widgetService)
 
 	//inisialisasi analytics service
 	analyticsService := service.NewAnalyticsService(firestoreClient)
+	suggestionFeedback := service.NewSuggestionFeedbackService(firestoreClient)
 
 	//inisialisasi analytics handler
-	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService, usageService, suggestionFeedback)
 
 	//inisialisasi inbox handler
-	inboxHandler := handler.NewInboxHandler(firestoreClient)
+	inboxHandler := handler.NewInboxHandler(firestoreClient, aiSvc, moderationService, suggestionFeedback, complaintService, emailNotifier, webhookService, chatService)
+
+	//inisialisasi suggestion handler (streaming draf balasan)
+	suggestionSettings := service.NewSuggestionSettingsService(firestoreClient)
+	suggestionHandler := handler.NewSuggestionHandler(firestoreClient, aiSvc, suggestionFeedback, suggestionSettings)
+
+	//inisialisasi rekomendasi & eksekusi aksi tiket
+	actionService := service.NewActionService(firestoreClient, aiSvc, emailNotifier, webhookService, chatService, csatService, aiCfg.SupportTeams)
+	actionHandler := handler.NewActionHandler(actionService)
+
+	//pengingat & penutupan otomatis tiket yang menunggu balasan mahasiswa
+	autoCloseCfg := config.LoadAutoCloseConfig()
+	autoCloseService := service.NewAutoCloseService(firestoreClient, emailNotifier, webhookService, chatService, csatService, autoCloseCfg)
+	autoCloseHandler := handler.NewAutoCloseHandler(autoCloseService)
+	if autoCloseCfg.Interval > 0 {
+		go autoCloseService.Run(ctx, autoCloseCfg.Interval)
+	}
+
+	//inisialisasi prompt handler
+	promptHandler := handler.NewPromptHandler(promptRegistry)
+
+	//inisialisasi analysis handler
+	feedbackService := service.NewFeedbackService(firestoreClient)
+	analysisHandler := handler.NewAnalysisHandler(aiSvc, feedbackService)
 
 	//Setup middleware
 	authMiddleware := middleware.AuthMiddleware(authClient)
 	studentGuard := middleware.RequireRole("customer", firestoreClient)
-	supportGuard := middleware.RequireRole("customer_support", firestoreClient)
+	supportGuard := middleware.RequireAnyRole(firestoreClient, "customer_support", "support_lead")
+	leadGuard := middleware.RequireRole("support_lead", firestoreClient)
 
 	//grouping API sesuai contract
 	v1 := r.Group("/api/v1")
@@ -96,14 +262,52 @@ func main() {
 			authGroup.POST("/register", authHandler.Register)
 			authGroup.POST("/login", authHandler.Login)
 		}
+
+		//pengelolaan role agent/lead, registrasi mandiri hanya menghasilkan customer
+		users := v1.Group("/users")
+		users.Use(authMiddleware, leadGuard)
+		{
+			users.PUT("/:id/role", authHandler.SetRole)
+		}
+
+		//webhook platform chat, diverifikasi oleh adapter masing-masing (secret token)
+		v1.POST("/channels/:channel/webhook", chatHandler.Webhook)
+
+		//unduhan lampiran dari storage lokal, diotorisasi lewat link bertanda tangan
+		v1.GET("/attachments/download", attachmentHandler.Download)
 		//endpoint student
 		student := v1.Group("/student")
 		student.Use(authMiddleware, studentGuard)
 		{
 			student.POST("/complaints", studentHandler.SubmitComplaint)
+			student.GET("/courses", courseHandler.GetStudentCourses)
 			student.GET("/conversations", inboxHandler.GetStudentConversations)
 			student.GET("/conversations/:id", inboxHandler.GetStudentConversationDetail)
 			student.POST("/conversations/:id/reply", inboxHandler.StudentReplyConversation)
+			student.POST("/conversations/:id/csat", studentHandler.SubmitCSAT)
+			student.GET("/conversations/:id/attachments/:attachmentId", attachmentHandler.GetStudentAttachmentLink)
+			student.GET("/notification-preferences", notificationHandler.GetPreferences)
+			student.PUT("/notification-preferences", notificationHandler.SavePreferences)
+			student.GET("/chat-links", chatHandler.GetChatLinks)
+			student.POST("/chat-links/:channel", chatHandler.CreateChatLinkCode)
+			student.DELETE("/chat-links/:channel", chatHandler.DeleteChatLink)
+			student.POST("/widget-session/link", widgetHandler.LinkSession)
+		}
+
+		//endpoint widget chat pengunjung anonim, diotentikasi token sesi widget
+		if widgetService.Enabled() {
+			widget := v1.Group("/widget")
+			widget.POST("/sessions", middleware.RateLimit(widgetSessionLimit, middleware.ClientIP), widgetHandler.CreateSession)
+
+			widgetConversations := widget.Group("/conversations")
+			widgetConversations.Use(middleware.RequireWidgetSession(widgetService))
+			{
+				widgetConversations.GET("", widgetHandler.ListConversations)
+				widgetConversations.GET("/:id", widgetHandler.GetConversation)
+				widgetConversations.POST("", middleware.RateLimit(widgetMessageLimit, middleware.WidgetSessionID), widgetHandler.CreateConversation)
+				widgetConversations.POST("/:id/reply", middleware.RateLimit(widgetMessageLimit, middleware.WidgetSessionID), widgetHandler.ReplyConversation)
+				widgetConversations.POST("/:id/csat", widgetHandler.SubmitCSAT)
+			}
 		}
 
 		//endpoint inbox & conversations
@@ -127,15 +331,149 @@ func main() {
 				var conv model.Conversation
 				doc.DataTo(&conv)
 
-				suggestions, err := aiSvc.GenerateSuggestions(c, conv.LastMessage)
+				language := conv.Language
+				if language == "" {
+					language = service.DetectLanguage(conv.LastMessage)
+				}
+
+				//tiket krisis hanya boleh memakai template balasan yang sudah disetujui
+				if conv.Flag == service.ModerationCrisis {
+					suggestions := service.SafeResponseIDs(id, conv.Version, service.SafeResponseSuggestions(language))
+					if err := suggestionFeedback.Track(c.Request.Context(), id, suggestions); err != nil {
+						log.Printf("gagal mencatat draf balasan %s: %v", id, err)
+					}
+					c.JSON(http.StatusOK, gin.H{"suggestions": suggestions, "language": language, "safe_response": true})
+					return
+				}
+
+				settings, err := suggestionSettings.Get(c.Request.Context(), c.GetString("user_team"))
+				if err != nil {
+					c.JSON(500, gin.H{"error": "Gagal mengambil pengaturan draf balasan"})
+					return
+				}
+
+				ctx := service.WithCallInfo(c.Request.Context(), service.CallInfo{ConversationID: id, UserID: c.GetString("user_id")})
+				suggestions, err := aiSvc.GetSuggestions(ctx, id, conv, language, settings)
+				if errors.Is(err, service.ErrBudgetExceeded) {
+					//budget habis: saran dilewati, agent tetap bisa membalas manual
+					c.JSON(http.StatusOK, gin.H{"suggestions": []model.Suggestion{}, "language": language, "skipped": true, "reason": err.Error()})
+					return
+				}
+				if errors.Is(err, ai.ErrUnavailable) {
+					c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Layanan AI sedang tidak tersedia, silakan coba lagi nanti"})
+					return
+				}
 				if err != nil {
 					c.JSON(500, gin.H{"error": "Gagal menghasilkan saran AI: " + err.Error()})
 					return
 				}
-				c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+				if err := suggestionFeedback.Track(c.Request.Context(), id, suggestions); err != nil {
+					log.Printf("gagal mencatat draf balasan %s: %v", id, err)
+				}
+				c.JSON(http.StatusOK, gin.H{"suggestions": suggestions, "language": language})
 			})
 
+			conversations.GET("/:id/suggestions/stream", suggestionHandler.StreamSuggestions)
+			conversations.GET("/:id/attachments/:attachmentId", attachmentHandler.GetAttachmentLink)
+			conversations.GET("/:id/translation", inboxHandler.GetTranslatedConversation)
 			conversations.POST(":id/reply", inboxHandler.ReplyConversation)
+			conversations.POST("/:id/analysis/refresh", analysisHandler.RefreshAnalysis)
+			conversations.GET("/:id/analysis/history", analysisHandler.GetAnalysisHistory)
+			conversations.POST("/:id/analysis/override", analysisHandler.OverrideAnalysis)
+			conversations.GET("/:id/actions/recommendations", actionHandler.GetRecommendations)
+			conversations.POST("/:id/actions/execute", actionHandler.ExecuteAction)
+		}
+
+		//endpoint mata kuliah, daftar untuk agent dan perubahan/import khusus support lead
+		courses := v1.Group("/courses")
+		courses.Use(authMiddleware)
+		{
+			courses.GET("", supportGuard, courseHandler.ListCourses)
+			courses.GET("/:id", supportGuard, courseHandler.GetCourse)
+			courses.PUT("/:id", leadGuard, courseHandler.SaveCourse)
+			courses.DELETE("/:id", leadGuard, courseHandler.DeleteCourse)
+			courses.POST("/import/:kind", leadGuard, courseHandler.ImportCourses)
+		}
+
+		//endpoint notifikasi in-app untuk agent & support lead
+		notifications := v1.Group("/notifications")
+		notifications.Use(authMiddleware, supportGuard)
+		{
+			notifications.GET("", notificationHandler.GetNotifications)
+			notifications.POST("/:id/read", notificationHandler.MarkRead)
+		}
+
+		//endpoint template prompt (khusus support lead)
+		prompts := v1.Group("/prompts")
+		prompts.Use(authMiddleware, leadGuard)
+		{
+			prompts.GET("", promptHandler.ListPrompts)
+			prompts.GET("/:name/versions/:version", promptHandler.GetPromptVersion)
+			prompts.PUT("/:name/versions/:version", promptHandler.SavePrompt)
+		}
+
+		//endpoint macro balasan, daftar untuk agent dan perubahan khusus support lead
+		macros := v1.Group("/macros")
+		macros.Use(authMiddleware)
+		{
+			macros.GET("", supportGuard, actionHandler.ListMacros)
+			macros.PUT("/:id", leadGuard, actionHandler.SaveMacro)
+			macros.DELETE("/:id", leadGuard, actionHandler.DeleteMacro)
+		}
+
+		//endpoint log email masuk (khusus support lead)
+		emails := v1.Group("/email")
+		emails.Use(authMiddleware, leadGuard)
+		{
+			emails.GET("/inbound", emailHandler.GetInboundEmails)
+		}
+
+		//endpoint integrasi sistem luar, diotentikasi API key (bukan token Firebase)
+		integrations := v1.Group("/integrations")
+		{
+			integrations.POST("/conversations", middleware.RequireAPIKey(apiKeyService, model.ScopeConversationsCreate), integrationHandler.CreateConversation)
+			integrations.GET("/conversations/:id", middleware.RequireAPIKey(apiKeyService, model.ScopeConversationsRead), integrationHandler.GetConversation)
+		}
+
+		//endpoint pengelolaan API key integrasi (khusus support lead)
+		apiKeys := v1.Group("/api-keys")
+		apiKeys.Use(authMiddleware, leadGuard)
+		{
+			apiKeys.GET("", apiKeyHandler.ListAPIKeys)
+			apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+			apiKeys.POST("/:id/rotate", apiKeyHandler.RotateAPIKey)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		//endpoint langganan webhook & dead-letter (khusus support lead)
+		webhooks := v1.Group("/webhooks")
+		webhooks.Use(authMiddleware, leadGuard)
+		{
+			webhooks.GET("", webhookHandler.ListWebhooks)
+			webhooks.POST("", webhookHandler.CreateWebhook)
+			webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.POST("/:id/rotate-secret", webhookHandler.RotateSecret)
+			webhooks.GET("/deliveries", webhookHandler.GetDeliveries)
+			webhooks.POST("/deliveries/:id/replay", webhookHandler.ReplayDelivery)
+		}
+
+		//endpoint pengaturan persona & tone draf balasan per tim (khusus support lead)
+		suggestionSettingsGroup := v1.Group("/suggestion-settings")
+		suggestionSettingsGroup.Use(authMiddleware, leadGuard)
+		{
+			suggestionSettingsGroup.GET("/:team", suggestionHandler.GetSettings)
+			suggestionSettingsGroup.PUT("/:team", suggestionHandler.SaveSettings)
+		}
+
+		//endpoint kebijakan penutupan otomatis per kategori (khusus support lead)
+		autoClose := v1.Group("/auto-close-policies")
+		autoClose.Use(authMiddleware, leadGuard)
+		{
+			autoClose.GET("", autoCloseHandler.ListPolicies)
+			autoClose.PUT("/:category", autoCloseHandler.SavePolicy)
+			autoClose.DELETE("/:category", autoCloseHandler.DeletePolicy)
+			autoClose.POST("/run", autoCloseHandler.RunSweep)
 		}
 
 		//endpoint analytics
@@ -143,6 +481,17 @@ func main() {
 		analytics.Use(authMiddleware, supportGuard)
 		{
 			analytics.GET("/overview", analyticsHandler.GetOverview)
+			analytics.GET("/ai-cache", analysisHandler.GetCacheStats)
+			analytics.GET("/ai-usage", analyticsHandler.GetAIUsage)
+			analytics.GET("/ai-accuracy", analyticsHandler.GetAIAccuracy)
+			analytics.GET("/suggestions", analyticsHandler.GetSuggestionAdoption)
+			analytics.GET("/courses", analyticsHandler.GetCourseBreakdown)
+			analytics.GET("/csat", analyticsHandler.GetCSAT)
+			analytics.GET("/auto-close", analyticsHandler.GetAutoClose)
+			analytics.GET("/ai-corrections/export", analysisHandler.ExportCorrections)
+			analytics.GET("/ai-providers", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"providers": aiProvider.Status()})
+			})
 
 		}
 	}
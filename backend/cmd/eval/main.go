@@ -0,0 +1,220 @@
+// Command eval menjalankan dataset keluhan berlabel melalui AIService.ProcessComplaint
+// dan melaporkan akurasi kategori/sentimen, confusion matrix, MAE priority, dan latensi.
+//
+// Contoh pemakaian di CI (tanpa API key):
+//
+//	go run ./cmd/eval -providers fake -dataset cmd/eval/testdata/sample.jsonl \
+//		-baseline cmd/eval/testdata/baseline_fake.json
+//
+// Untuk membandingkan versi prompt baru, taruh file <name>@<version>.tmpl di folder
+// lalu jalankan dengan -prompt-dir (versi tertinggi akan dipakai).
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/prompt"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dataset := flag.String("dataset", "cmd/eval/testdata/sample.jsonl", "file JSONL berisi keluhan berlabel")
+	providers := flag.String("providers", "", "rantai provider, misal \"fake\" atau \"gemini:gemini-2.5-flash\" (default: AI_PROVIDERS)")
+	promptDir := flag.String("prompt-dir", "", "folder template prompt tambahan (default: PROMPT_DIR)")
+	out := flag.String("out", "", "simpan laporan JSON ke file ini")
+	baseline := flag.String("baseline", "", "laporan JSON pembanding; keluar dengan kode 1 jika ada regresi")
+	maxAccuracyDrop := flag.Float64("max-accuracy-drop", 0.02, "penurunan akurasi maksimum yang masih diterima")
+	maxMAEIncrease := flag.Float64("max-mae-increase", 0.25, "kenaikan MAE priority maksimum yang masih diterima")
+	concurrency := flag.Int("concurrency", 4, "jumlah pemanggilan paralel")
+	flag.Parse()
+
+	godotenv.Load()
+	ctx := context.Background()
+
+	cfg := config.LoadAIConfig()
+	cfg.DailyTokenBudget = 0 //evaluasi tidak dibatasi budget harian
+	cfg.FeatureBudgets = nil
+	if *providers != "" {
+		cfg.Providers = strings.Split(*providers, ",")
+	}
+	if *promptDir != "" {
+		cfg.PromptDir = *promptDir
+	}
+
+	samples, err := loadDataset(*dataset)
+	if err != nil {
+		log.Fatalf("Gagal membaca dataset: %v", err)
+	}
+
+	chain, err := ai.NewProviderChain(ctx, cfg.Providers, ai.ResilienceConfig{
+		Timeout:     cfg.CallTimeout,
+		MaxRetries:  cfg.MaxRetries,
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("Gagal inisialisasi provider AI: %v", err)
+	}
+
+	//tanpa firestore: audit PII dan rollup pemakaian tidak disimpan
+	registry, err := prompt.NewRegistry(ctx, nil, cfg.PromptDir)
+	if err != nil {
+		log.Fatalf("Gagal memuat template prompt: %v", err)
+	}
+	aiSvc := service.NewAIService(chain, nil, cfg, service.NewUsageService(nil, cfg), registry, nil)
+
+	results := run(ctx, aiSvc, samples, *concurrency)
+	report := buildReport(chain.Name(), results)
+	printReport(report, results)
+
+	if *out != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+			log.Fatalf("Gagal menyimpan laporan: %v", err)
+		}
+	}
+
+	if *baseline != "" {
+		data, err := os.ReadFile(*baseline)
+		if err != nil {
+			log.Fatalf("Gagal membaca baseline: %v", err)
+		}
+		var base Report
+		if err := json.Unmarshal(data, &base); err != nil {
+			log.Fatalf("Baseline tidak valid: %v", err)
+		}
+
+		diff := compare(report, base, *maxAccuracyDrop, *maxMAEIncrease)
+		fmt.Printf("\nDibanding baseline (%s):\n", *baseline)
+		fmt.Printf("  category_accuracy  %+.3f\n", diff.CategoryAccuracy)
+		fmt.Printf("  sentiment_accuracy %+.3f\n", diff.SentimentAccuracy)
+		fmt.Printf("  priority_mae       %+.3f (positif = lebih baik)\n", diff.PriorityMAE)
+		if len(diff.Regressions) > 0 {
+			for _, r := range diff.Regressions {
+				fmt.Printf("  REGRESI: %s\n", r)
+			}
+			os.Exit(1)
+		}
+		fmt.Println("  Tidak ada regresi")
+	}
+}
+
+func loadDataset(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var s Sample
+		if err := json.Unmarshal([]byte(text), &s); err != nil {
+			return nil, fmt.Errorf("baris %d: %w", line, err)
+		}
+		if s.ID == "" {
+			s.ID = fmt.Sprintf("line-%d", line)
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+func run(ctx context.Context, aiSvc *service.AIService, samples []Sample, concurrency int) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]Result, len(samples))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				start := time.Now()
+				callCtx := service.WithCallInfo(ctx, service.CallInfo{ConversationID: samples[i].ID})
+				analysis, err := aiSvc.ProcessComplaint(callCtx, samples[i].Text, nil)
+				results[i] = Result{Sample: samples[i], Latency: time.Since(start), Err: err}
+				if err == nil {
+					results[i].Category = analysis.Category
+					results[i].PriorityScore = analysis.PriorityScore
+					results[i].Sentiment = analysis.Sentiment
+					results[i].PromptVersion = analysis.PromptVersion
+				}
+			}
+		}()
+	}
+	for i := range samples {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func printReport(report Report, results []Result) {
+	fmt.Printf("Provider           : %s\n", report.Provider)
+	fmt.Printf("Sampel             : %d (error: %d)\n", report.Total, report.Errors)
+	fmt.Printf("Akurasi kategori   : %.3f\n", report.CategoryAccuracy)
+	fmt.Printf("Akurasi sentimen   : %.3f\n", report.SentimentAccuracy)
+	fmt.Printf("MAE priority       : %.3f\n", report.PriorityMAE)
+	fmt.Printf("Latensi (ms)       : mean %.1f, p50 %.1f, p95 %.1f, max %.1f\n",
+		report.Latency.MeanMs, report.Latency.P50Ms, report.Latency.P95Ms, report.Latency.MaxMs)
+	fmt.Printf("Versi prompt       : %v\n", report.PromptVersions)
+
+	//confusion matrix: baris = label, kolom = prediksi
+	labels := map[string]bool{}
+	for expected, row := range report.Confusion {
+		labels[expected] = true
+		for predicted := range row {
+			labels[predicted] = true
+		}
+	}
+	var names []string
+	for l := range labels {
+		names = append(names, l)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("\nConfusion matrix (baris = label, kolom = prediksi)\n%-14s", "")
+	for _, n := range names {
+		fmt.Printf("%-12.11s", n)
+	}
+	fmt.Println()
+	for _, expected := range names {
+		fmt.Printf("%-14.13s", expected)
+		for _, predicted := range names {
+			fmt.Printf("%-12d", report.Confusion[expected][predicted])
+		}
+		fmt.Println()
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("\nERROR %s: %v", r.Sample.ID, r.Err)
+		}
+	}
+	fmt.Println()
+}
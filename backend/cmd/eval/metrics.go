@@ -0,0 +1,174 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// satu baris dataset berlabel (JSONL)
+type Sample struct {
+	ID            string `json:"id"`
+	Text          string `json:"text"`
+	Category      string `json:"category"`
+	PriorityScore int    `json:"priority_score"`
+	Sentiment     string `json:"sentiment"`
+}
+
+type Result struct {
+	Sample        Sample
+	Category      string
+	PriorityScore int
+	Sentiment     string
+	PromptVersion string
+	Latency       time.Duration
+	Err           error
+}
+
+type LatencyStats struct {
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+type Report struct {
+	Provider          string                    `json:"provider"`
+	PromptVersions    map[string]int            `json:"prompt_versions"`
+	Total             int                       `json:"total"`
+	Errors            int                       `json:"errors"`
+	CategoryAccuracy  float64                   `json:"category_accuracy"`
+	SentimentAccuracy float64                   `json:"sentiment_accuracy"`
+	PriorityMAE       float64                   `json:"priority_mae"`
+	Latency           LatencyStats              `json:"latency"`
+	Confusion         map[string]map[string]int `json:"category_confusion"` // expected -> predicted -> jumlah
+}
+
+func buildReport(provider string, results []Result) Report {
+	report := Report{
+		Provider:       provider,
+		PromptVersions: make(map[string]int),
+		Total:          len(results),
+		Confusion:      make(map[string]map[string]int),
+	}
+
+	var ok, categoryHit, sentimentHit, sentimentLabelled int
+	var absErr float64
+	var latencies []float64
+	for _, r := range results {
+		if r.Err != nil {
+			report.Errors++
+			continue
+		}
+		ok++
+		report.PromptVersions[r.PromptVersion]++
+		latencies = append(latencies, float64(r.Latency.Microseconds())/1000)
+
+		expected := normalizeLabel(r.Sample.Category)
+		predicted := normalizeLabel(r.Category)
+		if report.Confusion[expected] == nil {
+			report.Confusion[expected] = make(map[string]int)
+		}
+		report.Confusion[expected][predicted]++
+		if expected == predicted {
+			categoryHit++
+		}
+
+		if r.Sample.Sentiment != "" {
+			sentimentLabelled++
+			if normalizeSentiment(r.Sample.Sentiment) == normalizeSentiment(r.Sentiment) {
+				sentimentHit++
+			}
+		}
+		absErr += math.Abs(float64(r.PriorityScore - r.Sample.PriorityScore))
+	}
+
+	if ok > 0 {
+		report.CategoryAccuracy = round(float64(categoryHit) / float64(ok))
+		report.PriorityMAE = round(absErr / float64(ok))
+	}
+	if sentimentLabelled > 0 {
+		report.SentimentAccuracy = round(float64(sentimentHit) / float64(sentimentLabelled))
+	}
+	report.Latency = latencyStats(latencies)
+
+	return report
+}
+
+// Diff adalah perubahan metrik terhadap baseline, positif = lebih baik
+type Diff struct {
+	CategoryAccuracy  float64 `json:"category_accuracy"`
+	SentimentAccuracy float64 `json:"sentiment_accuracy"`
+	PriorityMAE       float64 `json:"priority_mae"` // negatif = error bertambah
+	Regressions       []string
+}
+
+func compare(current, baseline Report, maxAccuracyDrop, maxMAEIncrease float64) Diff {
+	diff := Diff{
+		CategoryAccuracy:  round(current.CategoryAccuracy - baseline.CategoryAccuracy),
+		SentimentAccuracy: round(current.SentimentAccuracy - baseline.SentimentAccuracy),
+		PriorityMAE:       round(baseline.PriorityMAE - current.PriorityMAE),
+	}
+
+	if -diff.CategoryAccuracy > maxAccuracyDrop {
+		diff.Regressions = append(diff.Regressions, "category_accuracy turun melebihi batas")
+	}
+	if -diff.SentimentAccuracy > maxAccuracyDrop {
+		diff.Regressions = append(diff.Regressions, "sentiment_accuracy turun melebihi batas")
+	}
+	if -diff.PriorityMAE > maxMAEIncrease {
+		diff.Regressions = append(diff.Regressions, "priority_mae naik melebihi batas")
+	}
+	if current.Errors > baseline.Errors {
+		diff.Regressions = append(diff.Regressions, "jumlah error bertambah")
+	}
+	return diff
+}
+
+func latencyStats(ms []float64) LatencyStats {
+	if len(ms) == 0 {
+		return LatencyStats{}
+	}
+	sort.Float64s(ms)
+
+	var sum float64
+	for _, v := range ms {
+		sum += v
+	}
+	percentile := func(p float64) float64 {
+		idx := int(math.Ceil(p*float64(len(ms)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return ms[idx]
+	}
+
+	return LatencyStats{
+		MeanMs: round(sum / float64(len(ms))),
+		P50Ms:  round(percentile(0.50)),
+		P95Ms:  round(percentile(0.95)),
+		MaxMs:  round(ms[len(ms)-1]),
+	}
+}
+
+func normalizeLabel(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// model kadang menjawab sentimen dalam bahasa Inggris
+func normalizeSentiment(s string) string {
+	switch normalizeLabel(s) {
+	case "negative", "negatif":
+		return "negatif"
+	case "positive", "positif":
+		return "positif"
+	case "neutral", "netral":
+		return "netral"
+	}
+	return normalizeLabel(s)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
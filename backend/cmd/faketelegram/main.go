@@ -0,0 +1,65 @@
+// Command faketelegram menjalankan Telegram Bot API palsu untuk mencoba channel Telegram
+// secara lokal tanpa bot sungguhan.
+//
+// Jalankan server palsu, lalu jalankan API dengan TELEGRAM_API_URL mengarah ke sini:
+//
+//	go run ./cmd/faketelegram -listen :8081
+//	TELEGRAM_BOT_TOKEN=dev TELEGRAM_API_URL=http://localhost:8081 \
+//		TELEGRAM_WEBHOOK_URL=http://localhost:8080/api/v1/channels/telegram/webhook go run ./cmd/api
+//
+// Pesan dari "mahasiswa" dikirim lewat endpoint simulate, balasan bot terlihat di log
+// dan di GET /sent:
+//
+//	curl -d user_id=1001 -d username=budi -d text="/start ABCD2345" localhost:8081/simulate
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/chat"
+)
+
+func main() {
+	listen := flag.String("listen", ":8081", "alamat server Bot API palsu")
+	flag.Parse()
+
+	fake := chat.NewFakeTelegram()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/simulate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "gunakan POST", http.StatusMethodNotAllowed)
+			return
+		}
+		userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "user_id harus angka", http.StatusBadRequest)
+			return
+		}
+		if err := fake.Simulate(r.Context(), userID, r.FormValue("username"), r.FormValue("text")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/sent", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fake.Sent())
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fake.ServeHTTP(w, r)
+		if strings.HasSuffix(r.URL.Path, "/sendMessage") {
+			if sent := fake.Sent(); len(sent) > 0 {
+				last := sent[len(sent)-1]
+				log.Printf("bot -> chat %s: %s", last.ChatID, last.Text)
+			}
+		}
+	})
+
+	log.Printf("Telegram Bot API palsu berjalan di %s", *listen)
+	log.Fatal(http.ListenAndServe(*listen, mux))
+}
@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type FirebaseConfig struct {
 	ServiceAccountPath string
@@ -11,3 +16,292 @@ func LoadFirebaseConfig() *FirebaseConfig {
 		ServiceAccountPath: os.Getenv("FIREBASE_SERVICE_ACCOUNT_PATH"),
 	}
 }
+
+type AIConfig struct {
+	//ringkasan percakapan diperbarui setiap N pesan baru
+	SummaryRefreshEvery int
+
+	//kebijakan redaksi PII sebelum teks dikirim ke LLM
+	PIIRedaction bool
+	PIITypes     []string // kosong = semua jenis PII
+
+	//cache hasil LLM (saran balasan, analisis, terjemahan)
+	CacheTTL        time.Duration
+	CacheMaxEntries int
+
+	//budget token harian (0 = tanpa batas) dan harga per 1 juta token (USD)
+	DailyTokenBudget   int64
+	FeatureBudgets     map[string]int64
+	PricePerMTokInput  float64
+	PricePerMTokOutput float64
+
+	//rantai provider "jenis:model" (urutan = prioritas fallback) dan ketahanan pemanggilan
+	Providers        []string
+	CallTimeout      time.Duration
+	MaxRetries       int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	//folder template prompt tambahan (<name>@<version>.tmpl), opsional
+	PromptDir string
+	//interval memuat ulang template prompt dari firestore/PROMPT_DIR (0 = hanya saat start/Save)
+	PromptReload time.Duration
+
+	//jumlah koreksi agent terbaru yang dipakai sebagai contoh few-shot di prompt analisis
+	FewShotExamples int
+
+	//tim tujuan yang boleh direkomendasikan AI untuk pengalihan tiket
+	SupportTeams []string
+
+	//kirim screenshot lampiran ke provider yang mendukung gambar. gambar tidak bisa
+	//diredaksi PII, jadi nonaktif kecuali diizinkan kebijakan data (opt-in)
+	Vision    bool
+	MaxImages int
+}
+
+func LoadAIConfig() *AIConfig {
+	return &AIConfig{
+		SummaryRefreshEvery: getEnvInt("AI_SUMMARY_REFRESH_EVERY", 5),
+		PIIRedaction:        getEnvBool("PII_REDACTION", true),
+		PIITypes:            getEnvList("PII_TYPES"),
+		CacheTTL:            time.Duration(getEnvInt("AI_CACHE_TTL_MINUTES", 30)) * time.Minute,
+		CacheMaxEntries:     getEnvInt("AI_CACHE_MAX_ENTRIES", 1000),
+		DailyTokenBudget:    int64(getEnvInt("AI_DAILY_TOKEN_BUDGET", 0)),
+		FeatureBudgets:      getEnvBudgets("AI_FEATURE_BUDGETS"),
+		PricePerMTokInput:   getEnvFloat("AI_PRICE_PER_MTOK_INPUT", 0.30),
+		PricePerMTokOutput:  getEnvFloat("AI_PRICE_PER_MTOK_OUTPUT", 2.50),
+		Providers:           getEnvList("AI_PROVIDERS"),
+		CallTimeout:         time.Duration(getEnvInt("AI_CALL_TIMEOUT_SECONDS", 20)) * time.Second,
+		MaxRetries:          getEnvInt("AI_MAX_RETRIES", 2),
+		BreakerThreshold:    getEnvInt("AI_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:     time.Duration(getEnvInt("AI_BREAKER_COOLDOWN_SECONDS", 30)) * time.Second,
+		PromptDir:           os.Getenv("PROMPT_DIR"),
+		PromptReload:        time.Duration(getEnvInt("PROMPT_RELOAD_SECONDS", 60)) * time.Second,
+		FewShotExamples:     getEnvInt("AI_FEW_SHOT_EXAMPLES", 5),
+		SupportTeams:        getEnvListDefault("SUPPORT_TEAMS", []string{"academic", "finance", "it", "student_affairs"}),
+		Vision:              getEnvBool("AI_VISION", false),
+		MaxImages:           getEnvInt("AI_MAX_IMAGES", 3),
+	}
+}
+
+type StorageConfig struct {
+	Backend  string // "local" atau "gcs"
+	LocalDir string
+	Bucket   string
+
+	//batas lampiran
+	MaxFileSize  int64
+	MaxFiles     int
+	AllowedTypes []string
+
+	//link unduhan lampiran
+	SigningKey string
+	LinkTTL    time.Duration
+	PublicURL  string // base URL API untuk link unduhan storage lokal, kosong = path relatif
+}
+
+func LoadStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		Backend:      getEnvDefault("STORAGE_BACKEND", "local"),
+		LocalDir:     getEnvDefault("STORAGE_LOCAL_DIR", "./uploads"),
+		Bucket:       os.Getenv("STORAGE_GCS_BUCKET"),
+		MaxFileSize:  int64(getEnvInt("ATTACHMENT_MAX_MB", 10)) << 20,
+		MaxFiles:     getEnvInt("ATTACHMENT_MAX_FILES", 5),
+		AllowedTypes: getEnvListDefault("ATTACHMENT_TYPES", []string{"image/png", "image/jpeg", "image/webp", "application/pdf"}),
+		SigningKey:   os.Getenv("ATTACHMENT_SIGNING_KEY"),
+		LinkTTL:      time.Duration(getEnvInt("ATTACHMENT_LINK_TTL_MINUTES", 15)) * time.Minute,
+		PublicURL:    strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"),
+	}
+}
+
+type EmailConfig struct {
+	//polling IMAP, kosong = nonaktif
+	IMAPAddr     string
+	IMAPTLS      bool
+	IMAPUser     string
+	IMAPPassword string
+	IMAPMailbox  string
+	PollInterval time.Duration
+
+	//penerima SMTP, kosong = nonaktif
+	SMTPListen string
+	SMTPDomain string
+	Recipients []string // alamat support yang diterima, kosong = semua
+
+	MaxSize int64
+	//pengirim yang tidak terdaftar di users tetap dibuatkan tiket (tanpa akun mahasiswa)
+	AcceptUnknownSenders bool
+	//authserv-id MTA tepercaya di header Authentication-Results. Penerima SMTP/mailbox IMAP wajib
+	//berada di belakang MTA ini, yang memeriksa DMARC/DKIM/SPF dan membuang header
+	//Authentication-Results palsu dengan id yang sama. Kosong = tidak ada pengirim yang
+	//dianggap terautentikasi, semua email diperlakukan sebagai pengirim tak dikenal.
+	TrustedAuthServIDs []string
+
+	//notifikasi keluar ke mahasiswa lewat relay SMTP, kosong = nonaktif
+	OutboundAddr     string
+	OutboundUser     string
+	OutboundPassword string
+	FromAddress      string
+	FromName         string
+	ReplyTo          string // alamat yang dibaca IMAP/SMTP masuk, agar mahasiswa bisa membalas via email
+	DashboardURL     string // link ke halaman tiket, misal https://support.kampus.ac.id/tiket
+
+	//balasan beruntun dalam BatchWindow digabung jadi satu email, paling lama BatchMaxDelay
+	BatchWindow   time.Duration
+	BatchMaxDelay time.Duration
+}
+
+func LoadEmailConfig() *EmailConfig {
+	return &EmailConfig{
+		IMAPAddr:             os.Getenv("EMAIL_IMAP_ADDR"),
+		IMAPTLS:              getEnvBool("EMAIL_IMAP_TLS", true),
+		IMAPUser:             os.Getenv("EMAIL_IMAP_USER"),
+		IMAPPassword:         os.Getenv("EMAIL_IMAP_PASSWORD"),
+		IMAPMailbox:          getEnvDefault("EMAIL_IMAP_MAILBOX", "INBOX"),
+		PollInterval:         time.Duration(getEnvInt("EMAIL_POLL_SECONDS", 60)) * time.Second,
+		SMTPListen:           os.Getenv("EMAIL_SMTP_LISTEN"),
+		SMTPDomain:           getEnvDefault("EMAIL_SMTP_DOMAIN", "localhost"),
+		Recipients:           getEnvList("EMAIL_INBOUND_ADDRESSES"),
+		MaxSize:              int64(getEnvInt("EMAIL_MAX_MB", 25)) << 20,
+		AcceptUnknownSenders: getEnvBool("EMAIL_ACCEPT_UNKNOWN_SENDERS", false),
+		TrustedAuthServIDs:   getEnvList("EMAIL_TRUSTED_AUTHSERV_IDS"),
+		OutboundAddr:         os.Getenv("EMAIL_OUTBOUND_SMTP_ADDR"),
+		OutboundUser:         os.Getenv("EMAIL_OUTBOUND_SMTP_USER"),
+		OutboundPassword:     os.Getenv("EMAIL_OUTBOUND_SMTP_PASSWORD"),
+		FromAddress:          os.Getenv("EMAIL_FROM"),
+		FromName:             getEnvDefault("EMAIL_FROM_NAME", "Layanan Mahasiswa"),
+		ReplyTo:              os.Getenv("EMAIL_REPLY_TO"),
+		DashboardURL:         strings.TrimRight(os.Getenv("STUDENT_DASHBOARD_URL"), "/"),
+		BatchWindow:          time.Duration(getEnvInt("EMAIL_BATCH_SECONDS", 120)) * time.Second,
+		BatchMaxDelay:        time.Duration(getEnvInt("EMAIL_BATCH_MAX_MINUTES", 10)) * time.Minute,
+	}
+}
+
+// ChatConfig berisi pengaturan channel aplikasi chat, token kosong = channel nonaktif
+type ChatConfig struct {
+	TelegramToken       string
+	TelegramSecret      string // wajib, dicocokkan dengan header X-Telegram-Bot-Api-Secret-Token
+	TelegramAPIURL      string // bisa diarahkan ke cmd/faketelegram untuk pengujian lokal
+	TelegramWebhookURL  string // jika diisi, webhook didaftarkan otomatis saat server mulai
+	TelegramBotUsername string // untuk deep link t.me/<bot>?start=<kode>
+	LinkCodeTTL         time.Duration
+}
+
+func LoadChatConfig() *ChatConfig {
+	return &ChatConfig{
+		TelegramToken:       os.Getenv("TELEGRAM_BOT_TOKEN"),
+		TelegramSecret:      os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+		TelegramAPIURL:      getEnvDefault("TELEGRAM_API_URL", "https://api.telegram.org"),
+		TelegramWebhookURL:  os.Getenv("TELEGRAM_WEBHOOK_URL"),
+		TelegramBotUsername: strings.TrimPrefix(os.Getenv("TELEGRAM_BOT_USERNAME"), "@"),
+		LinkCodeTTL:         time.Duration(getEnvInt("CHAT_LINK_CODE_MINUTES", 15)) * time.Minute,
+	}
+}
+
+// WidgetConfig berisi pengaturan widget chat untuk pengunjung anonim, secret kosong = nonaktif
+type WidgetConfig struct {
+	SessionSecret string
+	SessionTTL    time.Duration
+
+	//batas laju per instance server
+	SessionsPerIPHour      int
+	MessagesPerSessionHour int
+	MaxOpenConversations   int // tiket belum resolved per sesi
+}
+
+func LoadWidgetConfig() *WidgetConfig {
+	return &WidgetConfig{
+		SessionSecret:          os.Getenv("WIDGET_SESSION_SECRET"),
+		SessionTTL:             time.Duration(getEnvInt("WIDGET_SESSION_HOURS", 168)) * time.Hour,
+		SessionsPerIPHour:      getEnvInt("WIDGET_SESSIONS_PER_IP_HOUR", 10),
+		MessagesPerSessionHour: getEnvInt("WIDGET_MESSAGES_PER_SESSION_HOUR", 30),
+		MaxOpenConversations:   getEnvInt("WIDGET_MAX_OPEN_CONVERSATIONS", 3),
+	}
+}
+
+// AutoCloseConfig berisi kebijakan bawaan penutupan otomatis tiket yang menunggu balasan
+// mahasiswa, bisa ditimpa per kategori lewat /auto-close-policies
+type AutoCloseConfig struct {
+	Interval     time.Duration // jeda antar pemeriksaan, 0 = scheduler nonaktif
+	ReminderDays int           // 0 = tanpa pengingat
+	CloseDays    int
+}
+
+func LoadAutoCloseConfig() *AutoCloseConfig {
+	return &AutoCloseConfig{
+		Interval:     time.Duration(getEnvInt("AUTO_CLOSE_INTERVAL_MINUTES", 60)) * time.Minute,
+		ReminderDays: getEnvInt("AUTO_CLOSE_REMINDER_DAYS", 3),
+		CloseDays:    getEnvInt("AUTO_CLOSE_DAYS", 7),
+	}
+}
+
+// LoadTrustedProxies mengembalikan proxy yang header X-Forwarded-For-nya dipercaya untuk IP klien
+// (dipakai rate limit), kosong = tidak ada, IP diambil dari koneksi langsung
+func LoadTrustedProxies() []string {
+	return getEnvList("TRUSTED_PROXIES")
+}
+
+func getEnvDefault(key, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fallback
+}
+
+// membaca env bertipe integer, fallback ke nilai default jika kosong/tidak valid
+func getEnvInt(key string, fallback int) int {
+	val, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	val, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+// membaca env berisi daftar dipisah koma, misal "EMAIL,PHONE"
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvListDefault(key string, fallback []string) []string {
+	if list := getEnvList(key); len(list) > 0 {
+		return list
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	val, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+// format: "suggestions:200000,analysis:500000"
+func getEnvBudgets(key string) map[string]int64 {
+	budgets := make(map[string]int64)
+	for _, item := range getEnvList(key) {
+		name, limit, ok := strings.Cut(item, ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(limit), 10, 64); err == nil {
+			budgets[strings.TrimSpace(name)] = n
+		}
+	}
+	return budgets
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+	"github.com/gin-gonic/gin"
+)
+
+type ActionHandler struct {
+	actionService *service.ActionService
+}
+
+func NewActionHandler(as *service.ActionService) *ActionHandler {
+	return &ActionHandler{actionService: as}
+}
+
+// GetRecommendations - Rekomendasi aksi berikutnya dari AI untuk sebuah tiket
+func (h *ActionHandler) GetRecommendations(c *gin.Context) {
+	ctx := service.WithCallInfo(c.Request.Context(), service.CallInfo{UserID: c.GetString("user_id")})
+	recommendations, err := h.actionService.Recommend(ctx, c.Param("id"))
+	switch {
+	case errors.Is(err, service.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+	case errors.Is(err, service.ErrBudgetExceeded):
+		//budget habis: rekomendasi dilewati, agent tetap bisa menjalankan aksi manual
+		c.JSON(http.StatusOK, gin.H{"actions": []model.ActionRecommendation{}, "skipped": true, "reason": err.Error()})
+	case errors.Is(err, ai.ErrUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Layanan AI sedang tidak tersedia, silakan coba lagi nanti"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat rekomendasi aksi: " + err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"actions": recommendations})
+	}
+}
+
+// ExecuteAction - Menjalankan satu aksi (status, pengalihan tim, macro, minta data) sekaligus
+func (h *ActionHandler) ExecuteAction(c *gin.Context) {
+	var action model.Action
+	if err := c.ShouldBindJSON(&action); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conv, err := h.actionService.Execute(c.Request.Context(), c.Param("id"), c.GetString("user_id"), action)
+	switch {
+	case errors.Is(err, service.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+	case errors.Is(err, service.ErrMacroNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Macro tidak ditemukan"})
+	case errors.Is(err, service.ErrInvalidAction):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menjalankan aksi: " + err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"success": true, "conversation": conv})
+	}
+}
+
+// ListMacros - Daftar macro balasan yang tersedia
+func (h *ActionHandler) ListMacros(c *gin.Context) {
+	macros, err := h.actionService.ListMacros(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil macro"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"macros": macros})
+}
+
+type SaveMacroRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Text        string `json:"text" binding:"required"`
+	SetStatus   string `json:"set_status"`
+	AssignTeam  string `json:"assign_team"`
+}
+
+// SaveMacro - Support lead membuat/mengubah macro
+func (h *ActionHandler) SaveMacro(c *gin.Context) {
+	var req SaveMacroRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	macro, err := h.actionService.SaveMacro(c.Request.Context(), model.Macro{
+		ID:          c.Param("id"),
+		Name:        req.Name,
+		Description: req.Description,
+		Text:        req.Text,
+		SetStatus:   req.SetStatus,
+		AssignTeam:  req.AssignTeam,
+		UpdatedBy:   c.GetString("user_id"),
+	})
+	if errors.Is(err, service.ErrInvalidAction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan macro: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "macro": macro})
+}
+
+// DeleteMacro - Support lead menghapus macro
+func (h *ActionHandler) DeleteMacro(c *gin.Context) {
+	if err := h.actionService.DeleteMacro(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus macro"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
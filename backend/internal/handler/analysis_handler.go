@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AnalysisHandler struct {
+	aiService       *service.AIService
+	feedbackService *service.FeedbackService
+}
+
+func NewAnalysisHandler(aiSvc *service.AIService, feedback *service.FeedbackService) *AnalysisHandler {
+	return &AnalysisHandler{aiService: aiSvc, feedbackService: feedback}
+}
+
+type OverrideRequest struct {
+	Category      *string `json:"category"`
+	PriorityScore *int    `json:"priority_score"`
+	Sentiment     *string `json:"sentiment"`
+	Reason        string  `json:"reason" binding:"required"`
+}
+
+// OverrideAnalysis - Agent mengoreksi kategori/prioritas/sentimen hasil AI
+func (h *AnalysisHandler) OverrideAnalysis(c *gin.Context) {
+	convID := c.Param("id")
+
+	var req OverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alasan koreksi wajib diisi"})
+		return
+	}
+	if req.Category == nil && req.PriorityScore == nil && req.Sentiment == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Minimal satu dari category, priority_score, atau sentiment harus diisi"})
+		return
+	}
+
+	override, err := h.feedbackService.Override(c.Request.Context(), convID, c.GetString("user_id"), service.OverrideInput{
+		Category:      req.Category,
+		PriorityScore: req.PriorityScore,
+		Sentiment:     req.Sentiment,
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConversationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		case errors.Is(err, service.ErrInvalidOverride):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "priority_score harus 1-10 dan sentiment harus positif/negatif/netral"})
+		case errors.Is(err, service.ErrAnalysisNotReady):
+			c.JSON(http.StatusConflict, gin.H{"error": "Analisis AI belum tersedia untuk percakapan ini"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan koreksi: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"human_override": override,
+	})
+}
+
+// ExportCorrections - Dataset berlabel dari koreksi agent dalam format JSONL (dataset cmd/eval)
+func (h *AnalysisHandler) ExportCorrections(c *gin.Context) {
+	corrections, err := h.feedbackService.ListCorrections(c.Request.Context(), 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data koreksi"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="analysis_corrections.jsonl"`)
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	enc := json.NewEncoder(c.Writer)
+	for _, corr := range corrections {
+		enc.Encode(gin.H{
+			"id":             corr.ConversationID,
+			"text":           corr.Text,
+			"category":       corr.Human.Category,
+			"priority_score": corr.Human.PriorityScore,
+			"sentiment":      corr.Human.Sentiment,
+			"ai":             corr.AI,
+			"reason":         corr.Reason,
+			"prompt_version": corr.PromptVersion,
+		})
+	}
+}
+
+// RefreshAnalysis - Agent meminta ringkasan & analisis AI diperbarui sekarang juga
+func (h *AnalysisHandler) RefreshAnalysis(c *gin.Context) {
+	convID := c.Param("id")
+
+	ctx := service.WithCallInfo(c.Request.Context(), service.CallInfo{UserID: c.GetString("user_id")})
+	analysis, err := h.aiService.RefreshAnalysis(ctx, convID, "manual")
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConversationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		case errors.Is(err, service.ErrAnalysisConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": "Analisis sedang diperbarui, silakan coba lagi"})
+		case errors.Is(err, service.ErrNoNewMessages):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Percakapan belum memiliki pesan untuk dianalisis"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui analisis: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"ai_analysis": analysis,
+	})
+}
+
+// GetAnalysisHistory - Riwayat versi analisis AI untuk sebuah percakapan
+func (h *AnalysisHandler) GetAnalysisHistory(c *gin.Context) {
+	convID := c.Param("id")
+
+	history, err := h.aiService.GetAnalysisHistory(c.Request.Context(), convID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil riwayat analisis"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// GetCacheStats - Statistik hit/miss cache AI per fitur
+func (h *AnalysisHandler) GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"cache": h.aiService.CacheStats()})
+}
@@ -2,17 +2,20 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type AnalyticsHandler struct {
-	analyticsService *service.AnalyticsService
+	analyticsService   *service.AnalyticsService
+	usageService       *service.UsageService
+	suggestionFeedback *service.SuggestionFeedbackService
 }
 
-func NewAnalyticsHandler(as *service.AnalyticsService) *AnalyticsHandler {
-	return &AnalyticsHandler{analyticsService: as}
+func NewAnalyticsHandler(as *service.AnalyticsService, us *service.UsageService, sf *service.SuggestionFeedbackService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: as, usageService: us, suggestionFeedback: sf}
 }
 
 func (s *AnalyticsHandler) GetOverview(c *gin.Context) {
@@ -23,3 +26,99 @@ func (s *AnalyticsHandler) GetOverview(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, overview)
 }
+
+// GetAIUsage - Pemakaian token & biaya AI per hari, fitur, dan agent (?days=7)
+func (s *AnalyticsHandler) GetAIUsage(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter days harus 1-90"})
+		return
+	}
+
+	report, err := s.usageService.GetReport(c.Request.Context(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data pemakaian AI: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetAIAccuracy - Akurasi label AI dibanding koreksi agent per kategori & minggu (?weeks=8)
+func (s *AnalyticsHandler) GetAIAccuracy(c *gin.Context) {
+	weeks, err := strconv.Atoi(c.DefaultQuery("weeks", "8"))
+	if err != nil || weeks < 1 || weeks > 52 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter weeks harus 1-52"})
+		return
+	}
+
+	report, err := s.analyticsService.GetAIAccuracy(c.Request.Context(), weeks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data akurasi AI: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetSuggestionAdoption - Tingkat pemakaian draf balasan AI per tone & versi prompt (?days=30)
+func (s *AnalyticsHandler) GetSuggestionAdoption(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter days harus 1-90"})
+		return
+	}
+
+	report, err := s.suggestionFeedback.GetAdoptionReport(c.Request.Context(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data pemakaian draf balasan: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetCourseBreakdown - Jumlah tiket, kategori, dan prioritas per mata kuliah (?days=30)
+func (s *AnalyticsHandler) GetCourseBreakdown(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter days harus 1-365"})
+		return
+	}
+
+	report, err := s.analyticsService.GetCourseBreakdown(c.Request.Context(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data per mata kuliah: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetCSAT - Survei kepuasan per agent, kategori, dan minggu (?weeks=8)
+func (s *AnalyticsHandler) GetCSAT(c *gin.Context) {
+	weeks, err := strconv.Atoi(c.DefaultQuery("weeks", "8"))
+	if err != nil || weeks < 1 || weeks > 52 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter weeks harus 1-52"})
+		return
+	}
+
+	report, err := s.analyticsService.GetCSAT(c.Request.Context(), weeks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data survei kepuasan: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetAutoClose - Pengingat dan penutupan otomatis tiket per kategori & hari (?days=30)
+func (s *AnalyticsHandler) GetAutoClose(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter days harus 1-365"})
+		return
+	}
+
+	report, err := s.analyticsService.GetAutoClose(c.Request.Context(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data penutupan otomatis: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
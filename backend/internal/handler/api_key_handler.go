@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+func NewAPIKeyHandler(ks *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: ks}
+}
+
+// ListAPIKeys - Daftar API key integrasi (tanpa secret)
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil API key"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 = tanpa kedaluwarsa
+}
+
+// CreateAPIKey - Membuat API key baru, key utuh hanya ditampilkan sekali
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days tidak boleh negatif"})
+		return
+	}
+
+	key, err := h.apiKeyService.Create(c.Request.Context(), req.Name, req.Scopes, time.Duration(req.ExpiresInDays)*24*time.Hour, c.GetString("user_id"))
+	if errors.Is(err, service.ErrAPIKeyRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat API key"})
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+type RotateAPIKeyRequest struct {
+	GraceHours *int `json:"grace_hours"` // default 24, 0 = secret lama langsung tidak berlaku
+}
+
+// RotateAPIKey - Membuat secret baru, secret lama masih berlaku selama grace_hours
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	var req RotateAPIKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	grace := 24
+	if req.GraceHours != nil {
+		grace = *req.GraceHours
+	}
+	if grace < 0 || grace > 24*7 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grace_hours harus antara 0 dan 168"})
+		return
+	}
+
+	key, err := h.apiKeyService.Rotate(c.Request.Context(), c.Param("id"), time.Duration(grace)*time.Hour)
+	switch {
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key tidak ditemukan"})
+	case errors.Is(err, service.ErrAPIKeyRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal merotasi API key"})
+	default:
+		c.JSON(http.StatusOK, key)
+	}
+}
+
+// RevokeAPIKey - Mencabut API key
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	err := h.apiKeyService.Revoke(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencabut API key"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	firestoreClient *firestore.Client
+	attachments     *service.AttachmentService
+}
+
+func NewAttachmentHandler(client *firestore.Client, attachments *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{firestoreClient: client, attachments: attachments}
+}
+
+// bindMessage membaca body JSON biasa, atau multipart/form-data beserta file di field "attachments"
+func bindMessage(c *gin.Context, req interface{}) ([]*multipart.FileHeader, error) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return nil, c.ShouldBindJSON(req)
+	}
+	if err := c.ShouldBind(req); err != nil {
+		return nil, err
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	return form.File["attachments"], nil
+}
+
+// GetStudentAttachmentLink - Link unduhan lampiran untuk mahasiswa pemilik percakapan
+func (h *AttachmentHandler) GetStudentAttachmentLink(c *gin.Context) {
+	h.link(c, true)
+}
+
+// GetAttachmentLink - Link unduhan lampiran untuk agent/support lead
+func (h *AttachmentHandler) GetAttachmentLink(c *gin.Context) {
+	h.link(c, false)
+}
+
+func (h *AttachmentHandler) link(c *gin.Context, ownerOnly bool) {
+	doc, err := h.firestoreClient.Collection("conversations").Doc(c.Param("id")).Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		return
+	}
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membaca data"})
+		return
+	}
+	if ownerOnly && conv.StudentId != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak"})
+		return
+	}
+
+	att, err := h.attachments.Find(conv, c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lampiran tidak ditemukan"})
+		return
+	}
+
+	url, expires, err := h.attachments.Link(c.Request.Context(), *att)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat link unduhan: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"attachment": att, "url": url, "expires_at": expires})
+}
+
+// Download - Unduhan lampiran dari storage lokal lewat link bertanda tangan (tanpa login)
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	key := c.Query("key")
+	contentType := c.Query("type")
+	r, err := h.attachments.OpenSigned(c.Request.Context(), key, contentType, c.Query("expires"), c.Query("sig"))
+	switch {
+	case errors.Is(err, service.ErrInvalidSignature):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Link unduhan tidak valid atau sudah kedaluwarsa"})
+		return
+	case errors.Is(err, service.ErrAttachmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lampiran tidak ditemukan"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuka lampiran"})
+		return
+	}
+	defer r.Close()
+
+	name := key[strings.LastIndex(key, "/")+1:]
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `inline; filename="`+name+`"`)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, r)
+}
@@ -1,21 +1,34 @@
 package handler
 
 import (
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"firebase.google.com/go/v4/auth"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	RoleCustomer        = "customer"
+	RoleCustomerSupport = "customer_support"
+	RoleSupportLead     = "support_lead"
+)
+
+// role yang tidak boleh dipilih sendiri saat registrasi
+var staffRoles = map[string]bool{RoleCustomerSupport: true, RoleSupportLead: true}
+
 type AuthHandler struct {
 	authClient      *auth.Client
 	firestoreClient *firestore.Client
+	widget          *service.WidgetService
 }
 
-func NewAuthHandler(a *auth.Client, f *firestore.Client) *AuthHandler {
-	return &AuthHandler{authClient: a, firestoreClient: f}
+func NewAuthHandler(a *auth.Client, f *firestore.Client, widget *service.WidgetService) *AuthHandler {
+	return &AuthHandler{authClient: a, firestoreClient: f, widget: widget}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -24,6 +37,9 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Role    string `json:"role"`
 		Name    string `json:"name"`
 		Email   string `json:"email"`
+		Team    string `json:"team"` // opsional, untuk agent
+		// opsional, token sesi widget agar tiket yang dibuat sebelum daftar masuk ke akun ini
+		WidgetToken string `json:"widget_token"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -37,13 +53,39 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	//registrasi mandiri hanya untuk mahasiswa. Role agent/lead diberikan lewat custom claim
+	//Firebase (oleh admin atau endpoint PUT /users/:id/role), bukan dari isi request.
+	role := RoleCustomer
+	if claimed, _ := token.Claims["role"].(string); staffRoles[claimed] {
+		role = claimed
+	}
+	if req.Role != "" && req.Role != role {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Role " + req.Role + " hanya bisa diberikan oleh support lead"})
+		return
+	}
+	//tim menentukan pengaturan draf balasan, jadi ikut diberikan lewat claim, bukan dipilih sendiri
+	team := ""
+	if role != RoleCustomer {
+		team, _ = token.Claims["team"].(string)
+	}
+	if req.Team != "" && req.Team != team {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tim hanya bisa diberikan oleh support lead"})
+		return
+	}
+
 	userProfile := map[string]interface{}{
 		"uid":        token.UID,
 		"name":       req.Name,
 		"email":      req.Email,
-		"role":       req.Role,
+		"role":       role,
 		"created_at": time.Now(),
 	}
+	if email := verifiedEmail(token); email != "" {
+		userProfile["verified_email"] = email
+	}
+	if team != "" {
+		userProfile["team"] = team
+	}
 
 	_, err = h.firestoreClient.Collection("users").Doc(token.UID).Set(c.Request.Context(), userProfile)
 	if err != nil {
@@ -51,9 +93,20 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	data := gin.H{"user": userProfile}
+	//kegagalan menautkan sesi widget tidak membatalkan registrasi
+	if req.WidgetToken != "" && role == RoleCustomer {
+		linked, err := h.widget.Link(c.Request.Context(), req.WidgetToken, token.UID)
+		if err != nil {
+			log.Printf("Gagal menautkan sesi widget ke user %s: %v", token.UID, err)
+		} else {
+			data["linked_conversations"] = linked
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    gin.H{"user": userProfile},
+		"data":    data,
 	})
 }
 
@@ -80,8 +133,82 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	userProfile := doc.Data()
+	//email biasanya baru diverifikasi setelah registrasi, jadi disinkronkan setiap login
+	if email := verifiedEmail(token); email != "" && userProfile["verified_email"] != email {
+		if _, err := doc.Ref.Update(c.Request.Context(), []firestore.Update{{Path: "verified_email", Value: email}}); err != nil {
+			log.Printf("Gagal menyimpan verified_email user %s: %v", token.UID, err)
+		} else {
+			userProfile["verified_email"] = email
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    gin.H{"user": userProfile},
 	})
 }
+
+// SetRole - Support lead mengubah role (dan tim agent) user. Keduanya disimpan di profil (dibaca
+// guard) dan sebagai custom claim Firebase, sehingga tetap berlaku jika user mendaftar ulang.
+func (h *AuthHandler) SetRole(c *gin.Context) {
+	var req struct {
+		Role string `json:"role"`
+		Team string `json:"team"` // opsional, diabaikan untuk customer
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Role != RoleCustomer && !staffRoles[req.Role]) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role harus customer, customer_support, atau support_lead"})
+		return
+	}
+
+	uid := c.Param("id")
+	if uid == c.GetString("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tidak bisa mengubah role sendiri"})
+		return
+	}
+	ctx := c.Request.Context()
+	ref := h.firestoreClient.Collection("users").Doc(uid)
+	if _, err := ref.Get(ctx); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
+		return
+	}
+
+	user, err := h.authClient.GetUser(ctx, uid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
+		return
+	}
+	claims := map[string]interface{}{}
+	for k, v := range user.CustomClaims {
+		claims[k] = v
+	}
+	claims["role"] = req.Role
+	updates := []firestore.Update{{Path: "role", Value: req.Role}}
+	if req.Role == RoleCustomer {
+		delete(claims, "team")
+		updates = append(updates, firestore.Update{Path: "team", Value: firestore.Delete})
+	} else if req.Team != "" {
+		claims["team"] = req.Team
+		updates = append(updates, firestore.Update{Path: "team", Value: req.Team})
+	}
+	if err := h.authClient.SetCustomUserClaims(ctx, uid, claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan role"})
+		return
+	}
+	if _, err := ref.Update(ctx, updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan role"})
+		return
+	}
+
+	team, _ := claims["team"].(string)
+	c.JSON(http.StatusOK, gin.H{"success": true, "uid": uid, "role": req.Role, "team": team})
+}
+
+// verifiedEmail mengembalikan email dari token Firebase hanya jika sudah diverifikasi.
+// Nilai ini (bukan field email dari request) yang dipakai mencocokkan email masuk,
+// roster mata kuliah, dan integrasi dengan akun mahasiswa.
+func verifiedEmail(token *auth.Token) string {
+	if ok, _ := token.Claims["email_verified"].(bool); !ok {
+		return ""
+	}
+	email, _ := token.Claims["email"].(string)
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AutoCloseHandler struct {
+	autoClose *service.AutoCloseService
+}
+
+func NewAutoCloseHandler(autoClose *service.AutoCloseService) *AutoCloseHandler {
+	return &AutoCloseHandler{autoClose: autoClose}
+}
+
+func (h *AutoCloseHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.autoClose.ListPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil kebijakan penutupan otomatis"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+type AutoClosePolicyRequest struct {
+	Enabled      bool `json:"enabled"`
+	ReminderDays int  `json:"reminder_days"`
+	CloseDays    int  `json:"close_days" binding:"required"`
+}
+
+// SavePolicy - Support lead mengatur pengingat & penutupan otomatis untuk satu kategori
+// (atau "default" untuk kategori tanpa kebijakan sendiri)
+func (h *AutoCloseHandler) SavePolicy(c *gin.Context) {
+	var req AutoClosePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "close_days wajib diisi"})
+		return
+	}
+
+	policy, err := h.autoClose.SavePolicy(c.Request.Context(), model.AutoClosePolicy{
+		Category:     c.Param("category"),
+		Enabled:      req.Enabled,
+		ReminderDays: req.ReminderDays,
+		CloseDays:    req.CloseDays,
+		UpdatedBy:    c.GetString("user_id"),
+	})
+	if errors.Is(err, service.ErrInvalidAutoClosePolicy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan kebijakan penutupan otomatis"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "policy": policy})
+}
+
+func (h *AutoCloseHandler) DeletePolicy(c *gin.Context) {
+	err := h.autoClose.DeletePolicy(c.Request.Context(), c.Param("category"))
+	if errors.Is(err, service.ErrAutoClosePolicyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus kebijakan penutupan otomatis"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// RunSweep - Menjalankan pemeriksaan scheduler sekarang tanpa menunggu interval berikutnya
+func (h *AutoCloseHandler) RunSweep(c *gin.Context) {
+	result, err := h.autoClose.Sweep(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa tiket: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "result": result})
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/chat"
+	"github.com/gin-gonic/gin"
+)
+
+type ChatHandler struct {
+	chatService *service.ChatService
+}
+
+func NewChatHandler(cs *service.ChatService) *ChatHandler {
+	return &ChatHandler{chatService: cs}
+}
+
+// Webhook - Menerima update dari platform chat (misal Telegram), diverifikasi oleh adapter channel
+func (h *ChatHandler) Webhook(c *gin.Context) {
+	ch, ok := h.chatService.Channel(c.Param("channel"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Channel chat tidak tersedia"})
+		return
+	}
+
+	in, err := ch.ParseWebhook(c.Request)
+	switch {
+	case errors.Is(err, chat.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case in == nil:
+		c.JSON(http.StatusOK, gin.H{"success": true})
+		return
+	}
+
+	//status 5xx membuat platform mengirim ulang update yang sama
+	if err := h.chatService.Receive(c.Request.Context(), ch, in); err != nil {
+		log.Printf("gagal memproses pesan %s dari %s: %v", in.UpdateID, ch.Name(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses pesan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetChatLinks - Akun aplikasi chat yang terhubung dengan mahasiswa
+func (h *ChatHandler) GetChatLinks(c *gin.Context) {
+	links, err := h.chatService.ListLinks(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil akun chat"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+// CreateChatLinkCode - Kode sekali pakai yang dikirim mahasiswa ke bot (/start <kode>)
+func (h *ChatHandler) CreateChatLinkCode(c *gin.Context) {
+	code, err := h.chatService.CreateLinkCode(c.Request.Context(), c.GetString("user_id"), c.Param("channel"))
+	if errors.Is(err, service.ErrChannelNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Channel chat tidak tersedia"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat kode"})
+		return
+	}
+	c.JSON(http.StatusCreated, code)
+}
+
+// DeleteChatLink - Melepas akun chat, balasan agent tidak lagi diteruskan ke chat tersebut
+func (h *ChatHandler) DeleteChatLink(c *gin.Context) {
+	err := h.chatService.Unlink(c.Request.Context(), c.GetString("user_id"), c.Param("channel"))
+	if errors.Is(err, service.ErrChatLinkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Akun chat belum terhubung"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal melepas akun chat"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// batas ukuran file CSV import
+const courseImportMaxSize = 5 << 20
+
+type CourseHandler struct {
+	courseService *service.CourseService
+}
+
+func NewCourseHandler(cs *service.CourseService) *CourseHandler {
+	return &CourseHandler{courseService: cs}
+}
+
+// ListCourses - Daftar mata kuliah beserta tenggatnya
+func (h *CourseHandler) ListCourses(c *gin.Context) {
+	courses, err := h.courseService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil mata kuliah"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"courses": courses})
+}
+
+func (h *CourseHandler) GetCourse(c *gin.Context) {
+	course, err := h.courseService.Get(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, service.ErrCourseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mata kuliah tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil mata kuliah"})
+		return
+	}
+	c.JSON(http.StatusOK, course)
+}
+
+type SaveCourseRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Lecturer  string `json:"lecturer"`
+	Deadlines []struct {
+		Title string    `json:"title"`
+		Type  string    `json:"type"`
+		DueAt time.Time `json:"due_at"`
+	} `json:"deadlines"`
+}
+
+// SaveCourse - Membuat atau mengganti mata kuliah, deadlines menggantikan seluruh tenggat lama
+func (h *CourseHandler) SaveCourse(c *gin.Context) {
+	var req SaveCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	course := model.Course{ID: c.Param("id"), Name: req.Name, Lecturer: req.Lecturer}
+	for _, d := range req.Deadlines {
+		course.Deadlines = append(course.Deadlines, model.CourseDeadline{Title: d.Title, Type: d.Type, DueAt: d.DueAt})
+	}
+	saved, err := h.courseService.Save(c.Request.Context(), course)
+	if errors.Is(err, service.ErrInvalidCourse) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan mata kuliah"})
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteCourse - Menghapus mata kuliah beserta data pendaftaran mahasiswanya
+func (h *CourseHandler) DeleteCourse(c *gin.Context) {
+	err := h.courseService.Delete(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, service.ErrCourseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mata kuliah tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus mata kuliah"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ImportCourses - Import CSV (courses, enrollments, atau deadlines), dikirim sebagai
+// multipart field "file" atau langsung sebagai body text/csv
+func (h *CourseHandler) ImportCourses(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, courseImportMaxSize)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File CSV wajib dikirim di field file (maks 5 MB)"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, err := h.courseService.Import(c.Request.Context(), c.Param("kind"), body)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File CSV maksimal 5 MB"})
+	case errors.Is(err, service.ErrInvalidCourse):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengimport CSV"})
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// GetStudentCourses - Mata kuliah yang diikuti mahasiswa beserta tenggat yang belum lewat,
+// dipakai untuk pilihan mata kuliah di form keluhan
+func (h *CourseHandler) GetStudentCourses(c *gin.Context) {
+	courses, err := h.courseService.ListForStudent(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil mata kuliah"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"courses": courses})
+}
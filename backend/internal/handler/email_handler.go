@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type EmailHandler struct {
+	emailService *service.EmailService
+}
+
+func NewEmailHandler(es *service.EmailService) *EmailHandler {
+	return &EmailHandler{emailService: es}
+}
+
+// GetInboundEmails - Log email masuk (?status=unknown_sender&limit=50), untuk menelusuri email yang tidak menjadi tiket
+func (h *EmailHandler) GetInboundEmails(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit harus antara 1 dan 200"})
+		return
+	}
+
+	emails, err := h.emailService.ListLogs(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil log email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"emails": emails})
+}
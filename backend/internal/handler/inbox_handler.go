@@ -1,23 +1,40 @@
 package handler
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"sort"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/api/iterator"
 )
 
 type InboxHandler struct {
 	firestoreClient *firestore.Client
+	aiService       *service.AIService
+	moderation      *service.ModerationService
+	suggestions     *service.SuggestionFeedbackService
+	complaints      *service.ComplaintService
+	notifier        *service.EmailNotifier
+	webhooks        *service.WebhookService
+	chat            *service.ChatService
 }
 
-func NewInboxHandler(client *firestore.Client) *InboxHandler {
+func NewInboxHandler(client *firestore.Client, aiSvc *service.AIService, moderation *service.ModerationService, suggestions *service.SuggestionFeedbackService, complaints *service.ComplaintService, notifier *service.EmailNotifier, webhooks *service.WebhookService, chat *service.ChatService) *InboxHandler {
 	return &InboxHandler{
 		firestoreClient: client,
+		aiService:       aiSvc,
+		moderation:      moderation,
+		suggestions:     suggestions,
+		complaints:      complaints,
+		notifier:        notifier,
+		webhooks:        webhooks,
+		chat:            chat,
 	}
 }
 
@@ -73,6 +90,11 @@ func (h *InboxHandler) GetConversations(c *gin.Context) {
 		conversations = append(conversations, conv)
 	}
 
+	// Tiket krisis selalu dipin di atas, urutan sisanya tetap mengikuti query
+	sort.SliceStable(conversations, func(i, j int) bool {
+		return conversations[i].Flag == service.ModerationCrisis && conversations[j].Flag != service.ModerationCrisis
+	})
+
 	// Return empty array instead of null
 	if conversations == nil {
 		conversations = []model.Conversation{}
@@ -165,7 +187,11 @@ func (h *InboxHandler) GetStudentConversationDetail(c *gin.Context) {
 }
 
 type ReplyRequest struct {
-	Text string `json:"text" binding:"required"`
+	Text string `json:"text" form:"text" binding:"required"`
+	// hanya untuk agent: terjemahkan balasan ke bahasa mahasiswa sebelum dikirim
+	Translate bool `json:"translate"`
+	// hanya untuk agent: ID draf AI yang dipakai sebagai dasar balasan, jika ada
+	SuggestionID string `json:"suggestion_id"`
 }
 
 func (h *InboxHandler) ReplyConversation(c *gin.Context) {
@@ -184,18 +210,43 @@ func (h *InboxHandler) ReplyConversation(c *gin.Context) {
 		Sender:    "support", // Changed from "agent" to "support" for consistency
 		Text:      req.Text,
 		Timestamp: time.Now(),
+		Language:  service.DetectLanguage(req.Text),
+	}
+
+	doc, err := h.firestoreClient.Collection("conversations").Doc(convID).Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		return
+	}
+	var conv model.Conversation
+	doc.DataTo(&conv)
+	newMessage.Channel = service.MessageChannel(&conv)
+
+	// Terjemahkan ke bahasa mahasiswa jika diminta dan bahasanya berbeda
+	if req.Translate {
+		if conv.Language != "" && conv.Language != newMessage.Language {
+			ctx := service.WithCallInfo(c.Request.Context(), service.CallInfo{ConversationID: convID, UserID: c.GetString("user_id")})
+			translated, err := h.aiService.TranslateTexts(ctx, []string{req.Text}, conv.Language)
+			if err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal menerjemahkan balasan: " + err.Error()})
+				return
+			}
+			newMessage.OriginalText = req.Text
+			newMessage.Text = translated[0]
+			newMessage.Language = conv.Language
+		}
 	}
 
 	// 4. Update Firestore secara Atomic
 	// Kita update 3 field sekaligus: messages (append), last_message, dan updated_at
-	_, err := h.firestoreClient.Collection("conversations").Doc(convID).Update(c.Request.Context(), []firestore.Update{
+	_, err = h.firestoreClient.Collection("conversations").Doc(convID).Update(c.Request.Context(), []firestore.Update{
 		{
 			Path:  "messages",
 			Value: firestore.ArrayUnion(newMessage), // PENTING: Menambahkan ke array, bukan menimpa
 		},
 		{
 			Path:  "last_message",
-			Value: req.Text,
+			Value: newMessage.Text,
 		},
 		{
 			Path:  "updated_at",
@@ -203,7 +254,11 @@ func (h *InboxHandler) ReplyConversation(c *gin.Context) {
 		},
 		{
 			Path:  "status",
-			Value: "in_progress",
+			Value: model.StatusInProgress,
+		},
+		{
+			Path:  "version",
+			Value: firestore.Increment(1),
 		},
 	})
 
@@ -212,10 +267,49 @@ func (h *InboxHandler) ReplyConversation(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"timestamp": newMessage.Timestamp,
-	})
+	//catat draf mana yang dipakai (dibandingkan dengan teks sebelum diterjemahkan),
+	//draf lain yang ditampilkan dianggap diabaikan
+	outcome, err := h.suggestions.Resolve(c.Request.Context(), convID, c.GetString("user_id"), req.SuggestionID, req.Text)
+	if err != nil {
+		log.Printf("gagal mencatat pemakaian draf balasan %s: %v", convID, err)
+	}
+
+	h.webhooks.Publish(c.Request.Context(), model.WebhookMessageCreated, convID, map[string]interface{}{"message": newMessage})
+	if conv.Status != model.StatusInProgress {
+		h.webhooks.Publish(c.Request.Context(), model.WebhookStatusChanged, convID, map[string]interface{}{"from": conv.Status, "to": model.StatusInProgress})
+	}
+
+	//tiket dari aplikasi chat: balasan diteruskan ke chat asal, kegagalan dilaporkan ke agent
+	//tanpa membatalkan balasan yang sudah tersimpan
+	relayErr := h.chat.Relay(c.Request.Context(), &conv, newMessage.Text)
+	if relayErr != nil {
+		log.Printf("gagal meneruskan balasan %s ke chat: %v", convID, relayErr)
+	}
+
+	//kabari mahasiswa lewat email (digabung jika agent membalas beberapa kali berturut-turut)
+	events := []model.EmailEvent{{Type: model.EmailEventAgentReply, Text: newMessage.Text, At: newMessage.Timestamp}}
+	if conv.Status != model.StatusInProgress {
+		events = append(events, model.EmailEvent{Type: model.EmailEventStatusChanged, Status: model.StatusInProgress, At: newMessage.Timestamp})
+	}
+	err = h.notifier.Notify(c.Request.Context(), convID, events...)
+	if err != nil {
+		log.Printf("gagal menjadwalkan email notifikasi %s: %v", convID, err)
+	}
+
+	//perbarui ringkasan AI di background jika sudah cukup banyak pesan baru
+	go h.complaints.RefreshAnalysisInBackground(convID)
+
+	resp := gin.H{
+		"success":            true,
+		"timestamp":          newMessage.Timestamp,
+		"text":               newMessage.Text,
+		"suggestion_outcome": outcome,
+		"channel":            newMessage.Channel,
+	}
+	if relayErr != nil {
+		resp["relay_error"] = relayErr.Error()
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // StudentReplyConversation - Student reply to their own conversation
@@ -242,31 +336,68 @@ func (h *InboxHandler) StudentReplyConversation(c *gin.Context) {
 		return
 	}
 
+	//mahasiswa boleh mengirim balasan sebagai multipart/form-data beserta lampiran
 	var req ReplyRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	files, err := bindMessage(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	newMessage := model.Message{
-		Sender:    "student",
+	newMessage, err := h.complaints.Reply(c.Request.Context(), convID, service.ComplaintInput{
+		StudentID: uid,
 		Text:      req.Text,
-		Timestamp: time.Now(),
+		Files:     service.MultipartUploads(files),
+		Channel:   model.ChannelWeb,
+	})
+	if errors.Is(err, service.ErrInvalidAttachment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim balasan"})
+		return
 	}
 
-	_, err = h.firestoreClient.Collection("conversations").Doc(convID).Update(c.Request.Context(), []firestore.Update{
-		{Path: "messages", Value: firestore.ArrayUnion(newMessage)},
-		{Path: "last_message", Value: req.Text},
-		{Path: "updated_at", Value: time.Now()},
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"timestamp":   newMessage.Timestamp,
+		"attachments": newMessage.Attachments,
 	})
+}
 
+// GetTranslatedConversation - Tampilan percakapan yang diterjemahkan ke bahasa agent (?lang=id)
+func (h *InboxHandler) GetTranslatedConversation(c *gin.Context) {
+	convID := c.Param("id")
+	target := c.DefaultQuery("lang", service.DefaultLanguage)
+	if !service.IsSupportedLanguage(target) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bahasa tidak didukung: " + target})
+		return
+	}
+
+	doc, err := h.firestoreClient.Collection("conversations").Doc(convID).Get(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim balasan"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		return
+	}
+
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membaca data"})
+		return
+	}
+
+	ctx := service.WithCallInfo(c.Request.Context(), service.CallInfo{ConversationID: convID, UserID: c.GetString("user_id")})
+	messages, err := h.aiService.TranslateConversation(ctx, convID, conv, target)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal menerjemahkan percakapan: " + err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"timestamp": newMessage.Timestamp,
+		"id":               doc.Ref.ID,
+		"language":         target,
+		"student_language": conv.Language,
+		"messages":         messages,
 	})
 }
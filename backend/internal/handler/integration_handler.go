@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type IntegrationHandler struct {
+	integrationService *service.IntegrationService
+}
+
+func NewIntegrationHandler(is *service.IntegrationService) *IntegrationHandler {
+	return &IntegrationHandler{integrationService: is}
+}
+
+type IntegrationConversationRequest struct {
+	StudentID    string              `json:"student_id"`
+	StudentEmail string              `json:"student_email"`
+	Text         string              `json:"text" binding:"required"`
+	Context      model.TicketContext `json:"context"` // source & api_key_id diisi server dari API key
+}
+
+// CreateConversation - Sistem luar (LMS) membuka tiket atas nama mahasiswa
+func (h *IntegrationHandler) CreateConversation(c *gin.Context) {
+	var req IntegrationConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := c.MustGet("api_key").(*model.APIKey)
+	conv, created, err := h.integrationService.CreateConversation(c.Request.Context(), key, service.IntegrationInput{
+		StudentID:      req.StudentID,
+		StudentEmail:   req.StudentEmail,
+		Text:           req.Text,
+		Context:        req.Context,
+		IdempotencyKey: c.GetHeader("Idempotency-Key"),
+	})
+	switch {
+	case errors.Is(err, service.ErrIntegrationRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrStudentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mahasiswa tidak ditemukan"})
+	case errors.Is(err, service.ErrIdempotencyConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat tiket"})
+	default:
+		code := http.StatusCreated
+		if !created {
+			code = http.StatusOK
+		}
+		c.JSON(code, integrationConversation(conv))
+	}
+}
+
+// GetConversation - Status tiket yang dibuat oleh API key yang sama
+func (h *IntegrationHandler) GetConversation(c *gin.Context) {
+	key := c.MustGet("api_key").(*model.APIKey)
+	conv, err := h.integrationService.GetConversation(c.Request.Context(), key, c.Param("id"))
+	if errors.Is(err, service.ErrConversationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil percakapan"})
+		return
+	}
+	c.JSON(http.StatusOK, integrationConversation(conv))
+}
+
+// sistem luar hanya menerima ringkasan tiket, bukan isi percakapan lengkap
+func integrationConversation(conv *model.Conversation) gin.H {
+	return gin.H{
+		"ticket_id":      conv.ID,
+		"status":         conv.Status,
+		"category":       conv.AIAnalysis.Category,
+		"priority_score": conv.AIAnalysis.PriorityScore,
+		"context":        conv.Context,
+		"created_at":     conv.CreatedAt,
+		"updated_at":     conv.UpdatedAt,
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	emailNotifier       *service.EmailNotifier
+}
+
+func NewNotificationHandler(ns *service.NotificationService, en *service.EmailNotifier) *NotificationHandler {
+	return &NotificationHandler{notificationService: ns, emailNotifier: en}
+}
+
+// GetNotifications - Daftar notifikasi untuk user yang sedang login
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	notifications, err := h.notificationService.ListForUser(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil notifikasi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	err := h.notificationService.MarkRead(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notifikasi tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui notifikasi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetPreferences - Pengaturan notifikasi email mahasiswa yang sedang login
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	prefs, err := h.emailNotifier.GetPreferences(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil pengaturan notifikasi"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// field kosong tidak mengubah pengaturan yang sudah ada
+type SavePreferencesRequest struct {
+	Email         *bool   `json:"email"`
+	AgentReplies  *bool   `json:"agent_replies"`
+	StatusChanges *bool   `json:"status_changes"`
+	Language      *string `json:"language"`
+}
+
+func (h *NotificationHandler) SavePreferences(c *gin.Context) {
+	var req SavePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid := c.GetString("user_id")
+	prefs, err := h.emailNotifier.GetPreferences(c.Request.Context(), uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil pengaturan notifikasi"})
+		return
+	}
+	if req.Email != nil {
+		prefs.Email = *req.Email
+	}
+	if req.AgentReplies != nil {
+		prefs.AgentReplies = *req.AgentReplies
+	}
+	if req.StatusChanges != nil {
+		prefs.StatusChanges = *req.StatusChanges
+	}
+	if req.Language != nil {
+		prefs.Language = *req.Language
+	}
+
+	saved, err := h.emailNotifier.SavePreferences(c.Request.Context(), uid, *prefs)
+	if errors.Is(err, service.ErrInvalidPreferences) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan pengaturan notifikasi"})
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
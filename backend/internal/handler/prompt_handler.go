@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/prompt"
+	"github.com/gin-gonic/gin"
+)
+
+type PromptHandler struct {
+	registry *prompt.Registry
+}
+
+func NewPromptHandler(r *prompt.Registry) *PromptHandler {
+	return &PromptHandler{registry: r}
+}
+
+// ListPrompts - Semua template prompt beserta versi dan bobot A/B-nya
+func (h *PromptHandler) ListPrompts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"templates": h.registry.List()})
+}
+
+type SavePromptRequest struct {
+	Text   string `json:"text"`   // kosong = pakai teks versi yang sudah ada (hanya ubah bobot)
+	Weight *int   `json:"weight"` // bobot A/B split, 0 = tidak ikut split
+}
+
+// SavePrompt - Support lead membuat/mengubah satu versi template prompt
+func (h *PromptHandler) SavePrompt(c *gin.Context) {
+	var req SavePromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tmpl := model.PromptTemplate{
+		Name:      c.Param("name"),
+		Version:   c.Param("version"),
+		Text:      req.Text,
+		UpdatedBy: c.GetString("user_id"),
+	}
+
+	existing, err := h.registry.Get(tmpl.Name, tmpl.Version)
+	if err == nil {
+		if tmpl.Text == "" {
+			tmpl.Text = existing.Text
+		}
+		tmpl.Weight = existing.Weight
+	}
+	if req.Weight != nil {
+		if *req.Weight < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Weight tidak boleh negatif"})
+			return
+		}
+		tmpl.Weight = *req.Weight
+	}
+	if tmpl.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Teks template wajib diisi untuk versi baru"})
+		return
+	}
+
+	compiled, err := h.registry.Save(c.Request.Context(), tmpl)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Gagal menyimpan template: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"template": compiled.PromptTemplate,
+	})
+}
+
+// GetPromptVersion - Detail satu versi template
+func (h *PromptHandler) GetPromptVersion(c *gin.Context) {
+	tmpl, err := h.registry.Get(c.Param("name"), c.Param("version"))
+	if err != nil {
+		if errors.Is(err, prompt.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tmpl.PromptTemplate)
+}
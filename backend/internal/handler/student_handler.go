@@ -1,30 +1,29 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
-	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
 	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type StudentHandler struct {
-	firestoreClient *firestore.Client
-	aiService       *service.AIService
+	complaints *service.ComplaintService
+	courses    *service.CourseService
+	csat       *service.CSATService
 }
 
-func NewStudentHandler(client *firestore.Client, aiSvc *service.AIService) *StudentHandler {
-	return &StudentHandler{
-		firestoreClient: client,
-		aiService:       aiSvc,
-	}
+func NewStudentHandler(complaints *service.ComplaintService, courses *service.CourseService, csat *service.CSATService) *StudentHandler {
+	return &StudentHandler{complaints: complaints, courses: courses, csat: csat}
 }
 
+// bisa dikirim sebagai JSON, atau multipart/form-data dengan file di field "attachments"
 type CreateComplaintRequest struct {
-	StudentName string `json:"student_name"`
-	Text        string `json:"text" binding:"required"`
+	StudentName string `json:"student_name" form:"student_name"`
+	Text        string `json:"text" form:"text" binding:"required"`
+	CourseID    string `json:"course_id" form:"course_id"` // opsional, harus mata kuliah yang diikuti mahasiswa
 }
 
 func (h *StudentHandler) SubmitComplaint(c *gin.Context) {
@@ -36,39 +35,37 @@ func (h *StudentHandler) SubmitComplaint(c *gin.Context) {
 	uid := userID.(string)
 
 	var req CreateComplaintRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	files, err := bindMessage(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	analysis, err := h.aiService.ProcessComplaint(c.Request.Context(), req.Text)
-	if err != nil {
-		analysis = &model.AIAnalysis{
-			PriorityScore: 5,
-			Category:      "Uncategorized",
-			Summary:       "AI Analysis Failed",
-			IsProcessed:   false,
+	var course *model.CourseSnapshot
+	if req.CourseID != "" {
+		course, err = h.courses.SnapshotForStudent(c.Request.Context(), req.CourseID, uid)
+		if errors.Is(err, service.ErrNotEnrolled) || errors.Is(err, service.ErrCourseNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data mata kuliah"})
+			return
 		}
 	}
 
-	newConv := model.Conversation{
-		StudentId:   uid,
+	conv, err := h.complaints.Create(c.Request.Context(), service.ComplaintInput{
+		StudentID:   uid,
 		StudentName: req.StudentName,
-		Status:      "open",
-		LastMessage: req.Text,
-		AIAnalysis:  *analysis,
-		Messages: []model.Message{
-			{
-				Sender:    "student",
-				Text:      req.Text,
-				Timestamp: time.Now(),
-			},
-		},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Text:        req.Text,
+		Files:       service.MultipartUploads(files),
+		Channel:     model.ChannelWeb,
+		Course:      course,
+	})
+	if errors.Is(err, service.ErrInvalidAttachment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	ref, _, err := h.firestoreClient.Collection("conversations").Add(c.Request.Context(), newConv)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Faied to save complaint"})
 		return
@@ -77,7 +74,42 @@ func (h *StudentHandler) SubmitComplaint(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"success":   true,
 		"message":   "Complaint submitted and analyzed",
-		"ticket_id": ref.ID,
+		"ticket_id": conv.ID,
 	})
 
 }
+
+type CSATRequest struct {
+	Rating  int    `json:"rating" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// SubmitCSAT - Mahasiswa mengisi survei kepuasan tiket yang sudah resolved
+func (h *StudentHandler) SubmitCSAT(c *gin.Context) {
+	uid := c.GetString("user_id")
+	var req CSATRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating wajib diisi"})
+		return
+	}
+
+	csat, err := h.csat.Submit(c.Request.Context(), c.Param("id"), func(conv *model.Conversation) bool {
+		return conv.StudentId == uid
+	}, req.Rating, req.Comment)
+	respondCSAT(c, csat, err)
+}
+
+func respondCSAT(c *gin.Context, csat *model.CSAT, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCSAT):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+	case errors.Is(err, service.ErrCSATNotRequested), errors.Is(err, service.ErrCSATSubmitted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan survei kepuasan"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"success": true, "csat": csat})
+	}
+}
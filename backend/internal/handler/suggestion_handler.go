@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+	"github.com/gin-gonic/gin"
+)
+
+type SuggestionHandler struct {
+	firestoreClient *firestore.Client
+	aiService       *service.AIService
+	feedback        *service.SuggestionFeedbackService
+	settings        *service.SuggestionSettingsService
+}
+
+func NewSuggestionHandler(client *firestore.Client, aiSvc *service.AIService, feedback *service.SuggestionFeedbackService, settings *service.SuggestionSettingsService) *SuggestionHandler {
+	return &SuggestionHandler{firestoreClient: client, aiService: aiSvc, feedback: feedback, settings: settings}
+}
+
+// GetSettings - Pengaturan persona & tone draf balasan untuk satu tim
+func (h *SuggestionHandler) GetSettings(c *gin.Context) {
+	settings, err := h.settings.Get(c.Request.Context(), c.Param("team"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil pengaturan draf balasan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+type SuggestionSettingsRequest struct {
+	PersonaName      string   `json:"persona_name" binding:"required"`
+	PersonaRole      string   `json:"persona_role"`
+	Signature        string   `json:"signature"`
+	ForbiddenPhrases []string `json:"forbidden_phrases"`
+	Tones            []string `json:"tones" binding:"required"`
+	Count            int      `json:"count"` // 0 = satu draf per tone
+}
+
+// SaveSettings - Support lead mengatur persona, tanda tangan, frasa terlarang, tone, dan jumlah draf
+func (h *SuggestionHandler) SaveSettings(c *gin.Context) {
+	var req SuggestionSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.settings.Save(c.Request.Context(), model.SuggestionSettings{
+		Team:             c.Param("team"),
+		PersonaName:      req.PersonaName,
+		PersonaRole:      req.PersonaRole,
+		Signature:        req.Signature,
+		ForbiddenPhrases: req.ForbiddenPhrases,
+		Tones:            req.Tones,
+		Count:            req.Count,
+		UpdatedBy:        c.GetString("user_id"),
+	})
+	if errors.Is(err, service.ErrInvalidSuggestionSettings) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan pengaturan draf balasan: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "settings": settings})
+}
+
+// StreamSuggestions - Draf balasan AI dikirim lewat Server-Sent Events begitu token diterima.
+// Event: "delta" (isi draf sejauh ini), "suggestion" (draf lengkap), lalu "done" atau "error".
+func (h *SuggestionHandler) StreamSuggestions(c *gin.Context) {
+	id := c.Param("id")
+	doc, err := h.firestoreClient.Collection("conversations").Doc(id).Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		return
+	}
+	var conv model.Conversation
+	doc.DataTo(&conv)
+
+	language := conv.Language
+	if language == "" {
+		language = service.DetectLanguage(conv.LastMessage)
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	//context request dibatalkan saat agent menutup halaman, pemanggilan AI ikut berhenti
+	ctx := c.Request.Context()
+	emit := func(ev service.SuggestionEvent) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.SSEvent(ev.Type, ev)
+		c.Writer.Flush()
+		return nil
+	}
+
+	//tiket krisis hanya boleh memakai template balasan yang sudah disetujui
+	if conv.Flag == service.ModerationCrisis {
+		suggestions := service.SafeResponseIDs(id, conv.Version, service.SafeResponseSuggestions(language))
+		for i, sug := range suggestions {
+			emit(service.SuggestionEvent{Type: service.SuggestionComplete, Index: i, Suggestion: sug})
+		}
+		h.track(c, id, suggestions)
+		c.SSEvent("done", gin.H{"suggestions": suggestions, "language": language, "safe_response": true})
+		return
+	}
+
+	settings, err := h.settings.Get(ctx, c.GetString("user_team"))
+	if err != nil {
+		c.SSEvent("error", gin.H{"error": "Gagal mengambil pengaturan draf balasan"})
+		return
+	}
+
+	ctx = service.WithCallInfo(ctx, service.CallInfo{ConversationID: id, UserID: c.GetString("user_id")})
+	suggestions, err := h.aiService.StreamSuggestions(ctx, id, conv, language, settings, emit)
+	switch {
+	case c.Request.Context().Err() != nil:
+		//klien sudah pergi, tidak ada yang perlu dikirim
+		return
+	case errors.Is(err, service.ErrBudgetExceeded):
+		c.SSEvent("done", gin.H{"suggestions": []model.Suggestion{}, "language": language, "skipped": true, "reason": err.Error()})
+		return
+	case errors.Is(err, ai.ErrUnavailable):
+		c.SSEvent("error", gin.H{"error": "Layanan AI sedang tidak tersedia, silakan coba lagi nanti"})
+		return
+	case err != nil:
+		c.SSEvent("error", gin.H{"error": "Gagal menghasilkan saran AI: " + err.Error()})
+		return
+	}
+
+	h.track(c, id, suggestions)
+	c.SSEvent("done", gin.H{"suggestions": suggestions, "language": language})
+}
+
+func (h *SuggestionHandler) track(c *gin.Context, convID string, suggestions []model.Suggestion) {
+	if err := h.feedback.Track(c.Request.Context(), convID, suggestions); err != nil {
+		log.Printf("gagal mencatat draf balasan %s: %v", convID, err)
+	}
+}
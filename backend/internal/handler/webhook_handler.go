@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(ws *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: ws}
+}
+
+type SaveWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"` // default true
+}
+
+func (r SaveWebhookRequest) toModel() model.Webhook {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return model.Webhook{URL: r.URL, Events: r.Events, Description: r.Description, Active: active}
+}
+
+// ListWebhooks - Daftar langganan webhook (tanpa secret)
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// CreateWebhook - Mendaftarkan webhook baru, secret HMAC hanya ditampilkan sekali
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req SaveWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w := req.toModel()
+	w.CreatedBy = c.GetString("user_id")
+	created, err := h.webhookService.Create(c.Request.Context(), w)
+	if errors.Is(err, service.ErrInvalidWebhook) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan webhook"})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var req SaveWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.webhookService.Update(c.Request.Context(), c.Param("id"), req.toModel())
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook tidak ditemukan"})
+	case errors.Is(err, service.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan webhook"})
+	default:
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	err := h.webhookService.Delete(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// RotateSecret - Mengganti secret HMAC webhook
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	updated, err := h.webhookService.RotateSecret(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengganti secret webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetDeliveries - Riwayat pengiriman webhook, ?status=dead untuk dead-letter
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit harus antara 1 dan 200"})
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), c.Query("status"), c.Query("webhook_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil pengiriman webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// ReplayDelivery - Mengirim ulang pengiriman webhook dengan payload yang sama
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	delivery, err := h.webhookService.Replay(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, service.ErrDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pengiriman webhook tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim ulang webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
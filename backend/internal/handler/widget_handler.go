@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type WidgetHandler struct {
+	widget *service.WidgetService
+	csat   *service.CSATService
+}
+
+func NewWidgetHandler(widget *service.WidgetService, csat *service.CSATService) *WidgetHandler {
+	return &WidgetHandler{widget: widget, csat: csat}
+}
+
+type WidgetMessageRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// CreateSession membuat sesi pengunjung anonim, token dipakai di header X-Widget-Token
+func (h *WidgetHandler) CreateSession(c *gin.Context) {
+	var req struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	//body boleh kosong
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format data tidak valid"})
+			return
+		}
+	}
+
+	sess, err := h.widget.CreateSession(c.Request.Context(), req.Name, req.Email, c.ClientIP(), c.Request.UserAgent())
+	if errors.Is(err, service.ErrWidgetRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat sesi widget"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"session_id": sess.ID,
+		"token":      sess.Token,
+		"expires_at": sess.ExpiresAt,
+	})
+}
+
+func (h *WidgetHandler) CreateConversation(c *gin.Context) {
+	var req WidgetMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text wajib diisi"})
+		return
+	}
+
+	conv, err := h.widget.CreateConversation(c.Request.Context(), widgetSession(c), req.Text)
+	switch {
+	case errors.Is(err, service.ErrWidgetRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrWidgetLimit):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan keluhan"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"ticket_id": conv.ID,
+	})
+}
+
+func (h *WidgetHandler) ListConversations(c *gin.Context) {
+	convs, err := h.widget.ListConversations(c.Request.Context(), widgetSession(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data percakapan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"conversations": convs})
+}
+
+func (h *WidgetHandler) GetConversation(c *gin.Context) {
+	conv, err := h.widget.GetConversation(c.Request.Context(), widgetSession(c), c.Param("id"))
+	if errors.Is(err, service.ErrConversationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membaca data"})
+		return
+	}
+	c.JSON(http.StatusOK, conv)
+}
+
+func (h *WidgetHandler) ReplyConversation(c *gin.Context) {
+	var req WidgetMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text wajib diisi"})
+		return
+	}
+
+	msg, err := h.widget.Reply(c.Request.Context(), widgetSession(c), c.Param("id"), req.Text)
+	switch {
+	case errors.Is(err, service.ErrWidgetRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Percakapan tidak ditemukan"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim pesan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": msg})
+}
+
+// SubmitCSAT - Pengunjung mengisi survei kepuasan tiket yang sudah resolved
+func (h *WidgetHandler) SubmitCSAT(c *gin.Context) {
+	var req CSATRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating wajib diisi"})
+		return
+	}
+
+	sess := widgetSession(c)
+	csat, err := h.csat.Submit(c.Request.Context(), c.Param("id"), func(conv *model.Conversation) bool {
+		return conv.WidgetSessionID == sess.ID && conv.StudentId == ""
+	}, req.Rating, req.Comment)
+	respondCSAT(c, csat, err)
+}
+
+// LinkSession menautkan sesi widget ke akun mahasiswa yang sedang login
+func (h *WidgetHandler) LinkSession(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token wajib diisi"})
+		return
+	}
+
+	linked, err := h.widget.Link(c.Request.Context(), req.Token, c.GetString("user_id"))
+	switch {
+	case errors.Is(err, service.ErrInvalidWidgetSession):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrWidgetSessionLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrWidgetDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menautkan sesi widget"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "linked_conversations": linked})
+}
+
+func widgetSession(c *gin.Context) *model.WidgetSession {
+	return c.MustGet("widget_session").(*model.WidgetSession)
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAPIKey mengotentikasi sistem luar dengan API key (header X-API-Key atau
+// "Authorization: Bearer aik_...") dan mewajibkan scope tertentu
+func RequireAPIKey(keys *service.APIKeyService, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key diperlukan"})
+			return
+		}
+
+		apiKey, err := keys.Authenticate(c.Request.Context(), key, c.ClientIP())
+		if errors.Is(err, service.ErrInvalidAPIKey) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Gagal memverifikasi API key"})
+			return
+		}
+		if !service.HasScope(apiKey, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access Denied: API key tidak memiliki scope " + scope})
+			return
+		}
+
+		c.Set("api_key", apiKey)
+		c.Next()
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit membatasi request per key (misal IP atau sesi widget), membalas 429 dengan Retry-After
+func RateLimit(l *ratelimit.Limiter, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, retryAfter := l.Allow(key(c))
+		if !ok {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Terlalu banyak permintaan, coba lagi dalam %d detik", seconds)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClientIP adalah key RateLimit berdasarkan alamat IP pengirim
+func ClientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
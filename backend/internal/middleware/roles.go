@@ -3,12 +3,19 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 )
 
 func RequireRole(role string, f *firestore.Client) gin.HandlerFunc {
+	return RequireAnyRole(f, role)
+}
+
+// RequireAnyRole mengizinkan user dengan salah satu role yang diberikan,
+// misal support lead juga boleh mengakses endpoint customer support
+func RequireAnyRole(f *firestore.Client, roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		uid, exists := c.Get("user_id")
 		if !exists {
@@ -29,12 +36,17 @@ func RequireRole(role string, f *firestore.Client) gin.HandlerFunc {
 			return
 		}
 
-		if roleData != role {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Acces Denied: You are not a " + role})
-			return
+		for _, role := range roles {
+			if roleData == role {
+				c.Set("user_role", roleData)
+				//tim agent (opsional), dipakai untuk pengaturan draf balasan per tim
+				team, _ := data["team"].(string)
+				c.Set("user_team", team)
+				c.Next()
+				return
+			}
 		}
 
-		c.Next()
-
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Acces Denied: You are not a " + strings.Join(roles, " / ")})
 	}
 }
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireWidgetSession mengotentikasi pengunjung widget dengan token sesi (header
+// X-Widget-Token atau "Authorization: Bearer wgt_...")
+func RequireWidgetSession(widget *service.WidgetService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("X-Widget-Token")
+		if token == "" {
+			token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token sesi widget diperlukan"})
+			return
+		}
+
+		sess, err := widget.Authenticate(c.Request.Context(), token)
+		switch {
+		case errors.Is(err, service.ErrInvalidWidgetSession), errors.Is(err, service.ErrWidgetSessionLinked):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, service.ErrWidgetDisabled):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Gagal memverifikasi sesi widget"})
+			return
+		}
+
+		c.Set("widget_session", sess)
+		c.Next()
+	}
+}
+
+// WidgetSessionID adalah key RateLimit berdasarkan sesi widget, dipasang setelah RequireWidgetSession
+func WidgetSessionID(c *gin.Context) string {
+	if sess, ok := c.Get("widget_session"); ok {
+		return sess.(*model.WidgetSession).ID
+	}
+	return c.ClientIP()
+}
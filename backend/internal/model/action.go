@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// jenis aksi yang bisa direkomendasikan AI dan dijalankan agent dengan satu panggilan
+const (
+	ActionSetStatus   = "set_status"   // ubah status tiket (open, in_progress, resolved)
+	ActionAssign      = "assign"       // alihkan ke tim lain, misal eskalasi ke finance
+	ActionApplyMacro  = "apply_macro"  // kirim balasan macro beserta perubahan status/tim-nya
+	ActionRequestInfo = "request_info" // minta data ke mahasiswa, misal NIM
+)
+
+// status tiket
+const (
+	StatusOpen       = "open"
+	StatusInProgress = "in_progress"
+	StatusResolved   = "resolved"
+)
+
+// Macro adalah balasan siap pakai beserta perubahan tiket yang menyertainya,
+// disimpan di collection macros
+type Macro struct {
+	ID          string    `json:"id" firestore:"-"`
+	Name        string    `json:"name" firestore:"name"`
+	Description string    `json:"description" firestore:"description"` // kapan macro dipakai, dibaca juga oleh AI
+	Text        string    `json:"text" firestore:"text"`
+	SetStatus   string    `json:"set_status,omitempty" firestore:"set_status,omitempty"`
+	AssignTeam  string    `json:"assign_team,omitempty" firestore:"assign_team,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty" firestore:"updated_by"`
+	UpdatedAt   time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// Action adalah satu aksi yang bisa dijalankan, field yang dipakai tergantung Type
+type Action struct {
+	Type    string `json:"type" firestore:"type"`
+	Status  string `json:"status,omitempty" firestore:"status,omitempty"`
+	Team    string `json:"team,omitempty" firestore:"team,omitempty"`
+	MacroID string `json:"macro_id,omitempty" firestore:"macro_id,omitempty"`
+	Text    string `json:"text,omitempty" firestore:"text,omitempty"` // pesan ke mahasiswa untuk request_info
+}
+
+// ActionRecommendation adalah aksi hasil rekomendasi AI beserta alasannya
+type ActionRecommendation struct {
+	Action
+	Label      string  `json:"label"`
+	Reason     string  `json:"reason"`
+	Confidence float64 `json:"confidence"`
+}
+
+// jejak aksi yang dijalankan, disimpan di subcollection conversations/{id}/actions
+type ActionLog struct {
+	Action     Action    `json:"action" firestore:"action"`
+	AgentID    string    `json:"agent_id" firestore:"agent_id"`
+	ExecutedAt time.Time `json:"executed_at" firestore:"executed_at"`
+}
@@ -0,0 +1,29 @@
+package model
+
+type AIUsage struct {
+	Calls           int64   `json:"calls" firestore:"calls"`
+	PromptTokens    int64   `json:"prompt_tokens" firestore:"prompt_tokens"`
+	CandidateTokens int64   `json:"candidate_tokens" firestore:"candidate_tokens"`
+	TotalTokens     int64   `json:"total_tokens" firestore:"total_tokens"`
+	CostUSD         float64 `json:"cost_usd" firestore:"cost_usd"`
+}
+
+// rollup harian, disimpan di ai_usage_daily/{YYYY-MM-DD}
+type DailyAIUsage struct {
+	Date     string             `json:"date" firestore:"date"`
+	Total    AIUsage            `json:"total" firestore:"total"`
+	Features map[string]AIUsage `json:"features" firestore:"features"` // analysis, summary, suggestions, translation
+	Agents   map[string]AIUsage `json:"agents" firestore:"agents"`     // per user yang memicu pemanggilan
+}
+
+type AIBudgetStatus struct {
+	Feature  string `json:"feature"` // "total" untuk budget keseluruhan
+	Limit    int64  `json:"limit"`
+	Used     int64  `json:"used"`
+	Exceeded bool   `json:"exceeded"`
+}
+
+type AIUsageReport struct {
+	Days    []DailyAIUsage   `json:"days"`
+	Budgets []AIBudgetStatus `json:"budgets"`
+}
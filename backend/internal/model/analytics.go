@@ -10,3 +10,56 @@ type DailyTicketStats struct {
 	Date  string `json:"date"`
 	Count int    `json:"count"`
 }
+
+// akurasi label AI dibanding koreksi agent
+type AccuracyStats struct {
+	Total              int     `json:"total"`
+	Corrected          int     `json:"corrected"`
+	CategoryCorrected  int     `json:"category_corrected"`
+	PriorityCorrected  int     `json:"priority_corrected"`
+	SentimentCorrected int     `json:"sentiment_corrected"`
+	CategoryAccuracy   float64 `json:"category_accuracy"`
+	PriorityMAE        float64 `json:"priority_mae"`
+	SentimentAccuracy  float64 `json:"sentiment_accuracy"`
+
+	priorityErr int
+}
+
+type WeeklyAccuracy struct {
+	WeekStart  string                    `json:"week_start"`
+	Overall    AccuracyStats             `json:"overall"`
+	Categories map[string]*AccuracyStats `json:"categories"`
+}
+
+type AIAccuracyReport struct {
+	Overall        AccuracyStats             `json:"overall"`
+	Categories     map[string]*AccuracyStats `json:"categories"`
+	PromptVersions map[string]*AccuracyStats `json:"prompt_versions"`
+	Weeks          []WeeklyAccuracy          `json:"weeks"`
+}
+
+// Add mencatat satu tiket, ai dan human adalah label sebelum dan sesudah koreksi
+func (a *AccuracyStats) Add(ai AnalysisLabels, human *AnalysisLabels) {
+	a.Total++
+	if human != nil {
+		a.Corrected++
+		if human.Category != ai.Category {
+			a.CategoryCorrected++
+		}
+		if human.PriorityScore != ai.PriorityScore {
+			a.PriorityCorrected++
+			diff := human.PriorityScore - ai.PriorityScore
+			if diff < 0 {
+				diff = -diff
+			}
+			a.priorityErr += diff
+		}
+		if human.Sentiment != ai.Sentiment {
+			a.SentimentCorrected++
+		}
+	}
+	total := float64(a.Total)
+	a.CategoryAccuracy = 1 - float64(a.CategoryCorrected)/total
+	a.SentimentAccuracy = 1 - float64(a.SentimentCorrected)/total
+	a.PriorityMAE = float64(a.priorityErr) / total
+}
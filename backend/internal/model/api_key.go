@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// izin yang bisa diberikan ke API key integrasi
+const (
+	ScopeConversationsCreate = "conversations:create" // membuka tiket atas nama mahasiswa
+	ScopeConversationsRead   = "conversations:read"   // membaca status tiket yang dibuat key ini
+)
+
+// APIKey adalah kredensial layanan (misal LMS) yang disimpan di collection api_keys.
+// Hanya hash SHA-256 secret yang disimpan, key utuh ditampilkan sekali saat dibuat/rotate.
+type APIKey struct {
+	ID        string   `json:"id" firestore:"-"`
+	Name      string   `json:"name" firestore:"name"`
+	Prefix    string   `json:"prefix" firestore:"prefix"` // awal key untuk dikenali di dashboard, misal "aik_AbC123_9f2e"
+	Hash      string   `json:"-" firestore:"hash"`
+	Scopes    []string `json:"scopes" firestore:"scopes"`
+	CreatedBy string   `json:"created_by" firestore:"created_by"`
+
+	//setelah rotate, secret lama masih berlaku sampai PreviousExpiresAt
+	PreviousHash      string     `json:"-" firestore:"previous_hash,omitempty"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at,omitempty" firestore:"previous_expires_at,omitempty"`
+
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" firestore:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" firestore:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" firestore:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty" firestore:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" firestore:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty" firestore:"rotated_at,omitempty"`
+
+	Key string `json:"key,omitempty" firestore:"-"` // key utuh, hanya terisi saat dibuat/rotate
+}
+
+// TicketContext adalah konteks terstruktur dari sistem luar yang membuka tiket,
+// misal submission kuis yang gagal di LMS
+type TicketContext struct {
+	Source       string            `json:"source" firestore:"source"` // nama API key / sistem pengirim
+	CourseID     string            `json:"course_id,omitempty" firestore:"course_id,omitempty"`
+	AssignmentID string            `json:"assignment_id,omitempty" firestore:"assignment_id,omitempty"`
+	ErrorCode    string            `json:"error_code,omitempty" firestore:"error_code,omitempty"`
+	URL          string            `json:"url,omitempty" firestore:"url,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty" firestore:"metadata,omitempty"`
+	APIKeyID     string            `json:"api_key_id,omitempty" firestore:"api_key_id,omitempty"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// AutoClosePolicy mengatur pengingat dan penutupan otomatis tiket in_progress yang menunggu
+// balasan mahasiswa, per kategori. Disimpan di auto_close_policies/{category}.
+type AutoClosePolicy struct {
+	Category     string    `json:"category" firestore:"-"`
+	Enabled      bool      `json:"enabled" firestore:"enabled"`
+	ReminderDays int       `json:"reminder_days" firestore:"reminder_days"` // 0 = tanpa pengingat
+	CloseDays    int       `json:"close_days" firestore:"close_days"`
+	UpdatedBy    string    `json:"updated_by,omitempty" firestore:"updated_by"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty" firestore:"updated_at"`
+}
+
+// jenis kejadian penutupan otomatis
+const (
+	AutoCloseReminder = "reminder"
+	AutoCloseClosed   = "closed"
+)
+
+// AutoCloseEvent dicatat di auto_close_events untuk laporan analytics
+type AutoCloseEvent struct {
+	ConversationID string    `json:"conversation_id" firestore:"conversation_id"`
+	Type           string    `json:"type" firestore:"type"`
+	Category       string    `json:"category" firestore:"category"`
+	IdleDays       int       `json:"idle_days" firestore:"idle_days"` // lama menunggu balasan saat kejadian
+	At             time.Time `json:"at" firestore:"at"`
+}
+
+// AutoCloseSweep adalah hasil satu kali pemeriksaan scheduler
+type AutoCloseSweep struct {
+	Checked  int `json:"checked"`
+	Reminded int `json:"reminded"`
+	Closed   int `json:"closed"`
+}
+
+type AutoCloseStats struct {
+	Reminders  int `json:"reminders"`
+	AutoClosed int `json:"auto_closed"`
+}
+
+type DailyAutoClose struct {
+	Date string `json:"date"`
+	AutoCloseStats
+}
+
+type AutoCloseReport struct {
+	Days       int                        `json:"days"`
+	Overall    AutoCloseStats             `json:"overall"`
+	Categories map[string]*AutoCloseStats `json:"categories"`
+	Daily      []DailyAutoClose           `json:"daily"`
+}
+
+// Add mencatat satu kejadian
+func (s *AutoCloseStats) Add(e AutoCloseEvent) {
+	switch e.Type {
+	case AutoCloseReminder:
+		s.Reminders++
+	case AutoCloseClosed:
+		s.AutoClosed++
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// channel tempat pesan dikirim/diterima, dicatat di setiap Message
+const (
+	ChannelWeb      = "web"
+	ChannelEmail    = "email"
+	ChannelAPI      = "api"
+	ChannelTelegram = "telegram"
+	ChannelWidget   = "widget"
+)
+
+// ChatThread menandai percakapan yang dibuka dari aplikasi chat, balasan agent diteruskan ke ChatID
+type ChatThread struct {
+	Channel  string `json:"channel" firestore:"channel"`
+	ChatID   string `json:"chat_id" firestore:"chat_id"`
+	UserID   string `json:"user_id" firestore:"user_id"`
+	Username string `json:"username,omitempty" firestore:"username,omitempty"`
+}
+
+// ChatLink menghubungkan akun aplikasi chat dengan mahasiswa,
+// disimpan di chat_links/{channel}_{user_id}
+type ChatLink struct {
+	Channel   string    `json:"channel" firestore:"channel"`
+	UserID    string    `json:"user_id" firestore:"user_id"`
+	ChatID    string    `json:"-" firestore:"chat_id"`
+	Username  string    `json:"username,omitempty" firestore:"username,omitempty"`
+	StudentID string    `json:"-" firestore:"student_id"`
+	LinkedAt  time.Time `json:"linked_at" firestore:"linked_at"`
+
+	// percakapan yang menerima pesan chat berikutnya, kosong = pesan berikutnya membuka tiket baru
+	ActiveConversationID string `json:"active_conversation_id,omitempty" firestore:"active_conversation_id,omitempty"`
+}
+
+// ChatLinkCode adalah kode sekali pakai yang dikirim mahasiswa ke bot untuk menautkan akunnya,
+// disimpan di chat_link_codes/{code}
+type ChatLinkCode struct {
+	Code      string    `json:"code" firestore:"-"`
+	Channel   string    `json:"channel" firestore:"channel"`
+	StudentID string    `json:"-" firestore:"student_id"`
+	ExpiresAt time.Time `json:"expires_at" firestore:"expires_at"`
+	DeepLink  string    `json:"deep_link,omitempty" firestore:"-"` // misal https://t.me/<bot>?start=<code>
+}
@@ -2,10 +2,34 @@ package model
 
 import "time"
 
+// lampiran pesan, file-nya disimpan di blob storage dengan key StorageKey
+type Attachment struct {
+	ID          string    `json:"id" firestore:"id"`
+	FileName    string    `json:"file_name" firestore:"file_name"`
+	ContentType string    `json:"content_type" firestore:"content_type"`
+	Size        int64     `json:"size" firestore:"size"`
+	StorageKey  string    `json:"-" firestore:"storage_key"`
+	UploadedBy  string    `json:"uploaded_by" firestore:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at" firestore:"created_at"`
+}
+
 type Message struct {
-	Sender    string    `json:"sender" firestore:"sender"` // "student" atau "support"
+	Sender    string    `json:"sender" firestore:"sender"` // "student", "support", atau "system" (pesan otomatis)
 	Text      string    `json:"text" firestore:"text"`
 	Timestamp time.Time `json:"timestamp" firestore:"timestamp"`
+	Language  string    `json:"language,omitempty" firestore:"language,omitempty"` // kode ISO 639-1, hasil deteksi otomatis
+	Channel   string    `json:"channel,omitempty" firestore:"channel,omitempty"`   // "web", "email", "api", "telegram", "widget"
+
+	// teks asli agent sebelum diterjemahkan ke bahasa mahasiswa
+	OriginalText string `json:"original_text,omitempty" firestore:"original_text,omitempty"`
+
+	Attachments []Attachment `json:"attachments,omitempty" firestore:"attachments,omitempty"`
+}
+
+// pesan dengan terjemahan untuk tampilan agent, tidak disimpan di firestore
+type TranslatedMessage struct {
+	Message
+	TranslatedText string `json:"translated_text"`
 }
 
 type AIAnalysis struct {
@@ -14,7 +38,49 @@ type AIAnalysis struct {
 	PriorityScore int    `json:"priority_score" firestore:"priority_score"`
 	Reason        string `json:"reason" firestore:"reason"`
 	Sentiment     string `json:"sentiment" firestore:"sentiment"`
+	Moderation    string `json:"moderation" firestore:"moderation"` // "none", "abusive", "crisis"
 	IsProcessed   bool   `json:"is_processed" firestore:"is_processed"`
+	Overridden    bool   `json:"overridden" firestore:"overridden"` // category/priority/sentiment sudah dikoreksi agent
+
+	// teks/error yang dibaca model dari screenshot lampiran, kosong jika analisis teks saja
+	ImageFindings string `json:"image_findings,omitempty" firestore:"image_findings,omitempty"`
+
+	Version       int       `json:"version" firestore:"version"`
+	Model         string    `json:"model" firestore:"model"`
+	PromptVersion string    `json:"prompt_version" firestore:"prompt_version"`
+	MessageCount  int       `json:"message_count" firestore:"message_count"` // jumlah pesan yang sudah tercakup di analisis ini
+	GeneratedAt   time.Time `json:"generated_at" firestore:"generated_at"`
+}
+
+type AnalysisLabels struct {
+	Category      string `json:"category" firestore:"category"`
+	PriorityScore int    `json:"priority_score" firestore:"priority_score"`
+	Sentiment     string `json:"sentiment" firestore:"sentiment"`
+}
+
+// koreksi agent atas hasil AI, nilai AI aslinya tetap disimpan
+type AnalysisOverride struct {
+	AI        AnalysisLabels `json:"ai" firestore:"ai"`
+	Human     AnalysisLabels `json:"human" firestore:"human"`
+	Reason    string         `json:"reason" firestore:"reason"`
+	AgentID   string         `json:"agent_id" firestore:"agent_id"`
+	CreatedAt time.Time      `json:"created_at" firestore:"created_at"`
+}
+
+// satu koreksi, disimpan di analysis_corrections sebagai dataset berlabel
+type AnalysisCorrection struct {
+	ConversationID string `json:"conversation_id" firestore:"conversation_id"`
+	Text           string `json:"text" firestore:"text"` // keluhan awal mahasiswa
+	Model          string `json:"model" firestore:"model"`
+	PromptVersion  string `json:"prompt_version" firestore:"prompt_version"`
+	AnalysisOverride
+}
+
+// riwayat analisis, disimpan di subcollection conversations/{id}/analyses
+type AnalysisRecord struct {
+	Analysis  AIAnalysis `json:"analysis" firestore:"analysis"`
+	Trigger   string     `json:"trigger" firestore:"trigger"` // "initial", "auto", "manual"
+	CreatedAt time.Time  `json:"created_at" firestore:"created_at"`
 }
 
 type Conversation struct {
@@ -23,14 +89,46 @@ type Conversation struct {
 	StudentName  string     `json:"student_name" firestore:"student_name"`
 	StudentEmail string     `json:"student_email" firestore:"student_email"`
 	LastMessage  string     `json:"last_message" firestore:"last_message"`
-	Status       string     `json:"status" firestore:"status"` // "open", "in_progress", "resolved"
+	Status       string     `json:"status" firestore:"status"`       // "open", "in_progress", "resolved"
+	Language     string     `json:"language" firestore:"language"`   // bahasa terakhir yang dipakai mahasiswa
+	Flag         string     `json:"flag,omitempty" firestore:"flag"` // "crisis" dipin di atas inbox, "abusive"
+	FlagReason   string     `json:"flag_reason,omitempty" firestore:"flag_reason"`
+	AssignedTeam string     `json:"assigned_team,omitempty" firestore:"assigned_team,omitempty"` // tim yang menangani, misal "finance"
 	Messages     []Message  `json:"messages" firestore:"messages"`
+	Version      int64      `json:"version" firestore:"version"` // naik setiap ada pesan baru, dipakai sebagai kunci cache AI
 	AIAnalysis   AIAnalysis `json:"ai_analysis" firestore:"ai_analysis"`
-	CreatedAt    time.Time  `json:"created_at" firestore:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at" firestore:"updated_at"`
+
+	HumanOverride *AnalysisOverride `json:"human_override,omitempty" firestore:"human_override,omitempty"`
+	CreatedAt     time.Time         `json:"created_at" firestore:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" firestore:"updated_at"`
+	ResolvedAt    *time.Time        `json:"resolved_at,omitempty" firestore:"resolved_at,omitempty"`
+
+	// Message-ID email yang termasuk percakapan ini, untuk threading balasan via email
+	EmailMessageIDs []string `json:"-" firestore:"email_message_ids,omitempty"`
+
+	// konteks terstruktur jika tiket dibuka sistem luar lewat API integrasi
+	Context *TicketContext `json:"context,omitempty" firestore:"context,omitempty"`
+
+	// mata kuliah yang dipilih mahasiswa beserta tenggat terdekat saat tiket dibuat
+	Course *CourseSnapshot `json:"course,omitempty" firestore:"course,omitempty"`
+
+	// chat asal jika tiket dibuka dari aplikasi chat, balasan agent diteruskan ke sana
+	Chat *ChatThread `json:"chat,omitempty" firestore:"chat,omitempty"`
+
+	// sesi widget pengunjung anonim, student_id kosong sampai pengunjung mendaftar
+	WidgetSessionID string `json:"widget_session_id,omitempty" firestore:"widget_session_id,omitempty"`
+
+	// survei kepuasan, diminta saat tiket resolved
+	CSAT *CSAT `json:"csat,omitempty" firestore:"csat,omitempty"`
+
+	// pengingat terakhir saat tiket menunggu balasan mahasiswa, dan waktu tiket ditutup otomatis
+	ReminderSentAt *time.Time `json:"reminder_sent_at,omitempty" firestore:"reminder_sent_at,omitempty"`
+	AutoClosedAt   *time.Time `json:"auto_closed_at,omitempty" firestore:"auto_closed_at,omitempty"`
 }
 
 type Suggestion struct {
-	Tone    string `json:"tone"`
-	Content string `json:"content"`
+	ID            string `json:"id"`
+	Tone          string `json:"tone"`
+	Content       string `json:"content"`
+	PromptVersion string `json:"prompt_version,omitempty"`
 }
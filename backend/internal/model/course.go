@@ -0,0 +1,95 @@
+package model
+
+import "time"
+
+// Course disimpan di collection courses dengan ID dokumen = kode mata kuliah (misal "IF2110")
+type Course struct {
+	ID        string           `json:"id" firestore:"-"`
+	Name      string           `json:"name" firestore:"name"`
+	Lecturer  string           `json:"lecturer,omitempty" firestore:"lecturer,omitempty"`
+	Deadlines []CourseDeadline `json:"deadlines" firestore:"deadlines"`
+	CreatedAt time.Time        `json:"created_at" firestore:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at" firestore:"updated_at"`
+}
+
+// tenggat tugas/kuis/ujian, judul unik dalam satu mata kuliah
+type CourseDeadline struct {
+	Title string    `json:"title" firestore:"title"`
+	Type  string    `json:"type,omitempty" firestore:"type,omitempty"` // misal "tugas", "kuis", "ujian"
+	DueAt time.Time `json:"due_at" firestore:"due_at"`
+}
+
+// Enrollment disimpan di collection enrollments dengan ID dokumen {course_id}_{student_id}
+type Enrollment struct {
+	CourseID  string    `json:"course_id" firestore:"course_id"`
+	StudentID string    `json:"student_id" firestore:"student_id"`
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+}
+
+// CourseSnapshot adalah mata kuliah beserta tenggat terdekat saat tiket dibuat,
+// disalin ke percakapan agar agent melihat konteks yang sama dengan AI
+type CourseSnapshot struct {
+	ID        string           `json:"id" firestore:"id"`
+	Name      string           `json:"name" firestore:"name"`
+	Deadlines []CourseDeadline `json:"upcoming_deadlines,omitempty" firestore:"upcoming_deadlines,omitempty"`
+}
+
+// hasil import CSV, baris yang gagal dilaporkan tanpa membatalkan baris lain
+type CourseImportResult struct {
+	Imported int                 `json:"imported"`
+	Skipped  int                 `json:"skipped"`
+	Errors   []CourseImportError `json:"errors"`
+}
+
+type CourseImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// statistik tiket per mata kuliah, CourseID kosong = tiket tanpa mata kuliah
+type CourseStats struct {
+	CourseID        string         `json:"course_id"`
+	Name            string         `json:"name"`
+	Total           int            `json:"total"`
+	Open            int            `json:"open"`
+	Resolved        int            `json:"resolved"`
+	NearDeadline    int            `json:"near_deadline"` // dibuat <= 72 jam sebelum sebuah tenggat
+	AveragePriority float64        `json:"average_priority"`
+	Categories      map[string]int `json:"categories"`
+
+	prioritySum, priorityCount int
+}
+
+type CourseBreakdown struct {
+	Days    int           `json:"days"`
+	Courses []CourseStats `json:"courses"`
+}
+
+// Add mencatat satu tiket ke statistik mata kuliah
+func (s *CourseStats) Add(conv Conversation) {
+	s.Total++
+	switch conv.Status {
+	case StatusResolved:
+		s.Resolved++
+	case StatusOpen, StatusInProgress:
+		s.Open++
+	}
+	category := conv.AIAnalysis.Category
+	if category == "" {
+		category = "Others"
+	}
+	s.Categories[category]++
+	if conv.AIAnalysis.PriorityScore > 0 {
+		s.prioritySum += conv.AIAnalysis.PriorityScore
+		s.priorityCount++
+		s.AveragePriority = float64(s.prioritySum) / float64(s.priorityCount)
+	}
+	if conv.Course != nil {
+		for _, d := range conv.Course.Deadlines {
+			if until := d.DueAt.Sub(conv.CreatedAt); until >= 0 && until <= 72*time.Hour {
+				s.NearDeadline++
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// CSAT adalah survei kepuasan mahasiswa yang diminta saat tiket resolved,
+// disimpan di dalam dokumen percakapan
+type CSAT struct {
+	RequestedAt time.Time `json:"requested_at" firestore:"requested_at"`
+	AgentID     string    `json:"agent_id,omitempty" firestore:"agent_id,omitempty"` // agent yang menyelesaikan tiket, kosong jika otomatis
+
+	Rating      int        `json:"rating,omitempty" firestore:"rating,omitempty"` // 1-5, 0 = belum diisi
+	Comment     string     `json:"comment,omitempty" firestore:"comment,omitempty"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty" firestore:"submitted_at,omitempty"`
+
+	// hasil analisis sentimen komentar oleh AI, kosong selama belum dianalisis
+	Sentiment string   `json:"sentiment,omitempty" firestore:"sentiment,omitempty"` // "positif", "netral", "negatif"
+	Themes    []string `json:"themes,omitempty" firestore:"themes,omitempty"`
+}
+
+// CSATSentiment adalah hasil analisis komentar survei
+type CSATSentiment struct {
+	Sentiment string   `json:"sentiment"`
+	Themes    []string `json:"themes"`
+}
+
+// CSATStats merangkum survei untuk satu kelompok (agent, kategori, minggu)
+type CSATStats struct {
+	Requested    int            `json:"requested"`
+	Responses    int            `json:"responses"`
+	ResponseRate float64        `json:"response_rate"`
+	Average      float64        `json:"average_rating"`
+	Satisfied    int            `json:"satisfied"`  // rating 4-5
+	CSATScore    float64        `json:"csat_score"` // persentase satisfied dari responses
+	Ratings      map[string]int `json:"ratings"`    // distribusi rating "1".."5"
+	Sentiments   map[string]int `json:"sentiments"` // distribusi sentimen komentar
+
+	ratingSum int
+}
+
+func NewCSATStats() *CSATStats {
+	return &CSATStats{Ratings: make(map[string]int), Sentiments: make(map[string]int)}
+}
+
+// Add mencatat satu survei yang sudah diminta, terjawab atau belum
+func (s *CSATStats) Add(c CSAT) {
+	s.Requested++
+	if c.SubmittedAt != nil && c.Rating > 0 {
+		s.Responses++
+		s.ratingSum += c.Rating
+		s.Ratings[strconv.Itoa(c.Rating)]++
+		if c.Rating >= 4 {
+			s.Satisfied++
+		}
+		if c.Sentiment != "" {
+			s.Sentiments[c.Sentiment]++
+		}
+	}
+	s.ResponseRate = float64(s.Responses) / float64(s.Requested)
+	if s.Responses > 0 {
+		s.Average = float64(s.ratingSum) / float64(s.Responses)
+		s.CSATScore = 100 * float64(s.Satisfied) / float64(s.Responses)
+	}
+}
+
+type AgentCSAT struct {
+	AgentID string `json:"agent_id"` // kosong = tiket ditutup otomatis
+	Name    string `json:"name,omitempty"`
+	CSATStats
+}
+
+type WeeklyCSAT struct {
+	WeekStart string `json:"week_start"`
+	CSATStats
+}
+
+type CSATReport struct {
+	Weeks      int                   `json:"weeks"`
+	Overall    *CSATStats            `json:"overall"`
+	Agents     []AgentCSAT           `json:"agents"`
+	Categories map[string]*CSATStats `json:"categories"`
+	Weekly     []WeeklyCSAT          `json:"weekly"`
+}
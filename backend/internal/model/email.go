@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// hasil pemrosesan email masuk
+const (
+	EmailProcessing    = "processing"
+	EmailCreated       = "created"        // tiket baru dibuat
+	EmailReplied       = "replied"        // masuk sebagai balasan di tiket yang sudah ada
+	EmailAutoReply     = "auto_reply"     // auto-reply/bounce, diabaikan
+	EmailUnknownSender = "unknown_sender" // pengirim tidak terdaftar sebagai mahasiswa
+	EmailRejected      = "rejected"       // email tidak valid atau kosong
+)
+
+// EmailLog mencatat setiap email masuk di collection inbound_emails (ID = hash Message-ID),
+// sekaligus mencegah email yang sama diproses dua kali oleh IMAP dan SMTP
+type EmailLog struct {
+	ID             string    `json:"id" firestore:"-"`
+	MessageID      string    `json:"message_id" firestore:"message_id"`
+	From           string    `json:"from" firestore:"from"`
+	Subject        string    `json:"subject" firestore:"subject"`
+	Source         string    `json:"source" firestore:"source"` // "imap" atau "smtp"
+	Status         string    `json:"status" firestore:"status"`
+	ConversationID string    `json:"conversation_id,omitempty" firestore:"conversation_id,omitempty"`
+	Reason         string    `json:"reason,omitempty" firestore:"reason,omitempty"`
+	ReceivedAt     time.Time `json:"received_at" firestore:"received_at"`
+}
+
+// jenis kejadian yang dikirim ke mahasiswa lewat email
+const (
+	EmailEventAgentReply    = "agent_reply"
+	EmailEventStatusChanged = "status_changed"
+	EmailEventCSATRequest   = "csat_request" // ajakan mengisi survei kepuasan setelah resolved
+)
+
+// NotificationPreferences adalah pengaturan email notifikasi per mahasiswa,
+// disimpan di notification_preferences/{uid}
+type NotificationPreferences struct {
+	Email         bool      `json:"email" firestore:"email"` // saklar utama
+	AgentReplies  bool      `json:"agent_replies" firestore:"agent_replies"`
+	StatusChanges bool      `json:"status_changes" firestore:"status_changes"`
+	Language      string    `json:"language" firestore:"language"` // "id", "en", kosong = ikut bahasa percakapan
+	UpdatedAt     time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// EmailEvent adalah satu kejadian di tiket yang menunggu dikirim
+type EmailEvent struct {
+	Type   string    `json:"type" firestore:"type"`
+	Text   string    `json:"text,omitempty" firestore:"text,omitempty"`     // isi balasan agent
+	Status string    `json:"status,omitempty" firestore:"status,omitempty"` // status baru tiket
+	At     time.Time `json:"at" firestore:"at"`
+}
+
+// EmailOutbox menampung kejadian per percakapan di email_outbox/{conversation_id}.
+// Balasan beruntun digabung menjadi satu email setelah SendAfter.
+type EmailOutbox struct {
+	ConversationID string       `firestore:"conversation_id"`
+	StudentID      string       `firestore:"student_id"`
+	To             string       `firestore:"to"`
+	Events         []EmailEvent `firestore:"events"`
+	FirstAt        time.Time    `firestore:"first_at"`
+	SendAfter      time.Time    `firestore:"send_after"`
+	Attempts       int          `firestore:"attempts"`
+	LastError      string       `firestore:"last_error,omitempty"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type Notification struct {
+	ID             string    `json:"id" firestore:"-"`
+	UserID         string    `json:"user_id" firestore:"user_id"`
+	Type           string    `json:"type" firestore:"type"` // "crisis", "abusive", ...
+	Title          string    `json:"title" firestore:"title"`
+	Body           string    `json:"body" firestore:"body"`
+	ConversationID string    `json:"conversation_id" firestore:"conversation_id"`
+	Read           bool      `json:"read" firestore:"read"`
+	CreatedAt      time.Time `json:"created_at" firestore:"created_at"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// template prompt, disimpan di prompt_templates/{name}@{version}
+type PromptTemplate struct {
+	Name      string    `json:"name" firestore:"name"`       // "analysis", "summary", "suggestions", "translation"
+	Version   string    `json:"version" firestore:"version"` // "v1", "v2", ...
+	Text      string    `json:"text" firestore:"text"`       // sintaks text/template
+	Weight    int       `json:"weight" firestore:"weight"`   // bobot A/B split, 0 = tidak ikut split
+	Source    string    `json:"source" firestore:"-"`        // "embedded", "file", "store"
+	UpdatedBy string    `json:"updated_by,omitempty" firestore:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" firestore:"updated_at"`
+}
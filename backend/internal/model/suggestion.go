@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// hasil pemakaian draf balasan oleh agent
+const (
+	SuggestionPending  = "pending"
+	SuggestionVerbatim = "verbatim"
+	SuggestionEdited   = "edited"
+	SuggestionIgnored  = "ignored"
+)
+
+// satu draf balasan yang pernah ditampilkan ke agent, disimpan di collection suggestions
+type SuggestionRecord struct {
+	ID             string    `json:"id" firestore:"-"`
+	ConversationID string    `json:"conversation_id" firestore:"conversation_id"`
+	Tone           string    `json:"tone" firestore:"tone"`
+	Content        string    `json:"content" firestore:"content"`
+	PromptVersion  string    `json:"prompt_version" firestore:"prompt_version"`
+	Outcome        string    `json:"outcome" firestore:"outcome"`
+	AgentID        string    `json:"agent_id,omitempty" firestore:"agent_id,omitempty"`
+	EditDistance   int       `json:"edit_distance" firestore:"edit_distance"`
+	CreatedAt      time.Time `json:"created_at" firestore:"created_at"`
+	ResolvedAt     time.Time `json:"resolved_at,omitempty" firestore:"resolved_at,omitempty"`
+}
+
+type ToneAdoption struct {
+	Shown    int `json:"shown"`
+	Verbatim int `json:"verbatim"`
+	Edited   int `json:"edited"`
+	Ignored  int `json:"ignored"`
+	Pending  int `json:"pending"`
+
+	AdoptionRate    float64 `json:"adoption_rate"`     // (verbatim + edited) / yang sudah selesai
+	AvgEditDistance float64 `json:"avg_edit_distance"` // rata-rata untuk draf yang diedit
+	AvgEditRatio    float64 `json:"avg_edit_ratio"`    // edit distance dibagi panjang draf
+
+	totalEditRatio   float64
+	totalEditedChars int
+}
+
+type SuggestionAdoptionReport struct {
+	Days           int                      `json:"days"`
+	Tones          map[string]*ToneAdoption `json:"tones"`
+	PromptVersions map[string]*ToneAdoption `json:"prompt_versions"`
+}
+
+// Add mencatat satu draf ke statistik
+func (t *ToneAdoption) Add(r SuggestionRecord) {
+	t.Shown++
+	switch r.Outcome {
+	case SuggestionVerbatim:
+		t.Verbatim++
+	case SuggestionEdited:
+		t.Edited++
+		t.totalEditedChars += r.EditDistance
+		if n := len([]rune(r.Content)); n > 0 {
+			t.totalEditRatio += float64(r.EditDistance) / float64(n)
+		}
+	case SuggestionIgnored:
+		t.Ignored++
+	default:
+		t.Pending++
+	}
+
+	if resolved := t.Verbatim + t.Edited + t.Ignored; resolved > 0 {
+		t.AdoptionRate = float64(t.Verbatim+t.Edited) / float64(resolved)
+	}
+	if t.Edited > 0 {
+		t.AvgEditDistance = float64(t.totalEditedChars) / float64(t.Edited)
+		t.AvgEditRatio = t.totalEditRatio / float64(t.Edited)
+	}
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// pengaturan draf balasan AI per tim, disimpan di suggestion_settings/{team}
+type SuggestionSettings struct {
+	Team             string    `json:"team" firestore:"-"`
+	PersonaName      string    `json:"persona_name" firestore:"persona_name"`
+	PersonaRole      string    `json:"persona_role" firestore:"persona_role"`
+	Signature        string    `json:"signature" firestore:"signature"` // ditambahkan di akhir setiap draf, boleh kosong
+	ForbiddenPhrases []string  `json:"forbidden_phrases" firestore:"forbidden_phrases"`
+	Tones            []string  `json:"tones" firestore:"tones"`
+	Count            int       `json:"count" firestore:"count"` // jumlah draf, tone dipakai bergiliran
+	UpdatedBy        string    `json:"updated_by,omitempty" firestore:"updated_by"`
+	UpdatedAt        time.Time `json:"updated_at,omitempty" firestore:"updated_at"`
+}
+
+// ExpectedTones adalah urutan tone yang harus ada di respon, sebanyak Count
+func (s SuggestionSettings) ExpectedTones() []string {
+	if len(s.Tones) == 0 {
+		return nil
+	}
+	count := s.Count
+	if count <= 0 {
+		count = len(s.Tones)
+	}
+	tones := make([]string, count)
+	for i := range tones {
+		tones[i] = s.Tones[i%len(s.Tones)]
+	}
+	return tones
+}
+
+// Key membedakan pengaturan di kunci cache draf balasan
+func (s SuggestionSettings) Key() string {
+	s.UpdatedBy, s.UpdatedAt = "", time.Time{}
+	raw, _ := json.Marshal(s)
+	h := fnv.New32a()
+	h.Write(raw)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// event tiket yang bisa dilanggan webhook
+const (
+	WebhookConversationCreated  = "conversation.created"
+	WebhookConversationAnalyzed = "conversation.analyzed"
+	WebhookMessageCreated       = "message.created"
+	WebhookStatusChanged        = "status.changed"
+)
+
+// status pengiriman webhook
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // gagal setelah semua percobaan, masuk dead-letter
+)
+
+// Webhook adalah langganan event tiket oleh sistem luar (LMS, tools internal),
+// disimpan di collection webhooks
+type Webhook struct {
+	ID          string    `json:"id" firestore:"-"`
+	URL         string    `json:"url" firestore:"url"`
+	Events      []string  `json:"events" firestore:"events"`
+	Description string    `json:"description" firestore:"description"`
+	Active      bool      `json:"active" firestore:"active"`
+	Secret      string    `json:"secret,omitempty" firestore:"secret"` // kunci HMAC, hanya ditampilkan saat dibuat/rotate
+	CreatedBy   string    `json:"created_by" firestore:"created_by"`
+	CreatedAt   time.Time `json:"created_at" firestore:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// WebhookPayload adalah body JSON yang dikirim ke URL webhook
+type WebhookPayload struct {
+	ID             string      `json:"id"` // sama dengan ID pengiriman, dipakai penerima untuk dedup
+	Event          string      `json:"event"`
+	ConversationID string      `json:"conversation_id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	Data           interface{} `json:"data"`
+}
+
+// WebhookDelivery adalah satu pengiriman event ke satu webhook, disimpan di
+// webhook_deliveries. Payload disimpan apa adanya sehingga replay mengirim body yang sama.
+type WebhookDelivery struct {
+	ID             string     `json:"id" firestore:"-"`
+	WebhookID      string     `json:"webhook_id" firestore:"webhook_id"`
+	Event          string     `json:"event" firestore:"event"`
+	ConversationID string     `json:"conversation_id" firestore:"conversation_id"`
+	Payload        string     `json:"payload" firestore:"payload"`
+	Status         string     `json:"status" firestore:"status"`
+	Attempts       int        `json:"attempts" firestore:"attempts"`
+	ResponseCode   int        `json:"response_code,omitempty" firestore:"response_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty" firestore:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" firestore:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at" firestore:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" firestore:"delivered_at,omitempty"`
+}
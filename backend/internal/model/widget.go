@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// WidgetSession adalah sesi pengunjung anonim dari widget chat di situs publik,
+// disimpan di widget_sessions/{id}
+type WidgetSession struct {
+	ID        string    `json:"id" firestore:"-"`
+	Name      string    `json:"name,omitempty" firestore:"name,omitempty"`
+	Email     string    `json:"email,omitempty" firestore:"email,omitempty"` // opsional, kontak dari pengunjung, belum diverifikasi
+	IP        string    `json:"-" firestore:"ip"`
+	UserAgent string    `json:"-" firestore:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" firestore:"expires_at"`
+
+	// diisi saat pengunjung mendaftar/login, setelah itu token sesi tidak berlaku lagi
+	LinkedStudentID string     `json:"linked_student_id,omitempty" firestore:"linked_student_id,omitempty"`
+	LinkedAt        *time.Time `json:"linked_at,omitempty" firestore:"linked_at,omitempty"`
+
+	Token string `json:"token,omitempty" firestore:"-"` // token bertanda tangan, hanya terisi saat sesi dibuat
+}
@@ -0,0 +1,97 @@
+package prompt
+
+import "reflect"
+
+// nama template yang dipakai AIService
+const (
+	Analysis    = "analysis"
+	Summary     = "summary"
+	Suggestions = "suggestions"
+	Translation = "translation"
+	Actions     = "actions"
+	CSAT        = "csat"
+)
+
+// input bertipe untuk setiap template, field-nya yang boleh dipakai di teks template
+
+type AnalysisInput struct {
+	ComplaintText string
+	Examples      []AnalysisExample // koreksi agent sebagai contoh few-shot, boleh kosong
+	ImageCount    int               // jumlah gambar lampiran yang ikut dikirim, 0 = analisis teks saja
+	Course        *AnalysisCourse   // mata kuliah yang dipilih mahasiswa, nil jika tidak ada
+}
+
+type AnalysisCourse struct {
+	ID        string
+	Name      string
+	Deadlines []AnalysisDeadline // tenggat terdekat yang belum lewat
+}
+
+type AnalysisDeadline struct {
+	Title string
+	Type  string
+	DueAt string // "2006-01-02 15:04"
+	DueIn string // jarak dari sekarang, misal "2 hari lagi"
+}
+
+type AnalysisExample struct {
+	Text          string
+	Category      string
+	PriorityScore int
+	Sentiment     string
+}
+
+type SummaryInput struct {
+	PreviousSummary string
+	NewMessages     string
+}
+
+type SuggestionInput struct {
+	Language string // nama bahasa dalam bahasa Inggris, misal "Indonesian"
+	History  string
+
+	//persona & tone dari pengaturan tim
+	PersonaName      string
+	PersonaRole      string
+	Signature        string
+	ForbiddenPhrases []string
+	Tones            []string // satu draf per elemen, sesuai urutan
+	Count            int
+}
+
+type TranslationInput struct {
+	Language string
+	Count    int
+	Input    string // JSON array berisi teks yang diterjemahkan
+}
+
+type ActionInput struct {
+	Summary       string
+	Category      string
+	PriorityScore int
+	Status        string
+	AssignedTeam  string
+	History       string
+	Teams         []string
+	Macros        []ActionMacro
+}
+
+type ActionMacro struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+type CSATInput struct {
+	Rating  int // 1-5
+	Comment string
+}
+
+var inputTypes = map[string]reflect.Type{
+	Analysis:    reflect.TypeOf(AnalysisInput{}),
+	Summary:     reflect.TypeOf(SummaryInput{}),
+	Suggestions: reflect.TypeOf(SuggestionInput{}),
+	Translation: reflect.TypeOf(TranslationInput{}),
+	Actions:     reflect.TypeOf(ActionInput{}),
+	CSAT:        reflect.TypeOf(CSATInput{}),
+}
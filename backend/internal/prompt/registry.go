@@ -0,0 +1,317 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+)
+
+// template bawaan, nama file: <name>@<version>.tmpl
+//
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+var ErrNotFound = errors.New("template prompt tidak ditemukan")
+
+// Compiled adalah template yang sudah diparse dan divalidasi terhadap tipe inputnya
+type Compiled struct {
+	model.PromptTemplate
+	tmpl *template.Template
+}
+
+// ID dicatat di hasil analisis sebagai prompt_version, misal "analysis-v2"
+func (c *Compiled) ID() string {
+	return c.Name + "-" + c.Version
+}
+
+// Uses menandakan teks template merujuk field input, misal Uses("Tones")
+func (c *Compiled) Uses(field string) bool {
+	return strings.Contains(c.Text, "."+field)
+}
+
+func (c *Compiled) Execute(input interface{}) (string, error) {
+	if want := inputTypes[c.Name]; reflect.TypeOf(input) != want {
+		return "", fmt.Errorf("input template %s harus bertipe %s", c.Name, want)
+	}
+
+	var buf bytes.Buffer
+	if err := c.tmpl.Execute(&buf, input); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Compile memparse template dan mencoba mengeksekusinya dengan input kosong,
+// sehingga field yang salah ketik langsung ketahuan saat disimpan
+func Compile(t model.PromptTemplate) (*Compiled, error) {
+	inputType, ok := inputTypes[t.Name]
+	if !ok {
+		return nil, fmt.Errorf("nama template tidak dikenal: %s", t.Name)
+	}
+	if t.Version == "" {
+		return nil, errors.New("versi template wajib diisi")
+	}
+
+	tmpl, err := template.New(t.Name + "@" + t.Version).Option("missingkey=error").Parse(t.Text)
+	if err != nil {
+		return nil, fmt.Errorf("template tidak valid: %w", err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, reflect.Zero(inputType).Interface()); err != nil {
+		return nil, fmt.Errorf("template tidak cocok dengan input %s: %w", inputType.Name(), err)
+	}
+
+	return &Compiled{PromptTemplate: t, tmpl: tmpl}, nil
+}
+
+// Registry menyimpan semua versi template dari tiga sumber, dengan prioritas
+// embedded < file (PROMPT_DIR) < firestore, sehingga versi yang sama bisa ditimpa
+type Registry struct {
+	firestore *firestore.Client
+	dir       string
+
+	mu        sync.RWMutex
+	templates map[string]map[string]*Compiled // name -> version -> template
+}
+
+func NewRegistry(ctx context.Context, f *firestore.Client, dir string) (*Registry, error) {
+	r := &Registry{firestore: f, dir: dir}
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload membaca ulang semua sumber. Template embedded yang rusak adalah bug build dan
+// menggagalkan Reload, sedangkan template file/firestore yang rusak dilewati (dicatat di log)
+// agar satu template buruk tidak mematikan server atau menghapus versi lain.
+func (r *Registry) Reload(ctx context.Context) error {
+	templates := make(map[string]map[string]*Compiled)
+	add := func(t model.PromptTemplate) error {
+		compiled, err := Compile(t)
+		if err != nil {
+			err = fmt.Errorf("%s@%s (%s): %w", t.Name, t.Version, t.Source, err)
+			if t.Source == "embedded" {
+				return err
+			}
+			log.Printf("Template prompt dilewati: %v", err)
+			return nil
+		}
+		if templates[t.Name] == nil {
+			templates[t.Name] = make(map[string]*Compiled)
+		}
+		templates[t.Name][t.Version] = compiled
+		return nil
+	}
+
+	files, _ := embedded.ReadDir("templates")
+	for _, f := range files {
+		data, err := embedded.ReadFile("templates/" + f.Name())
+		if err != nil {
+			return err
+		}
+		if err := add(fromFile(f.Name(), data, "embedded")); err != nil {
+			return err
+		}
+	}
+
+	if r.dir != "" {
+		paths, err := filepath.Glob(filepath.Join(r.dir, "*.tmpl"))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := add(fromFile(filepath.Base(path), data, "file")); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.firestore != nil {
+		iter := r.firestore.Collection("prompt_templates").Documents(ctx)
+		defer iter.Stop()
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			var t model.PromptTemplate
+			if err := doc.DataTo(&t); err != nil {
+				log.Printf("Template prompt %s dilewati: %v", doc.Ref.ID, err)
+				continue
+			}
+			t.Source = "store"
+			if err := add(t); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.templates = templates
+	r.mu.Unlock()
+	return nil
+}
+
+// RunReload memuat ulang registry secara berkala sampai ctx dibatalkan, sehingga template
+// yang disimpan lewat instance lain (Save hanya memuat ulang instance sendiri) ikut terpakai
+func (r *Registry) RunReload(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(ctx); err != nil {
+				log.Printf("Gagal memuat ulang template prompt: %v", err)
+			}
+		}
+	}
+}
+
+// Select memilih versi template untuk sebuah key (misal ID percakapan).
+// Jika ada versi dengan weight > 0, dipilih secara deterministik sesuai bobot (A/B split),
+// jika tidak ada, dipakai versi tertinggi.
+func (r *Registry) Select(name string, key string) (*Compiled, error) {
+	return r.SelectWhere(name, key, nil)
+}
+
+// SelectWhere sama dengan Select, tetapi hanya dari versi yang lolos usable (nil = semua),
+// misal template yang memakai input tertentu
+func (r *Registry) SelectWhere(name string, key string, usable func(*Compiled) bool) (*Compiled, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var versions []*Compiled
+	for _, t := range sortedVersions(r.templates[name]) {
+		if usable == nil || usable(t) {
+			versions = append(versions, t)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	total := 0
+	for _, t := range versions {
+		total += t.Weight
+	}
+	if total <= 0 {
+		return versions[len(versions)-1], nil
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	pick := int(h.Sum32() % uint32(total))
+	for _, t := range versions {
+		if t.Weight <= 0 {
+			continue
+		}
+		if pick < t.Weight {
+			return t, nil
+		}
+		pick -= t.Weight
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (r *Registry) Get(name string, version string) (*Compiled, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.templates[name][version]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+func (r *Registry) List() []model.PromptTemplate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := []model.PromptTemplate{}
+	for _, name := range names {
+		for _, t := range sortedVersions(r.templates[name]) {
+			list = append(list, t.PromptTemplate)
+		}
+	}
+	return list
+}
+
+// Save memvalidasi lalu menyimpan template ke firestore, kemudian memuat ulang registry
+func (r *Registry) Save(ctx context.Context, t model.PromptTemplate) (*Compiled, error) {
+	if r.firestore == nil {
+		return nil, errors.New("penyimpanan template tidak tersedia")
+	}
+
+	t.UpdatedAt = time.Now()
+	compiled, err := Compile(t)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.firestore.Collection("prompt_templates").Doc(t.Name+"@"+t.Version).Set(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	compiled.Source = "store"
+	return compiled, nil
+}
+
+func fromFile(filename string, data []byte, source string) model.PromptTemplate {
+	name, version, _ := strings.Cut(strings.TrimSuffix(filename, ".tmpl"), "@")
+	return model.PromptTemplate{Name: name, Version: version, Text: string(data), Source: source}
+}
+
+// urut naik berdasarkan nomor versi ("v2" < "v10")
+func sortedVersions(versions map[string]*Compiled) []*Compiled {
+	list := make([]*Compiled, 0, len(versions))
+	for _, t := range versions {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, errA := strconv.Atoi(strings.TrimPrefix(list[i].Version, "v"))
+		b, errB := strconv.Atoi(strings.TrimPrefix(list[j].Version, "v"))
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return list[i].Version < list[j].Version
+	})
+	return list
+}
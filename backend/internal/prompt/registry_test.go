@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+)
+
+func TestSelectWhere(t *testing.T) {
+	texts := map[string]string{
+		"v1": `Reply in {{.Language}}: {{.History}}`,
+		"v2": `Tones: {{range .Tones}}{{.}} {{end}}{{.History}}`,
+		"v3": `Persona {{.PersonaName}}, tones {{.Tones}}: {{.History}}`,
+	}
+	build := func(weights map[string]int) *Registry {
+		r := &Registry{templates: map[string]map[string]*Compiled{Suggestions: {}}}
+		for version, text := range texts {
+			c, err := Compile(model.PromptTemplate{Name: Suggestions, Version: version, Text: text, Weight: weights[version]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.templates[Suggestions][version] = c
+		}
+		return r
+	}
+	usesTones := func(c *Compiled) bool { return c.Uses("Tones") }
+	noneUsable := func(*Compiled) bool { return false }
+
+	tests := []struct {
+		name    string
+		weights map[string]int
+		usable  func(*Compiled) bool
+		allowed []string
+	}{
+		{"tanpa bobot, versi tertinggi", nil, nil, []string{"v3"}},
+		{"A/B split semua versi", map[string]int{"v1": 50, "v2": 50}, nil, []string{"v1", "v2"}},
+		{"A/B split tanpa v1", map[string]int{"v1": 50, "v2": 50}, usesTones, []string{"v2"}},
+		{"hanya v1 berbobot, tersaring", map[string]int{"v1": 100}, usesTones, []string{"v3"}},
+	}
+	for _, tt := range tests {
+		r := build(tt.weights)
+		seen := map[string]bool{}
+		for i := 0; i < 200; i++ {
+			c, err := r.SelectWhere(Suggestions, fmt.Sprintf("conv-%d", i), tt.usable)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			seen[c.Version] = true
+		}
+		for _, v := range tt.allowed {
+			if !seen[v] {
+				t.Errorf("%s: versi %s tidak pernah terpilih", tt.name, v)
+			}
+			delete(seen, v)
+		}
+		if len(seen) > 0 {
+			t.Errorf("%s: versi lain ikut terpilih: %v", tt.name, seen)
+		}
+	}
+
+	if _, err := build(nil).SelectWhere(Suggestions, "x", noneUsable); !errors.Is(err, ErrNotFound) {
+		t.Errorf("tanpa versi yang lolos, err = %v, ingin ErrNotFound", err)
+	}
+}
+
+func TestReloadSkipsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"summary@v2.tmpl":      `Ringkasan: {{.PreviousSummary}} {{.NewMessages}}`,
+		"summary@v3.tmpl":      `Ringkasan: {{.FieldSalah}}`,
+		"summary@v4.tmpl":      `Ringkasan: {{.PreviousSummary`,
+		"tidakdikenal@v1.tmpl": `halo`,
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewRegistry(context.Background(), nil, dir)
+	if err != nil {
+		t.Fatalf("template file rusak seharusnya dilewati, err = %v", err)
+	}
+	if _, err := r.Get(Summary, "v2"); err != nil {
+		t.Errorf("summary@v2 yang valid tidak termuat: %v", err)
+	}
+	for _, version := range []string{"v3", "v4"} {
+		if _, err := r.Get(Summary, version); !errors.Is(err, ErrNotFound) {
+			t.Errorf("summary@%s yang rusak ikut termuat, err = %v", version, err)
+		}
+	}
+	if _, err := r.Get(Summary, "v1"); err != nil {
+		t.Errorf("template embedded hilang: %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/prompt"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	ErrInvalidAction = errors.New("aksi tidak valid")
+	ErrMacroNotFound = errors.New("macro tidak ditemukan")
+)
+
+// jumlah pesan terakhir yang dikirim ke AI untuk rekomendasi aksi
+const actionHistoryMessages = 10
+
+var validStatuses = map[string]bool{model.StatusOpen: true, model.StatusInProgress: true, model.StatusResolved: true}
+
+// ActionService menyusun rekomendasi "next best action" dan menjalankan aksi tiket
+// (status, pengalihan tim, macro) dengan satu panggilan.
+type ActionService struct {
+	firestore *firestore.Client
+	aiService *AIService
+	notifier  *EmailNotifier
+	webhooks  *WebhookService
+	chat      *ChatService
+	csat      *CSATService
+	teams     []string
+}
+
+func NewActionService(f *firestore.Client, aiSvc *AIService, notifier *EmailNotifier, webhooks *WebhookService, chat *ChatService, csat *CSATService, teams []string) *ActionService {
+	return &ActionService{firestore: f, aiService: aiSvc, notifier: notifier, webhooks: webhooks, chat: chat, csat: csat, teams: teams}
+}
+
+func (s *ActionService) ListMacros(ctx context.Context) ([]model.Macro, error) {
+	iter := s.firestore.Collection("macros").Documents(ctx)
+	defer iter.Stop()
+
+	macros := []model.Macro{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var m model.Macro
+		if err := doc.DataTo(&m); err != nil {
+			continue
+		}
+		m.ID = doc.Ref.ID
+		macros = append(macros, m)
+	}
+	sort.Slice(macros, func(i, j int) bool { return macros[i].Name < macros[j].Name })
+	return macros, nil
+}
+
+func (s *ActionService) SaveMacro(ctx context.Context, m model.Macro) (*model.Macro, error) {
+	m.Name = strings.TrimSpace(m.Name)
+	m.Text = strings.TrimSpace(m.Text)
+	if m.ID == "" || m.Name == "" || m.Text == "" {
+		return nil, fmt.Errorf("%w: name dan text macro wajib diisi", ErrInvalidAction)
+	}
+	if m.SetStatus != "" && !validStatuses[m.SetStatus] {
+		return nil, fmt.Errorf("%w: status %q tidak dikenal", ErrInvalidAction, m.SetStatus)
+	}
+	if m.AssignTeam != "" && !s.validTeam(m.AssignTeam) {
+		return nil, fmt.Errorf("%w: tim %q tidak dikenal", ErrInvalidAction, m.AssignTeam)
+	}
+	m.UpdatedAt = time.Now()
+
+	if _, err := s.firestore.Collection("macros").Doc(m.ID).Set(ctx, m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *ActionService) DeleteMacro(ctx context.Context, id string) error {
+	_, err := s.firestore.Collection("macros").Doc(id).Delete(ctx)
+	return err
+}
+
+// Recommend meminta AI merekomendasikan aksi untuk percakapan. Aksi dengan tim/macro/status
+// yang tidak dikenal dibuang, sehingga setiap rekomendasi bisa langsung dijalankan.
+func (s *ActionService) Recommend(ctx context.Context, convID string) ([]model.ActionRecommendation, error) {
+	conv, err := s.getConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	macros, err := s.ListMacros(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = WithCallInfo(ctx, CallInfo{ConversationID: convID, UserID: callInfoFrom(ctx).UserID})
+	recommendations, err := s.aiService.RecommendActions(ctx, convID, *conv, macros, s.teams)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]model.Macro, len(macros))
+	for _, m := range macros {
+		byID[m.ID] = m
+	}
+	valid := []model.ActionRecommendation{}
+	for _, rec := range recommendations {
+		if err := s.validate(rec.Action, byID); err != nil {
+			continue
+		}
+		//aksi yang tidak mengubah apa-apa tidak perlu ditampilkan
+		if (rec.Type == model.ActionSetStatus && rec.Status == conv.Status) ||
+			(rec.Type == model.ActionAssign && rec.Team == conv.AssignedTeam) {
+			continue
+		}
+		valid = append(valid, rec)
+	}
+	return valid, nil
+}
+
+// Execute menjalankan satu aksi dan mencatatnya di conversations/{id}/actions
+func (s *ActionService) Execute(ctx context.Context, convID, agentID string, action model.Action) (*model.Conversation, error) {
+	ref := s.firestore.Collection("conversations").Doc(convID)
+	current, err := s.getConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validate(action, nil); err != nil {
+		return nil, err
+	}
+
+	var macro *model.Macro
+	if action.Type == model.ActionApplyMacro {
+		doc, err := s.firestore.Collection("macros").Doc(action.MacroID).Get(ctx)
+		if err != nil {
+			return nil, ErrMacroNotFound
+		}
+		macro = &model.Macro{}
+		if err := doc.DataTo(macro); err != nil {
+			return nil, err
+		}
+		macro.ID = doc.Ref.ID
+	}
+
+	now := time.Now()
+	updates := []firestore.Update{{Path: "updated_at", Value: now}}
+	var events []model.EmailEvent
+	var sent model.Message
+	setStatus := func(status string) {
+		updates = append(updates, firestore.Update{Path: "status", Value: status})
+		if status == model.StatusResolved {
+			updates = append(updates, firestore.Update{Path: "resolved_at", Value: now})
+		}
+		if status != current.Status {
+			events = append(events, model.EmailEvent{Type: model.EmailEventStatusChanged, Status: status, At: now})
+		}
+	}
+	addMessage := func(text string) {
+		msg := model.Message{Sender: "support", Text: text, Timestamp: now, Language: DetectLanguage(text), Channel: MessageChannel(current)}
+		sent = msg
+		events = append(events, model.EmailEvent{Type: model.EmailEventAgentReply, Text: text, At: now})
+		updates = append(updates,
+			firestore.Update{Path: "messages", Value: firestore.ArrayUnion(msg)},
+			firestore.Update{Path: "last_message", Value: text},
+			firestore.Update{Path: "version", Value: firestore.Increment(1)},
+		)
+	}
+
+	switch action.Type {
+	case model.ActionSetStatus:
+		setStatus(action.Status)
+	case model.ActionAssign:
+		updates = append(updates, firestore.Update{Path: "assigned_team", Value: action.Team})
+		setStatus(model.StatusInProgress)
+	case model.ActionRequestInfo:
+		addMessage(action.Text)
+		setStatus(model.StatusInProgress)
+	case model.ActionApplyMacro:
+		addMessage(macro.Text)
+		if macro.AssignTeam != "" {
+			updates = append(updates, firestore.Update{Path: "assigned_team", Value: macro.AssignTeam})
+		}
+		if macro.SetStatus != "" {
+			setStatus(macro.SetStatus)
+		} else {
+			setStatus(model.StatusInProgress)
+		}
+	}
+
+	if _, err := ref.Update(ctx, updates); err != nil {
+		return nil, err
+	}
+	if _, err := ref.Collection("actions").NewDoc().Set(ctx, model.ActionLog{Action: action, AgentID: agentID, ExecutedAt: now}); err != nil {
+		return nil, err
+	}
+	if err := s.notifier.Notify(ctx, convID, events...); err != nil {
+		log.Printf("gagal menjadwalkan email notifikasi %s: %v", convID, err)
+	}
+	for _, e := range events {
+		switch e.Type {
+		case model.EmailEventAgentReply:
+			s.webhooks.Publish(ctx, model.WebhookMessageCreated, convID, map[string]interface{}{"message": sent})
+			if err := s.chat.Relay(ctx, current, sent.Text); err != nil {
+				log.Printf("gagal meneruskan balasan %s ke chat: %v", convID, err)
+			}
+		case model.EmailEventStatusChanged:
+			s.webhooks.Publish(ctx, model.WebhookStatusChanged, convID, map[string]interface{}{"from": current.Status, "to": e.Status})
+			if e.Status == model.StatusResolved {
+				if err := s.csat.Request(ctx, convID, agentID); err != nil {
+					log.Printf("gagal meminta survei kepuasan %s: %v", convID, err)
+				}
+			}
+		}
+	}
+
+	return s.getConversation(ctx, convID)
+}
+
+// validate memeriksa field aksi sesuai jenisnya. macros nil berarti macro tidak dicek
+// (sudah dicek oleh pemanggil).
+func (s *ActionService) validate(action model.Action, macros map[string]model.Macro) error {
+	switch action.Type {
+	case model.ActionSetStatus:
+		if !validStatuses[action.Status] {
+			return fmt.Errorf("%w: status %q tidak dikenal", ErrInvalidAction, action.Status)
+		}
+	case model.ActionAssign:
+		if !s.validTeam(action.Team) {
+			return fmt.Errorf("%w: tim %q tidak dikenal", ErrInvalidAction, action.Team)
+		}
+	case model.ActionRequestInfo:
+		if strings.TrimSpace(action.Text) == "" {
+			return fmt.Errorf("%w: text wajib diisi untuk request_info", ErrInvalidAction)
+		}
+	case model.ActionApplyMacro:
+		if action.MacroID == "" {
+			return fmt.Errorf("%w: macro_id wajib diisi", ErrInvalidAction)
+		}
+		if _, ok := macros[action.MacroID]; macros != nil && !ok {
+			return ErrMacroNotFound
+		}
+	default:
+		return fmt.Errorf("%w: jenis aksi %q tidak dikenal", ErrInvalidAction, action.Type)
+	}
+	return nil
+}
+
+func (s *ActionService) validTeam(team string) bool {
+	for _, t := range s.teams {
+		if t == team {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ActionService) getConversation(ctx context.Context, convID string) (*model.Conversation, error) {
+	doc, err := s.firestore.Collection("conversations").Doc(convID).Get(ctx)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		return nil, err
+	}
+	conv.ID = doc.Ref.ID
+	return &conv, nil
+}
+
+// RecommendActions meminta AI memilih aksi berikutnya dari taksonomi tim dan macro yang tersedia.
+// Hasil di-cache per versi percakapan.
+func (s *AIService) RecommendActions(ctx context.Context, convID string, conv model.Conversation, macros []model.Macro, teams []string) ([]model.ActionRecommendation, error) {
+	tmpl, err := s.prompts.Select(prompt.Actions, convID)
+	if err != nil {
+		return nil, err
+	}
+
+	var macroKey strings.Builder
+	for _, m := range macros {
+		fmt.Fprintf(&macroKey, "%s@%d,", m.ID, m.UpdatedAt.Unix())
+	}
+	key := fmt.Sprintf("%s:%d:%s:%s:%s:%s", convID, conv.Version, conv.Status, conv.AssignedTeam, tmpl.ID(), macroKey.String())
+	value, err := s.cache.GetOrLoad(ctx, "actions", key, func(ctx context.Context) (interface{}, error) {
+		redaction := s.redactor.NewSession()
+		defer s.auditRedaction(ctx, "actions", redaction)
+
+		messages := conv.Messages
+		if len(messages) > actionHistoryMessages {
+			messages = messages[len(messages)-actionHistoryMessages:]
+		}
+		var history strings.Builder
+		for _, m := range messages {
+			fmt.Fprintf(&history, "%s: %s\n", m.Sender, redaction.Redact(m.Text))
+		}
+
+		input := prompt.ActionInput{
+			Summary:       redaction.Redact(conv.AIAnalysis.Summary),
+			Category:      conv.AIAnalysis.Category,
+			PriorityScore: conv.AIAnalysis.PriorityScore,
+			Status:        conv.Status,
+			AssignedTeam:  conv.AssignedTeam,
+			History:       history.String(),
+			Teams:         teams,
+		}
+		for _, m := range macros {
+			input.Macros = append(input.Macros, prompt.ActionMacro{ID: m.ID, Name: m.Name, Description: m.Description})
+		}
+		promptText, err := tmpl.Execute(input)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.generate(ctx, "actions", ai.Request{Prompt: promptText, JSON: true})
+		if err != nil {
+			return nil, err
+		}
+
+		var recommendations []model.ActionRecommendation
+		if err := json.Unmarshal([]byte(resp.Text), &recommendations); err != nil {
+			return nil, fmt.Errorf("gagal Unmarshal JSON AI: %w", err)
+		}
+		for i := range recommendations {
+			recommendations[i].Text = redaction.Restore(recommendations[i].Text)
+			recommendations[i].Reason = redaction.Restore(recommendations[i].Reason)
+		}
+		return recommendations, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]model.ActionRecommendation), nil
+}
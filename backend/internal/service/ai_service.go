@@ -5,97 +5,625 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
 	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/prompt"
 	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
-	"github.com/google/generative-ai-go/genai"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/cache"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/pii"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	ErrConversationNotFound = errors.New("percakapan tidak ditemukan")
+	ErrAnalysisConflict     = errors.New("analisis sudah diperbarui oleh proses lain")
+	ErrNoNewMessages        = errors.New("tidak ada pesan baru untuk diringkas")
 )
 
 type AIService struct {
-	geminiClient *ai.GeminiClient
-	firestore    *firestore.Client
+	provider  ai.Provider
+	firestore *firestore.Client
+	cfg       *config.AIConfig
+	redactor  *pii.Redactor
+	cache     *cache.Cache
+	usage     *UsageService
+	prompts   *prompt.Registry
+	webhooks  *WebhookService
 }
 
-func NewAIService(g *ai.GeminiClient, f *firestore.Client) *AIService {
-	return &AIService{geminiClient: g, firestore: f}
+func NewAIService(p ai.Provider, f *firestore.Client, cfg *config.AIConfig, usage *UsageService, prompts *prompt.Registry, webhooks *WebhookService) *AIService {
+	return &AIService{
+		provider:  p,
+		prompts:   prompts,
+		webhooks:  webhooks,
+		firestore: f,
+		cfg:       cfg,
+		usage:     usage,
+		redactor:  pii.NewRedactor(cfg.PIIRedaction, cfg.PIITypes),
+		cache:     cache.New(cfg.CacheTTL, cfg.CacheMaxEntries),
+	}
 }
 
-func (s *AIService) ProcessComplaint(ctx context.Context, complainText string) (*model.AIAnalysis, error) {
+// ProcessComplaint menganalisis keluhan baru. Screenshot lampiran (images) ikut dikirim
+// jika provider mendukung gambar, selain itu analisis berjalan dengan teks saja.
+// course (boleh nil) dan tenggatnya ikut dipertimbangkan saat menentukan prioritas.
+func (s *AIService) ProcessComplaint(ctx context.Context, complainText string, course *model.CourseSnapshot, images ...ai.Image) (*model.AIAnalysis, error) {
 	if complainText == "" {
 		return nil, errors.New("complain text cannot be empty")
 	}
 
-	//konstruksi prompt
-	prompt := fmt.Sprintf(`Analisislah keluhan mahasiswa berikut: "%s".
-	Berikan output dalam format JSON mentah dengan field berikut:
-	- summary (string): ringkasan singkat keluhan.
-	- category (string): kategori keluhan (misal: Akademik, Fasilitas, Keuangan).
-	- priority_score (integer 1-10): tingkat urgensi.
-	- reason (string): alasan penentuan skor dan kategori.
-	- sentiment (string): sentimen pesan (positif/negatif/netral).`, complainText)
+	//redaksi PII sebelum dikirim ke LLM
+	redaction := s.redactor.NewSession()
+	complainText = redaction.Redact(complainText)
+	defer s.auditRedaction(ctx, "analysis", redaction)
 
-	//konfigurasi model
-	aiModel := s.geminiClient.Model
-	aiModel.ResponseMIMEType = "application/json"
-
-	//panggil generatecontent
-	resp, err := aiModel.GenerateContent(ctx, genai.Text(prompt))
+	//konstruksi prompt dari template (versi dipilih sesuai A/B split)
+	tmpl, err := s.prompts.Select(prompt.Analysis, abKey(ctx, complainText))
+	if err != nil {
+		return nil, err
+	}
+	input := prompt.AnalysisInput{
+		ComplaintText: complainText,
+		Examples:      s.fewShotExamples(ctx, redaction),
+		Course:        analysisCourse(course, time.Now()),
+	}
+	promptText, err := tmpl.Execute(input)
 	if err != nil {
-		return nil, fmt.Errorf("gagal memanggil gemini: %w", err)
+		return nil, err
 	}
+	req := ai.Request{Prompt: promptText, JSON: true}
 
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("gemini tidak memberikan respon")
+	//prompt bergambar, provider tanpa vision di rantai fallback tetap menerima prompt teks di atas
+	if req.Images = s.visionImages(images); len(req.Images) > 0 {
+		input.ImageCount = len(req.Images)
+		s.auditImages(ctx, "analysis", len(req.Images))
+		if req.Prompt, err = tmpl.Execute(input); err != nil {
+			return nil, err
+		}
+		req.TextPrompt = promptText
+	}
+
+	resp, err := s.generate(ctx, "analysis", req)
+	if err != nil {
+		return nil, err
 	}
 
 	//response parsing
 	var analysis model.AIAnalysis
-	respText := fmt.Sprint(resp.Candidates[0].Content.Parts[0])
-	if err := json.Unmarshal([]byte(respText), &analysis); err != nil {
+	if err := json.Unmarshal([]byte(resp.Text), &analysis); err != nil {
 		return nil, fmt.Errorf("gagal Unmarshal JSON AI: %w", err)
 	}
+	analysis.Summary = redaction.Restore(analysis.Summary)
+	analysis.Reason = redaction.Restore(analysis.Reason)
+	analysis.ImageFindings = strings.TrimSpace(analysis.ImageFindings)
+	//gabungkan hasil LLM dengan screening kata kunci, ambil yang paling berat
+	analysis.Moderation = MaxModeration(analysis.Moderation, ScreenText(complainText))
+	if analysis.Moderation == ModerationCrisis {
+		analysis.PriorityScore = 10
+	}
 	//set isprocessed jadi true
 	analysis.IsProcessed = true
-	//update database
-	//_, err = s.firestore.Collection("conversations").Doc(conversationsID).Set(ctx, map[string]interface{}{
-	//	"ai_analysis": analysis,
-	//}, firestore.MergeAll)
+	analysis.Version = 1
+	analysis.Model = resp.Model
+	analysis.PromptVersion = tmpl.ID()
+	analysis.MessageCount = 1
+	analysis.GeneratedAt = time.Now()
+
+	return &analysis, nil
+}
+
+// SummarizeConversation memperbarui analisis secara inkremental: ringkasan sebelumnya
+// digabung dengan pesan-pesan yang belum tercakup, bukan membaca ulang seluruh thread.
+func (s *AIService) SummarizeConversation(ctx context.Context, messages []model.Message, prev model.AIAnalysis) (*model.AIAnalysis, error) {
+	start := prev.MessageCount
+	if !prev.IsProcessed || start > len(messages) {
+		start = 0
+	}
+	if start == len(messages) {
+		return nil, ErrNoNewMessages
+	}
+
+	redaction := s.redactor.NewSession()
+	defer s.auditRedaction(ctx, "summary", redaction)
+
+	var newMessages strings.Builder
+	for _, m := range messages[start:] {
+		fmt.Fprintf(&newMessages, "[%s] %s: %s\n", m.Timestamp.Format("2006-01-02 15:04"), m.Sender, redaction.Redact(m.Text))
+	}
+
+	prevSummary := "(belum ada)"
+	if start > 0 {
+		prevSummary = fmt.Sprintf(`"%s" (kategori: %s, priority_score: %d)`, redaction.Redact(prev.Summary), prev.Category, prev.PriorityScore)
+		if prev.ImageFindings != "" {
+			prevSummary += fmt.Sprintf(`, temuan dari screenshot: "%s"`, redaction.Redact(prev.ImageFindings))
+		}
+	}
+
+	tmpl, err := s.prompts.Select(prompt.Summary, abKey(ctx, prevSummary))
+	if err != nil {
+		return nil, err
+	}
+	promptText, err := tmpl.Execute(prompt.SummaryInput{PreviousSummary: prevSummary, NewMessages: newMessages.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.generate(ctx, "summary", ai.Request{Prompt: promptText, JSON: true})
+	if err != nil {
+		return nil, err
+	}
 
-	//if err != nil {
-	//	return nil, fmt.Errorf("gagal update firestore: %w", err)
-	//}
+	var analysis model.AIAnalysis
+	if err := json.Unmarshal([]byte(resp.Text), &analysis); err != nil {
+		return nil, fmt.Errorf("gagal Unmarshal JSON AI: %w", err)
+	}
+	analysis.Summary = redaction.Restore(analysis.Summary)
+	analysis.Reason = redaction.Restore(analysis.Reason)
+	analysis.Moderation = MaxModeration(analysis.Moderation, ScreenText(newMessages.String()))
+	if analysis.Moderation == ModerationCrisis {
+		analysis.PriorityScore = 10
+	}
+	if start > 0 {
+		analysis.ImageFindings = prev.ImageFindings
+	}
+	analysis.IsProcessed = true
+	analysis.Version = prev.Version + 1
+	analysis.Model = resp.Model
+	analysis.PromptVersion = tmpl.ID()
+	analysis.MessageCount = len(messages)
+	analysis.GeneratedAt = time.Now()
 
 	return &analysis, nil
 }
 
-func (s *AIService) GenerateSuggestions(ctx context.Context, history string) ([]model.Suggestion, error) {
-	aiModel := s.geminiClient.Model
-	aiModel.ResponseMIMEType = "application/json"
+// RefreshAnalysis membuat versi analisis baru untuk percakapan dan menyimpannya ke riwayat.
+// Request refresh yang identik (versi percakapan sama) hanya memanggil AI sekali.
+func (s *AIService) RefreshAnalysis(ctx context.Context, convID string, trigger string) (*model.AIAnalysis, error) {
+	ctx = WithCallInfo(ctx, CallInfo{ConversationID: convID, UserID: callInfoFrom(ctx).UserID})
 
-	aiModel.SetTemperature(0.7)
+	ref := s.firestore.Collection("conversations").Doc(convID)
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
 
-	systemPrompt := `You are Alex, a Senior Customer Support Lead. 
-    Analyze the chat history and provide 2 replies (Formal & Empathetic) in Indonesian.
-    Output format MUST be a JSON Array: [{"tone": "...", "content": "..."}]
-    Input: %s`
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		return nil, fmt.Errorf("gagal membaca percakapan: %w", err)
+	}
 
-	finalPrompt := fmt.Sprintf(systemPrompt, history)
+	//tidak ada pesan baru sejak analisis terakhir, analisis saat ini sudah terkini
+	if conv.AIAnalysis.IsProcessed && conv.AIAnalysis.MessageCount >= len(conv.Messages) {
+		return &conv.AIAnalysis, nil
+	}
 
-	resp, err := aiModel.GenerateContent(ctx, genai.Text(finalPrompt))
+	tmpl, err := s.prompts.Select(prompt.Summary, convID)
 	if err != nil {
 		return nil, err
 	}
 
-	var suggestions []model.Suggestion
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("empty response from AI")
+	key := fmt.Sprintf("%s:%d:%d:%s", convID, conv.Version, len(conv.Messages), tmpl.ID())
+	value, err := s.cache.GetOrLoad(ctx, "analysis", key, func(ctx context.Context) (interface{}, error) {
+		return s.saveRefreshedAnalysis(ctx, ref, conv, trigger)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*model.AIAnalysis), nil
+}
+
+func (s *AIService) saveRefreshedAnalysis(ctx context.Context, ref *firestore.DocumentRef, conv model.Conversation, trigger string) (*model.AIAnalysis, error) {
+	analysis, err := s.SummarizeConversation(ctx, conv.Messages, conv.AIAnalysis)
+	if err != nil {
+		return nil, err
+	}
+
+	//simpan hanya jika belum ada proses lain yang memperbarui analisis selama pemanggilan AI
+	err = s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var current model.Conversation
+		if err := snap.DataTo(&current); err != nil {
+			return err
+		}
+		if current.AIAnalysis.Version != conv.AIAnalysis.Version {
+			return ErrAnalysisConflict
+		}
+
+		//riwayat menyimpan hasil AI apa adanya, ai_analysis tetap memakai koreksi agent
+		raw := *analysis
+		applyOverride(analysis, current.HumanOverride)
+
+		if err := tx.Update(ref, []firestore.Update{{Path: "ai_analysis", Value: analysis}}); err != nil {
+			return err
+		}
+		return tx.Set(ref.Collection("analyses").Doc(strconv.Itoa(analysis.Version)), model.AnalysisRecord{
+			Analysis:  raw,
+			Trigger:   trigger,
+			CreatedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.webhooks.Publish(ctx, model.WebhookConversationAnalyzed, ref.ID, map[string]interface{}{"analysis": analysis, "trigger": trigger})
+	return analysis, nil
+}
+
+// MaybeRefreshAnalysis memperbarui analisis hanya jika sudah ada cukup banyak pesan baru.
+// Mengembalikan nil tanpa error jika belum perlu diperbarui.
+func (s *AIService) MaybeRefreshAnalysis(ctx context.Context, convID string) (*model.AIAnalysis, error) {
+	doc, err := s.firestore.Collection("conversations").Doc(convID).Get(ctx)
+	if err != nil {
+		return nil, ErrConversationNotFound
+	}
+
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		return nil, err
+	}
+
+	pending := len(conv.Messages) - conv.AIAnalysis.MessageCount
+	if pending < s.cfg.SummaryRefreshEvery {
+		return nil, nil
 	}
-	jsonString := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
 
-	if err := json.Unmarshal([]byte(jsonString), &suggestions); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %v. Raw: %s", err, jsonString)
+	analysis, err := s.RefreshAnalysis(ctx, convID, "auto")
+	if errors.Is(err, ErrBudgetExceeded) {
+		//budget habis: tunda analisis, dijalankan ulang oleh worker antrean
+		return nil, s.QueueAnalysis(ctx, convID, err.Error())
 	}
+	return analysis, err
+}
 
-	return suggestions, nil
+// RecordAnalysis menyimpan analisis ke riwayat, dipakai untuk analisis awal saat tiket dibuat.
+func (s *AIService) RecordAnalysis(ctx context.Context, convID string, analysis model.AIAnalysis, trigger string) error {
+	_, err := s.firestore.Collection("conversations").Doc(convID).
+		Collection("analyses").Doc(strconv.Itoa(analysis.Version)).
+		Set(ctx, model.AnalysisRecord{
+			Analysis:  analysis,
+			Trigger:   trigger,
+			CreatedAt: time.Now(),
+		})
+	if err != nil {
+		return err
+	}
+
+	s.webhooks.Publish(ctx, model.WebhookConversationAnalyzed, convID, map[string]interface{}{"analysis": analysis, "trigger": trigger})
+	return nil
+}
+
+func (s *AIService) GetAnalysisHistory(ctx context.Context, convID string) ([]model.AnalysisRecord, error) {
+	iter := s.firestore.Collection("conversations").Doc(convID).
+		Collection("analyses").
+		OrderBy("analysis.version", firestore.Desc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	history := []model.AnalysisRecord{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record model.AnalysisRecord
+		if err := doc.DataTo(&record); err != nil {
+			continue
+		}
+		history = append(history, record)
+	}
+
+	return history, nil
+}
+
+// GetSuggestions mengambil draf balasan untuk percakapan dari cache, atau membuatnya
+// jika percakapan atau pengaturan tim sudah berubah sejak terakhir kali dibuat.
+func (s *AIService) GetSuggestions(ctx context.Context, convID string, conv model.Conversation, language string, settings model.SuggestionSettings) ([]model.Suggestion, error) {
+	tmpl, err := s.suggestionTemplate(convID, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	key := suggestionCacheKey(convID, conv, tmpl, language, settings)
+	value, err := s.cache.GetOrLoad(ctx, "suggestions", key, func(ctx context.Context) (interface{}, error) {
+		return s.GenerateSuggestions(ctx, conv.LastMessage, language, settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]model.Suggestion), nil
+}
+
+// GenerateSuggestions membuat draf balasan dalam bahasa mahasiswa (kode ISO 639-1) sesuai
+// persona dan tone tim. Respon yang tidak memuat tone yang diminta dicoba ulang sekali.
+func (s *AIService) GenerateSuggestions(ctx context.Context, history string, language string, settings model.SuggestionSettings) ([]model.Suggestion, error) {
+	redaction := s.redactor.NewSession()
+	defer s.auditRedaction(ctx, "suggestions", redaction)
+
+	tmpl, err := s.suggestionTemplate(abKey(ctx, history), settings)
+	if err != nil {
+		return nil, err
+	}
+	finalPrompt, err := tmpl.Execute(suggestionInput(language, redaction.Redact(history), settings))
+	if err != nil {
+		return nil, err
+	}
+
+	temperature := float32(0.7)
+	for attempt := 0; ; attempt++ {
+		resp, err := s.generate(ctx, "suggestions", ai.Request{Prompt: finalPrompt, JSON: true, Temperature: &temperature})
+		if err != nil {
+			return nil, err
+		}
+		jsonString := resp.Text
+
+		var suggestions []model.Suggestion
+		if err := json.Unmarshal([]byte(jsonString), &suggestions); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %v. Raw: %s", err, jsonString)
+		}
+
+		//kembalikan placeholder PII (misal [NIM_1]) ke nilai aslinya di draf balasan
+		for i := range suggestions {
+			suggestions[i].Content = redaction.Restore(suggestions[i].Content)
+			suggestions[i].ID = newSuggestionID()
+			suggestions[i].PromptVersion = tmpl.ID()
+		}
+
+		suggestions, err = conformSuggestions(suggestions, settings)
+		if errors.Is(err, ErrInvalidSuggestions) && attempt == 0 {
+			log.Printf("draf balasan tidak sesuai pengaturan, mencoba ulang: %v", err)
+			continue
+		}
+		return suggestions, err
+	}
+}
+
+// suggestionTemplate memilih versi prompt draf balasan. Template lama yang tidak membaca
+// persona/tone hanya ikut A/B split selama tim memakai pengaturan bawaan, agar hasilnya
+// tidak selalu gagal di conformSuggestions.
+func (s *AIService) suggestionTemplate(key string, settings model.SuggestionSettings) (*prompt.Compiled, error) {
+	if defaultSuggestionPrompt(settings) {
+		return s.prompts.Select(prompt.Suggestions, key)
+	}
+	return s.prompts.SelectWhere(prompt.Suggestions, key, func(t *prompt.Compiled) bool {
+		return t.Uses("Tones")
+	})
+}
+
+func suggestionInput(language, history string, settings model.SuggestionSettings) prompt.SuggestionInput {
+	tones := settings.ExpectedTones()
+	return prompt.SuggestionInput{
+		Language:         LanguageName(language),
+		History:          history,
+		PersonaName:      settings.PersonaName,
+		PersonaRole:      settings.PersonaRole,
+		Signature:        settings.Signature,
+		ForbiddenPhrases: settings.ForbiddenPhrases,
+		Tones:            tones,
+		Count:            len(tones),
+	}
+}
+
+func suggestionCacheKey(convID string, conv model.Conversation, tmpl *prompt.Compiled, language string, settings model.SuggestionSettings) string {
+	return fmt.Sprintf("%s:%d:%d:%s:%s:%s", convID, conv.Version, len(conv.Messages), tmpl.ID(), language, settings.Key())
+}
+
+// TranslateTexts menerjemahkan beberapa teks sekaligus dalam satu panggilan AI.
+// Urutan hasil sama dengan urutan input.
+func (s *AIService) TranslateTexts(ctx context.Context, texts []string, target string) ([]string, error) {
+	if len(texts) == 0 {
+		return []string{}, nil
+	}
+
+	redaction := s.redactor.NewSession()
+	defer s.auditRedaction(ctx, "translation", redaction)
+
+	redacted := make([]string, len(texts))
+	for i, t := range texts {
+		redacted[i] = redaction.Redact(t)
+	}
+
+	input, err := json.Marshal(redacted)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := s.prompts.Select(prompt.Translation, abKey(ctx, string(input)))
+	if err != nil {
+		return nil, err
+	}
+	promptText, err := tmpl.Execute(prompt.TranslationInput{Language: LanguageName(target), Count: len(texts), Input: string(input)})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.generate(ctx, "translation", ai.Request{Prompt: promptText, JSON: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var translated []string
+	if err := json.Unmarshal([]byte(resp.Text), &translated); err != nil {
+		return nil, fmt.Errorf("gagal Unmarshal JSON AI: %w", err)
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("jumlah terjemahan tidak sesuai: %d dari %d", len(translated), len(texts))
+	}
+	for i := range translated {
+		translated[i] = redaction.Restore(translated[i])
+	}
+
+	return translated, nil
+}
+
+// TranslateConversation menyiapkan tampilan percakapan dalam bahasa agent.
+// Hanya pesan yang bahasanya berbeda dari target yang dikirim ke AI.
+func (s *AIService) TranslateConversation(ctx context.Context, convID string, conv model.Conversation, target string) ([]model.TranslatedMessage, error) {
+	tmpl, err := s.prompts.Select(prompt.Translation, convID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s:%d:%d:%s:%s", convID, conv.Version, len(conv.Messages), tmpl.ID(), target)
+	value, err := s.cache.GetOrLoad(ctx, "translation", key, func(ctx context.Context) (interface{}, error) {
+		return s.translateMessages(ctx, conv.Messages, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]model.TranslatedMessage), nil
+}
+
+func (s *AIService) translateMessages(ctx context.Context, messages []model.Message, target string) ([]model.TranslatedMessage, error) {
+	result := make([]model.TranslatedMessage, len(messages))
+	var pending []string
+	var pendingIdx []int
+
+	for i, m := range messages {
+		result[i] = model.TranslatedMessage{Message: m, TranslatedText: m.Text}
+		lang := m.Language
+		if lang == "" {
+			lang = DetectLanguage(m.Text)
+		}
+		if lang != target {
+			pending = append(pending, m.Text)
+			pendingIdx = append(pendingIdx, i)
+		}
+	}
+
+	translated, err := s.TranslateTexts(ctx, pending, target)
+	if err != nil {
+		return nil, err
+	}
+	for i, idx := range pendingIdx {
+		result[idx].TranslatedText = translated[i]
+	}
+
+	return result, nil
+}
+
+// CacheStats mengembalikan statistik hit/miss cache AI per fitur
+func (s *AIService) CacheStats() map[string]cache.Stats {
+	return s.cache.Stats()
+}
+
+// memanggil provider AI (sudah dibungkus timeout, retry, dan fallback).
+// feature dipakai untuk pengecekan budget dan pencatatan pemakaian token.
+func (s *AIService) generate(ctx context.Context, feature string, req ai.Request) (*ai.Response, error) {
+	if err := s.usage.CheckBudget(feature); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.provider.Generate(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memanggil AI: %w", err)
+	}
+	s.usage.Record(ctx, feature, resp.Model, resp.Usage)
+
+	return resp, nil
+}
+
+// SupportsVision menandakan screenshot lampiran akan ikut dianalisis,
+// dipakai pemanggil untuk melewati pembacaan file yang tidak akan terpakai
+func (s *AIService) SupportsVision() bool {
+	return s.cfg.Vision && ai.SupportsVision(s.provider)
+}
+
+// batas total ukuran gambar per request, di bawah batas inline data Gemini (20 MB)
+const maxImageBytes = 15 << 20
+
+// visionImages memilih gambar yang boleh dikirim ke model sesuai konfigurasi dan kemampuan provider
+func (s *AIService) visionImages(images []ai.Image) []ai.Image {
+	if len(images) == 0 || !s.SupportsVision() {
+		return nil
+	}
+
+	var selected []ai.Image
+	total := 0
+	for _, img := range images {
+		if len(selected) >= s.cfg.MaxImages || total+len(img.Data) > maxImageBytes {
+			break
+		}
+		selected = append(selected, img)
+		total += len(img.Data)
+	}
+	return selected
+}
+
+// fewShotExamples mengambil koreksi agent terbaru sebagai contoh pelabelan. Teks contoh
+// ikut diredaksi dengan sesi yang sama, gagal membaca koreksi tidak menggagalkan analisis.
+func (s *AIService) fewShotExamples(ctx context.Context, redaction *pii.Session) []prompt.AnalysisExample {
+	if s.firestore == nil || s.cfg.FewShotExamples <= 0 {
+		return nil
+	}
+
+	value, err := s.cache.GetOrLoad(ctx, "few_shot", strconv.Itoa(s.cfg.FewShotExamples), func(ctx context.Context) (interface{}, error) {
+		return LatestCorrections(ctx, s.firestore, s.cfg.FewShotExamples)
+	})
+	if err != nil {
+		log.Printf("gagal mengambil contoh few-shot: %v", err)
+		return nil
+	}
+
+	var examples []prompt.AnalysisExample
+	for _, c := range value.([]model.AnalysisCorrection) {
+		examples = append(examples, prompt.AnalysisExample{
+			Text:          redaction.Redact(c.Text),
+			Category:      c.Human.Category,
+			PriorityScore: c.Human.PriorityScore,
+			Sentiment:     c.Human.Sentiment,
+		})
+	}
+	return examples
+}
+
+// kunci A/B split: percakapan yang sama selalu mendapat versi prompt yang sama
+func abKey(ctx context.Context, fallback string) string {
+	info := callInfoFrom(ctx)
+	switch {
+	case info.ConversationID != "":
+		return info.ConversationID
+	case info.UserID != "":
+		return info.UserID
+	default:
+		return fallback
+	}
+}
+
+// analysisCourse mengubah snapshot mata kuliah menjadi input prompt dengan jarak tenggat yang mudah dibaca model
+func analysisCourse(course *model.CourseSnapshot, now time.Time) *prompt.AnalysisCourse {
+	if course == nil {
+		return nil
+	}
+	in := &prompt.AnalysisCourse{ID: course.ID, Name: course.Name}
+	for _, d := range course.Deadlines {
+		if !d.DueAt.After(now) {
+			continue
+		}
+		until := d.DueAt.Sub(now)
+		dueIn := fmt.Sprintf("%d hari lagi", int(until.Hours()/24))
+		if until < 24*time.Hour {
+			dueIn = fmt.Sprintf("%d jam lagi", int(until.Hours()))
+		}
+		in.Deadlines = append(in.Deadlines, prompt.AnalysisDeadline{
+			Title: d.Title,
+			Type:  d.Type,
+			DueAt: d.DueAt.In(now.Location()).Format("2006-01-02 15:04"),
+			DueIn: dueIn,
+		})
+	}
+	return in
 }
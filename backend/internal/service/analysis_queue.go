@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// percakapan yang gagal berkali-kali dikeluarkan dari antrean
+const maxQueueAttempts = 5
+
+// QueueAnalysis menunda analisis percakapan, misal saat budget AI harian habis
+func (s *AIService) QueueAnalysis(ctx context.Context, convID string, reason string) error {
+	_, err := s.firestore.Collection("analysis_queue").Doc(convID).Set(ctx, map[string]interface{}{
+		"conversation_id": convID,
+		"reason":          reason,
+		"queued_at":       time.Now(),
+	}, firestore.MergeAll)
+	return err
+}
+
+// RunAnalysisQueue memproses antrean analisis secara berkala sampai ctx dibatalkan.
+// Hasil analisis yang tertunda tetap dieskalasi lewat moderation, sama seperti analisis langsung.
+func (s *AIService) RunAnalysisQueue(ctx context.Context, interval time.Duration, moderation *ModerationService) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.processAnalysisQueue(ctx, moderation)
+		}
+	}
+}
+
+func (s *AIService) processAnalysisQueue(ctx context.Context, moderation *ModerationService) {
+	iter := s.firestore.Collection("analysis_queue").OrderBy("queued_at", firestore.Asc).Limit(20).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return
+		}
+		if err != nil {
+			log.Printf("Gagal membaca antrean analisis: %v", err)
+			return
+		}
+
+		analysis, err := s.RefreshAnalysis(ctx, doc.Ref.ID, "queued")
+		if err == nil && analysis != nil {
+			if err := moderation.Escalate(ctx, doc.Ref.ID, analysis.Moderation, analysis.Summary); err != nil {
+				log.Printf("Gagal eskalasi percakapan %s: %v", doc.Ref.ID, err)
+			}
+		}
+		switch {
+		case errors.Is(err, ErrBudgetExceeded):
+			return //budget masih habis, coba lagi di putaran berikutnya
+		case err == nil, errors.Is(err, ErrConversationNotFound), errors.Is(err, ErrNoNewMessages):
+			doc.Ref.Delete(ctx)
+		default:
+			attempts, _ := doc.Data()["attempts"].(int64)
+			if attempts+1 >= maxQueueAttempts {
+				log.Printf("Analisis percakapan %s gagal %d kali, dikeluarkan dari antrean: %v", doc.Ref.ID, attempts+1, err)
+				doc.Ref.Delete(ctx)
+				continue
+			}
+			doc.Ref.Update(ctx, []firestore.Update{{Path: "attempts", Value: firestore.Increment(1)}})
+		}
+	}
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -90,3 +92,283 @@ func (s *AnalyticsService) GetOverview(ctx context.Context) (*model.AnalyticsOve
 		DailyTickets:         dailyTickets,
 	}, nil
 }
+
+// GetAIAccuracy membandingkan label AI dengan koreksi agent per kategori (kategori versi AI),
+// per versi prompt, dan per minggu untuk beberapa minggu terakhir.
+func (s *AnalyticsService) GetAIAccuracy(ctx context.Context, weeks int) (*model.AIAccuracyReport, error) {
+	now := time.Now()
+	thisWeek := weekStart(now)
+	since := thisWeek.AddDate(0, 0, -7*(weeks-1))
+
+	iter := s.firestore.Collection("conversations").Where("created_at", ">=", since).Documents(ctx)
+	defer iter.Stop()
+
+	report := &model.AIAccuracyReport{
+		Categories:     make(map[string]*model.AccuracyStats),
+		PromptVersions: make(map[string]*model.AccuracyStats),
+	}
+	weekly := make(map[string]*model.WeeklyAccuracy)
+	for i := 0; i < weeks; i++ {
+		key := since.AddDate(0, 0, 7*i).Format("2006-01-02")
+		weekly[key] = &model.WeeklyAccuracy{WeekStart: key, Categories: make(map[string]*model.AccuracyStats)}
+	}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var conv model.Conversation
+		if err := doc.DataTo(&conv); err != nil || !conv.AIAnalysis.IsProcessed {
+			continue
+		}
+
+		ai := model.AnalysisLabels{
+			Category:      conv.AIAnalysis.Category,
+			PriorityScore: conv.AIAnalysis.PriorityScore,
+			Sentiment:     conv.AIAnalysis.Sentiment,
+		}
+		var human *model.AnalysisLabels
+		if conv.HumanOverride != nil {
+			ai = conv.HumanOverride.AI
+			human = &conv.HumanOverride.Human
+		}
+		category := ai.Category
+		if category == "" {
+			category = "Others"
+		}
+
+		report.Overall.Add(ai, human)
+		statsFor(report.Categories, category).Add(ai, human)
+		statsFor(report.PromptVersions, conv.AIAnalysis.PromptVersion).Add(ai, human)
+		if w, ok := weekly[weekStart(conv.CreatedAt.In(now.Location())).Format("2006-01-02")]; ok {
+			w.Overall.Add(ai, human)
+			statsFor(w.Categories, category).Add(ai, human)
+		}
+	}
+
+	for i := 0; i < weeks; i++ {
+		report.Weeks = append(report.Weeks, *weekly[since.AddDate(0, 0, 7*i).Format("2006-01-02")])
+	}
+	return report, nil
+}
+
+func statsFor(m map[string]*model.AccuracyStats, key string) *model.AccuracyStats {
+	if m[key] == nil {
+		m[key] = &model.AccuracyStats{}
+	}
+	return m[key]
+}
+
+// weekStart mengembalikan hari Senin 00:00 dari minggu t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// GetCourseBreakdown menghitung tiket per mata kuliah untuk beberapa hari terakhir.
+// Tiket dari integrasi tanpa snapshot mata kuliah dikelompokkan lewat context.course_id.
+func (s *AnalyticsService) GetCourseBreakdown(ctx context.Context, days int) (*model.CourseBreakdown, error) {
+	since := time.Now().AddDate(0, 0, -days)
+	iter := s.firestore.Collection("conversations").Where("created_at", ">=", since).Documents(ctx)
+	defer iter.Stop()
+
+	courses := make(map[string]*model.CourseStats)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var conv model.Conversation
+		if err := doc.DataTo(&conv); err != nil {
+			continue
+		}
+		courseID, name := "", ""
+		switch {
+		case conv.Course != nil:
+			courseID, name = conv.Course.ID, conv.Course.Name
+		case conv.Context != nil:
+			courseID = strings.ToUpper(strings.TrimSpace(conv.Context.CourseID))
+		}
+		stats := courses[courseID]
+		if stats == nil {
+			stats = &model.CourseStats{CourseID: courseID, Categories: make(map[string]int)}
+			courses[courseID] = stats
+		}
+		if stats.Name == "" {
+			stats.Name = name
+		}
+		stats.Add(conv)
+	}
+
+	report := &model.CourseBreakdown{Days: days, Courses: []model.CourseStats{}}
+	for _, stats := range courses {
+		report.Courses = append(report.Courses, *stats)
+	}
+	//urut dari tiket terbanyak, tiket tanpa mata kuliah selalu di akhir
+	sort.Slice(report.Courses, func(i, j int) bool {
+		a, b := report.Courses[i], report.Courses[j]
+		if (a.CourseID == "") != (b.CourseID == "") {
+			return b.CourseID == ""
+		}
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.CourseID < b.CourseID
+	})
+	return report, nil
+}
+
+// GetCSAT merangkum survei kepuasan yang diminta beberapa minggu terakhir per agent yang
+// menyelesaikan tiket, per kategori (setelah koreksi agent), dan per minggu permintaan survei
+func (s *AnalyticsService) GetCSAT(ctx context.Context, weeks int) (*model.CSATReport, error) {
+	now := time.Now()
+	since := weekStart(now).AddDate(0, 0, -7*(weeks-1))
+
+	iter := s.firestore.Collection("conversations").Where("csat.requested_at", ">=", since).Documents(ctx)
+	defer iter.Stop()
+
+	report := &model.CSATReport{
+		Weeks:      weeks,
+		Overall:    model.NewCSATStats(),
+		Agents:     []model.AgentCSAT{},
+		Categories: make(map[string]*model.CSATStats),
+	}
+	weekly := make(map[string]*model.WeeklyCSAT)
+	for i := 0; i < weeks; i++ {
+		key := since.AddDate(0, 0, 7*i).Format("2006-01-02")
+		weekly[key] = &model.WeeklyCSAT{WeekStart: key, CSATStats: *model.NewCSATStats()}
+	}
+	agents := make(map[string]*model.AgentCSAT)
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var conv model.Conversation
+		if err := doc.DataTo(&conv); err != nil || conv.CSAT == nil {
+			continue
+		}
+		csat := *conv.CSAT
+
+		category := conversationCategory(&conv)
+		if category == "" {
+			category = "Others"
+		}
+		if report.Categories[category] == nil {
+			report.Categories[category] = model.NewCSATStats()
+		}
+		if agents[csat.AgentID] == nil {
+			agents[csat.AgentID] = &model.AgentCSAT{AgentID: csat.AgentID, CSATStats: *model.NewCSATStats()}
+		}
+
+		report.Overall.Add(csat)
+		report.Categories[category].Add(csat)
+		agents[csat.AgentID].Add(csat)
+		if w, ok := weekly[weekStart(csat.RequestedAt.In(now.Location())).Format("2006-01-02")]; ok {
+			w.Add(csat)
+		}
+	}
+
+	//nama agent diambil sekaligus dari users
+	var refs []*firestore.DocumentRef
+	for id := range agents {
+		if id != "" {
+			refs = append(refs, s.firestore.Collection("users").Doc(id))
+		}
+	}
+	if len(refs) > 0 {
+		docs, err := s.firestore.GetAll(ctx, refs)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			if doc.Exists() {
+				agents[doc.Ref.ID].Name, _ = doc.Data()["name"].(string)
+			}
+		}
+	}
+
+	for _, a := range agents {
+		report.Agents = append(report.Agents, *a)
+	}
+	//urut dari respons terbanyak, tiket yang ditutup otomatis selalu di akhir
+	sort.Slice(report.Agents, func(i, j int) bool {
+		a, b := report.Agents[i], report.Agents[j]
+		if (a.AgentID == "") != (b.AgentID == "") {
+			return b.AgentID == ""
+		}
+		if a.Responses != b.Responses {
+			return a.Responses > b.Responses
+		}
+		return a.AgentID < b.AgentID
+	})
+	for i := 0; i < weeks; i++ {
+		report.Weekly = append(report.Weekly, *weekly[since.AddDate(0, 0, 7*i).Format("2006-01-02")])
+	}
+	return report, nil
+}
+
+// GetAutoClose menghitung pengingat dan penutupan otomatis per kategori dan per hari
+func (s *AnalyticsService) GetAutoClose(ctx context.Context, days int) (*model.AutoCloseReport, error) {
+	now := time.Now()
+	y, m, d := now.AddDate(0, 0, -(days - 1)).Date()
+	since := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	iter := s.firestore.Collection("auto_close_events").Where("at", ">=", since).Documents(ctx)
+	defer iter.Stop()
+
+	report := &model.AutoCloseReport{Days: days, Categories: make(map[string]*model.AutoCloseStats)}
+	daily := make(map[string]*model.DailyAutoClose)
+	for i := 0; i < days; i++ {
+		key := since.AddDate(0, 0, i).Format("2006-01-02")
+		daily[key] = &model.DailyAutoClose{Date: key}
+	}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var event model.AutoCloseEvent
+		if err := doc.DataTo(&event); err != nil {
+			continue
+		}
+		category := event.Category
+		if category == "" {
+			category = "Others"
+		}
+		if report.Categories[category] == nil {
+			report.Categories[category] = &model.AutoCloseStats{}
+		}
+
+		report.Overall.Add(event)
+		report.Categories[category].Add(event)
+		if day, ok := daily[event.At.In(now.Location()).Format("2006-01-02")]; ok {
+			day.Add(event)
+		}
+	}
+
+	for i := 0; i < days; i++ {
+		report.Daily = append(report.Daily, *daily[since.AddDate(0, 0, i).Format("2006-01-02")])
+	}
+	return report, nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API key tidak ditemukan")
+	ErrInvalidAPIKey  = errors.New("API key tidak valid")
+	ErrAPIKeyRequest  = errors.New("permintaan API key tidak valid")
+)
+
+const (
+	apiKeyPrefix = "aik_"
+	// last_used_at cukup diperbarui sekali per menit agar tidak menulis di setiap request
+	apiKeyUsageInterval = time.Minute
+)
+
+var apiKeyScopes = map[string]bool{
+	model.ScopeConversationsCreate: true,
+	model.ScopeConversationsRead:   true,
+}
+
+// APIKeyService mengelola API key layanan. Format key: aik_<id>_<secret>, ID dipakai
+// untuk mengambil dokumen dan secret dicocokkan dengan hash SHA-256 yang tersimpan.
+type APIKeyService struct {
+	firestore *firestore.Client
+}
+
+func NewAPIKeyService(f *firestore.Client) *APIKeyService {
+	return &APIKeyService{firestore: f}
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]model.APIKey, error) {
+	iter := s.firestore.Collection("api_keys").OrderBy("created_at", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+
+	keys := []model.APIKey{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var k model.APIKey
+		if err := doc.DataTo(&k); err != nil {
+			continue
+		}
+		k.ID = doc.Ref.ID
+		keys = append(keys, k)
+	}
+}
+
+// Create membuat key baru. ttl 0 berarti tanpa kedaluwarsa.
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []string, ttl time.Duration, createdBy string) (*model.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name wajib diisi", ErrAPIKeyRequest)
+	}
+	if err := validateScopes(scopes); err != nil {
+		return nil, err
+	}
+
+	ref := s.firestore.Collection("api_keys").NewDoc()
+	secret := newAPIKeySecret()
+	now := time.Now()
+	k := model.APIKey{
+		Name:      name,
+		Hash:      hashAPIKeySecret(secret),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+	}
+	k.Key = apiKeyPrefix + ref.ID + "_" + secret
+	k.Prefix = k.Key[:len(apiKeyPrefix)+len(ref.ID)+5]
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		k.ExpiresAt = &expires
+	}
+
+	if _, err := ref.Set(ctx, k); err != nil {
+		return nil, err
+	}
+	k.ID = ref.ID
+	return &k, nil
+}
+
+// Rotate membuat secret baru untuk key yang sama. Secret lama tetap diterima selama grace
+// agar sistem luar sempat mengganti konfigurasinya tanpa downtime.
+func (s *APIKeyService) Rotate(ctx context.Context, id string, grace time.Duration) (*model.APIKey, error) {
+	ref := s.firestore.Collection("api_keys").Doc(id)
+	secret := newAPIKeySecret()
+	var rotated model.APIKey
+
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrAPIKeyNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := doc.DataTo(&rotated); err != nil {
+			return err
+		}
+		if rotated.RevokedAt != nil {
+			return fmt.Errorf("%w: key sudah dicabut", ErrAPIKeyRequest)
+		}
+
+		now := time.Now()
+		rotated.PreviousHash, rotated.PreviousExpiresAt = "", nil
+		if grace > 0 {
+			expires := now.Add(grace)
+			rotated.PreviousHash, rotated.PreviousExpiresAt = rotated.Hash, &expires
+		}
+		rotated.Hash = hashAPIKeySecret(secret)
+		rotated.RotatedAt = &now
+		rotated.Key = apiKeyPrefix + id + "_" + secret
+		rotated.Prefix = rotated.Key[:len(apiKeyPrefix)+len(id)+5]
+		return tx.Set(ref, rotated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	rotated.ID = id
+	return &rotated, nil
+}
+
+// Revoke mencabut key. Dokumen tetap disimpan untuk jejak audit.
+func (s *APIKeyService) Revoke(ctx context.Context, id string) error {
+	_, err := s.firestore.Collection("api_keys").Doc(id).Update(ctx, []firestore.Update{{Path: "revoked_at", Value: time.Now()}})
+	if status.Code(err) == codes.NotFound {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// Authenticate memverifikasi key dari request dan mencatat pemakaian terakhirnya
+func (s *APIKeyService) Authenticate(ctx context.Context, key, clientIP string) (*model.APIKey, error) {
+	id, secret, err := parseAPIKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.firestore.Collection("api_keys").Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	var k model.APIKey
+	if err := doc.DataTo(&k); err != nil {
+		return nil, err
+	}
+	k.ID = doc.Ref.ID
+
+	now := time.Now()
+	if err := checkAPIKey(&k, secret, now); err != nil {
+		return nil, err
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > apiKeyUsageInterval {
+		_, err := doc.Ref.Update(ctx, []firestore.Update{
+			{Path: "last_used_at", Value: now},
+			{Path: "last_used_ip", Value: clientIP},
+		})
+		if err != nil {
+			log.Printf("gagal mencatat pemakaian API key %s: %v", k.ID, err)
+		}
+	}
+	return &k, nil
+}
+
+// parseAPIKey memecah "aik_<id>_<secret>" menjadi id dokumen dan secret
+func parseAPIKey(key string) (id, secret string, err error) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", "", ErrInvalidAPIKey
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", "", ErrInvalidAPIKey
+	}
+	return id, secret, nil
+}
+
+// checkAPIKey mencocokkan secret dengan hash key saat ini, atau hash sebelum rotasi
+// selama masa tenggangnya belum habis, lalu memeriksa pencabutan dan kedaluwarsa
+func checkAPIKey(k *model.APIKey, secret string, now time.Time) error {
+	hash := hashAPIKeySecret(secret)
+	matches := subtle.ConstantTimeCompare([]byte(hash), []byte(k.Hash)) == 1
+	if !matches && k.PreviousHash != "" && k.PreviousExpiresAt != nil && now.Before(*k.PreviousExpiresAt) {
+		matches = subtle.ConstantTimeCompare([]byte(hash), []byte(k.PreviousHash)) == 1
+	}
+	switch {
+	case !matches:
+		return ErrInvalidAPIKey
+	case k.RevokedAt != nil:
+		return fmt.Errorf("%w: key sudah dicabut", ErrInvalidAPIKey)
+	case k.ExpiresAt != nil && now.After(*k.ExpiresAt):
+		return fmt.Errorf("%w: key sudah kedaluwarsa", ErrInvalidAPIKey)
+	}
+	return nil
+}
+
+// HasScope memeriksa apakah key memiliki izin tertentu
+func HasScope(k *model.APIKey, scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: minimal satu scope", ErrAPIKeyRequest)
+	}
+	for _, scope := range scopes {
+		if !apiKeyScopes[scope] {
+			return fmt.Errorf("%w: scope %q tidak dikenal", ErrAPIKeyRequest, scope)
+		}
+	}
+	return nil
+}
+
+func newAPIKeySecret() string {
+	var b [32]byte
+	rand.Read(b[:])
+	//base64 URL tanpa "_" agar pemisah id_secret tetap jelas
+	return strings.ReplaceAll(base64.RawURLEncoding.EncodeToString(b[:]), "_", "-")
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		key        string
+		id, secret string
+		valid      bool
+	}{
+		{"aik_k123_rahasia", "k123", "rahasia", true},
+		{"aik_k123_rahasia_dengan_garis", "k123", "rahasia_dengan_garis", true},
+		{"k123_rahasia", "", "", false},
+		{"AIK_k123_rahasia", "", "", false},
+		{"aik_k123", "", "", false},
+		{"aik__rahasia", "", "", false},
+		{"aik_k123_", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		id, secret, err := parseAPIKey(tt.key)
+		if !tt.valid {
+			if !errors.Is(err, ErrInvalidAPIKey) {
+				t.Errorf("parseAPIKey(%q) err = %v, ingin ErrInvalidAPIKey", tt.key, err)
+			}
+			continue
+		}
+		if err != nil || id != tt.id || secret != tt.secret {
+			t.Errorf("parseAPIKey(%q) = %q, %q, %v, ingin %q, %q", tt.key, id, secret, err, tt.id, tt.secret)
+		}
+	}
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	now := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	current, previous := hashAPIKeySecret("baru"), hashAPIKeySecret("lama")
+
+	tests := []struct {
+		name   string
+		key    model.APIKey
+		secret string
+		valid  bool
+	}{
+		{"secret saat ini", model.APIKey{Hash: current}, "baru", true},
+		{"secret salah", model.APIKey{Hash: current}, "salah", false},
+		{"secret lama dalam masa tenggang", model.APIKey{Hash: current, PreviousHash: previous, PreviousExpiresAt: &future}, "lama", true},
+		{"secret lama setelah masa tenggang", model.APIKey{Hash: current, PreviousHash: previous, PreviousExpiresAt: &past}, "lama", false},
+		{"secret lama tanpa batas tenggang", model.APIKey{Hash: current, PreviousHash: previous}, "lama", false},
+		{"secret baru tetap berlaku saat tenggang", model.APIKey{Hash: current, PreviousHash: previous, PreviousExpiresAt: &future}, "baru", true},
+		{"dicabut", model.APIKey{Hash: current, RevokedAt: &past}, "baru", false},
+		{"kedaluwarsa", model.APIKey{Hash: current, ExpiresAt: &past}, "baru", false},
+		{"belum kedaluwarsa", model.APIKey{Hash: current, ExpiresAt: &future}, "baru", true},
+	}
+	for _, tt := range tests {
+		err := checkAPIKey(&tt.key, tt.secret, now)
+		if tt.valid && err != nil {
+			t.Errorf("%s: err = %v, ingin valid", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("%s: err = %v, ingin ErrInvalidAPIKey", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/storage"
+)
+
+var (
+	ErrInvalidAttachment  = errors.New("lampiran tidak valid")
+	ErrAttachmentNotFound = errors.New("lampiran tidak ditemukan")
+	ErrInvalidSignature   = errors.New("link unduhan tidak valid atau sudah kedaluwarsa")
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// AttachmentService memvalidasi, menyimpan, dan membuat link unduhan lampiran
+type AttachmentService struct {
+	store storage.Store
+	cfg   *config.StorageConfig
+	key   []byte
+}
+
+func NewAttachmentService(store storage.Store, cfg *config.StorageConfig) *AttachmentService {
+	key := []byte(cfg.SigningKey)
+	if len(key) == 0 {
+		//tanpa kunci tetap, link unduhan lokal tidak berlaku lagi setelah server restart
+		key = make([]byte, 32)
+		rand.Read(key)
+		log.Println("Peringatan: ATTACHMENT_SIGNING_KEY kosong, memakai kunci acak")
+	}
+	return &AttachmentService{store: store, cfg: cfg, key: key}
+}
+
+// Upload adalah file lampiran yang belum disimpan, dari form multipart maupun channel lain (email)
+type Upload struct {
+	FileName string
+	Size     int64
+	Open     func() (io.ReadCloser, error)
+}
+
+func MultipartUploads(files []*multipart.FileHeader) []Upload {
+	uploads := make([]Upload, len(files))
+	for i, fh := range files {
+		uploads[i] = Upload{
+			FileName: fh.Filename,
+			Size:     fh.Size,
+			Open:     func() (io.ReadCloser, error) { return fh.Open() },
+		}
+	}
+	return uploads
+}
+
+func BytesUpload(fileName string, data []byte) Upload {
+	return Upload{
+		FileName: fileName,
+		Size:     int64(len(data)),
+		Open:     func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil },
+	}
+}
+
+// Save memvalidasi semua file lebih dulu lalu menyimpannya ke blob storage.
+// Tipe file ditentukan dari isi file, bukan dari header yang dikirim klien.
+func (s *AttachmentService) Save(ctx context.Context, convID, uploader string, files []Upload) ([]model.Attachment, error) {
+	if len(files) > s.cfg.MaxFiles {
+		return nil, fmt.Errorf("%w: maksimal %d file", ErrInvalidAttachment, s.cfg.MaxFiles)
+	}
+
+	type upload struct {
+		Upload
+		contentType string
+	}
+	var uploads []upload
+	for _, f := range files {
+		if f.Size > s.cfg.MaxFileSize {
+			return nil, fmt.Errorf("%w: %s melebihi %d MB", ErrInvalidAttachment, f.FileName, s.cfg.MaxFileSize>>20)
+		}
+		contentType, err := s.sniff(f)
+		if err != nil {
+			return nil, err
+		}
+		if !s.allowed(contentType) {
+			return nil, fmt.Errorf("%w: tipe %s (%s) tidak diizinkan", ErrInvalidAttachment, contentType, f.FileName)
+		}
+		uploads = append(uploads, upload{Upload: f, contentType: contentType})
+	}
+
+	var attachments []model.Attachment
+	for _, u := range uploads {
+		att := model.Attachment{
+			ID:          newAttachmentID(),
+			FileName:    safeFileName(u.FileName),
+			ContentType: u.contentType,
+			Size:        u.Size,
+			UploadedBy:  uploader,
+			CreatedAt:   time.Now(),
+		}
+		att.StorageKey = fmt.Sprintf("conversations/%s/%s/%s", convID, att.ID, att.FileName)
+
+		f, err := u.Open()
+		if err != nil {
+			s.cleanup(ctx, attachments)
+			return nil, err
+		}
+		//batasi ulang saat menyalin, Size dari header bisa saja tidak jujur
+		err = s.store.Put(ctx, att.StorageKey, att.ContentType, io.LimitReader(f, s.cfg.MaxFileSize))
+		f.Close()
+		if err != nil {
+			s.cleanup(ctx, attachments)
+			return nil, fmt.Errorf("gagal menyimpan lampiran: %w", err)
+		}
+		attachments = append(attachments, att)
+	}
+	return attachments, nil
+}
+
+// cleanup menghapus file yang sudah tersimpan jika unggahan berikutnya gagal
+func (s *AttachmentService) cleanup(ctx context.Context, attachments []model.Attachment) {
+	for _, att := range attachments {
+		if err := s.store.Delete(ctx, att.StorageKey); err != nil {
+			log.Printf("gagal menghapus lampiran %s: %v", att.StorageKey, err)
+		}
+	}
+}
+
+func (s *AttachmentService) sniff(u Upload) (string, error) {
+	f, err := u.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	return contentType, nil
+}
+
+func (s *AttachmentService) allowed(contentType string) bool {
+	for _, t := range s.cfg.AllowedTypes {
+		if strings.EqualFold(t, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find mencari lampiran di seluruh pesan percakapan
+func (s *AttachmentService) Find(conv model.Conversation, attachmentID string) (*model.Attachment, error) {
+	for _, m := range conv.Messages {
+		for _, att := range m.Attachments {
+			if att.ID == attachmentID {
+				return &att, nil
+			}
+		}
+	}
+	return nil, ErrAttachmentNotFound
+}
+
+// Link membuat link unduhan berumur pendek. Cloud Storage memakai signed URL-nya sendiri,
+// storage lokal memakai endpoint unduhan API yang ditandatangani HMAC.
+func (s *AttachmentService) Link(ctx context.Context, att model.Attachment) (string, time.Time, error) {
+	expires := time.Now().Add(s.cfg.LinkTTL)
+	signed, err := s.store.SignedURL(ctx, att.StorageKey, s.cfg.LinkTTL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if signed != "" {
+		return signed, expires, nil
+	}
+
+	q := url.Values{}
+	q.Set("key", att.StorageKey)
+	q.Set("type", att.ContentType)
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("sig", s.sign(att.StorageKey, att.ContentType, expires.Unix()))
+	return s.cfg.PublicURL + "/api/v1/attachments/download?" + q.Encode(), expires, nil
+}
+
+// OpenSigned memverifikasi link unduhan lokal lalu membuka file-nya
+func (s *AttachmentService) OpenSigned(ctx context.Context, key, contentType, expires, sig string) (io.ReadCloser, error) {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.sign(key, contentType, exp))) {
+		return nil, ErrInvalidSignature
+	}
+
+	r, err := s.store.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	return r, err
+}
+
+// Images membaca lampiran bergambar untuk dianalisis AI. Lampiran yang gagal dibaca
+// dilewati saja, analisis tetap berjalan dengan gambar yang tersisa atau teks saja.
+func (s *AttachmentService) Images(ctx context.Context, attachments []model.Attachment) []ai.Image {
+	var images []ai.Image
+	for _, att := range attachments {
+		if !strings.HasPrefix(att.ContentType, "image/") {
+			continue
+		}
+		r, err := s.store.Open(ctx, att.StorageKey)
+		if err != nil {
+			log.Printf("gagal membuka lampiran %s: %v", att.StorageKey, err)
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxFileSize))
+		r.Close()
+		if err != nil {
+			log.Printf("gagal membaca lampiran %s: %v", att.StorageKey, err)
+			continue
+		}
+		images = append(images, ai.Image{MIMEType: att.ContentType, Data: data})
+	}
+	return images
+}
+
+func (s *AttachmentService) sign(key, contentType string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%s\n%d", key, contentType, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newAttachmentID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "att_" + hex.EncodeToString(b)
+}
+
+func safeFileName(name string) string {
+	name = unsafeFileChars.ReplaceAllString(filepath.Base(name), "_")
+	name = strings.Trim(name, "._")
+	if name == "" {
+		return "file"
+	}
+	if len(name) > 100 {
+		ext := filepath.Ext(name)
+		name = name[:100-len(ext)] + ext
+	}
+	return name
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSafeFileName(t *testing.T) {
+	long := strings.Repeat("a", 120) + ".png"
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"bukti bayar.png", "bukti_bayar.png"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\budi\foto.jpg`, "C_Users_budi_foto.jpg"},
+		{"/tmp/../x.pdf", "x.pdf"},
+		{"..", "file"},
+		{".htaccess", "htaccess"},
+		{"", "file"},
+		{"struk pembayaran (1).pdf", "struk_pembayaran_1_.pdf"},
+		{"tangkapan–layar.webp", "tangkapan_layar.webp"},
+		{long, strings.Repeat("a", 96) + ".png"},
+	}
+	for _, tt := range tests {
+		if got := safeFileName(tt.name); got != tt.want {
+			t.Errorf("safeFileName(%q) = %q, ingin %q", tt.name, got, tt.want)
+		}
+	}
+}
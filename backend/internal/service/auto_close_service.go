@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// kebijakan untuk kategori yang tidak punya kebijakan sendiri
+const DefaultAutoCloseCategory = "default"
+
+const maxAutoCloseDays = 90
+
+var (
+	ErrInvalidAutoClosePolicy  = errors.New("kebijakan penutupan otomatis tidak valid")
+	ErrAutoClosePolicyNotFound = errors.New("kebijakan penutupan otomatis tidak ditemukan")
+)
+
+// AutoCloseService mengingatkan mahasiswa yang belum membalas pertanyaan agent dan menutup
+// tiketnya otomatis jika tetap tidak ada balasan. Tiket dianggap menunggu mahasiswa jika
+// statusnya in_progress dan pesan terakhir (selain pesan sistem) berasal dari support.
+type AutoCloseService struct {
+	firestore *firestore.Client
+	notifier  *EmailNotifier
+	webhooks  *WebhookService
+	chat      *ChatService
+	csat      *CSATService
+	cfg       *config.AutoCloseConfig
+}
+
+func NewAutoCloseService(f *firestore.Client, notifier *EmailNotifier, webhooks *WebhookService, chat *ChatService, csat *CSATService, cfg *config.AutoCloseConfig) *AutoCloseService {
+	return &AutoCloseService{firestore: f, notifier: notifier, webhooks: webhooks, chat: chat, csat: csat, cfg: cfg}
+}
+
+// DefaultAutoClosePolicy adalah kebijakan dari env, dipakai jika kategori default belum diatur
+func (s *AutoCloseService) DefaultAutoClosePolicy() model.AutoClosePolicy {
+	return model.AutoClosePolicy{
+		Category:     DefaultAutoCloseCategory,
+		Enabled:      s.cfg.CloseDays > 0,
+		ReminderDays: s.cfg.ReminderDays,
+		CloseDays:    s.cfg.CloseDays,
+	}
+}
+
+// ListPolicies mengembalikan kebijakan default diikuti kebijakan per kategori
+func (s *AutoCloseService) ListPolicies(ctx context.Context) ([]model.AutoClosePolicy, error) {
+	policies, err := s.loadPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := []model.AutoClosePolicy{policies[DefaultAutoCloseCategory]}
+	for category, p := range policies {
+		if category != DefaultAutoCloseCategory {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list[1:], func(i, j int) bool { return list[1+i].Category < list[1+j].Category })
+	return list, nil
+}
+
+func (s *AutoCloseService) SavePolicy(ctx context.Context, p model.AutoClosePolicy) (model.AutoClosePolicy, error) {
+	p.Category = strings.TrimSpace(p.Category)
+	switch {
+	case p.Category == "" || len(p.Category) > 50 || strings.Contains(p.Category, "/"):
+		return p, fmt.Errorf("%w: kategori tidak valid", ErrInvalidAutoClosePolicy)
+	case p.CloseDays < 1 || p.CloseDays > maxAutoCloseDays:
+		return p, fmt.Errorf("%w: close_days harus 1-%d", ErrInvalidAutoClosePolicy, maxAutoCloseDays)
+	case p.ReminderDays < 0 || (p.ReminderDays > 0 && p.ReminderDays >= p.CloseDays):
+		return p, fmt.Errorf("%w: reminder_days harus 0 (tanpa pengingat) atau kurang dari close_days", ErrInvalidAutoClosePolicy)
+	}
+	p.UpdatedAt = time.Now()
+	if _, err := s.firestore.Collection("auto_close_policies").Doc(p.Category).Set(ctx, p); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// DeletePolicy menghapus kebijakan kategori sehingga kategori tersebut kembali memakai default
+func (s *AutoCloseService) DeletePolicy(ctx context.Context, category string) error {
+	ref := s.firestore.Collection("auto_close_policies").Doc(category)
+	if _, err := ref.Get(ctx); status.Code(err) == codes.NotFound {
+		return ErrAutoClosePolicyNotFound
+	} else if err != nil {
+		return err
+	}
+	_, err := ref.Delete(ctx)
+	return err
+}
+
+func (s *AutoCloseService) loadPolicies(ctx context.Context) (map[string]model.AutoClosePolicy, error) {
+	policies := map[string]model.AutoClosePolicy{DefaultAutoCloseCategory: s.DefaultAutoClosePolicy()}
+	iter := s.firestore.Collection("auto_close_policies").Documents(ctx)
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return policies, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var p model.AutoClosePolicy
+		if err := doc.DataTo(&p); err != nil {
+			continue
+		}
+		p.Category = doc.Ref.ID
+		policies[p.Category] = p
+	}
+}
+
+// Run menjalankan Sweep secara berkala sampai ctx dibatalkan
+func (s *AutoCloseService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := s.Sweep(ctx)
+			if err != nil {
+				log.Printf("Gagal memeriksa tiket untuk penutupan otomatis: %v", err)
+				continue
+			}
+			if result.Reminded > 0 || result.Closed > 0 {
+				log.Printf("Penutupan otomatis: %d pengingat dikirim, %d tiket ditutup", result.Reminded, result.Closed)
+			}
+		}
+	}
+}
+
+// Sweep memeriksa semua tiket in_progress sekali. Setiap perubahan dicek ulang di dalam
+// transaksi sehingga aman dijalankan bersamaan oleh beberapa instance server.
+func (s *AutoCloseService) Sweep(ctx context.Context) (*model.AutoCloseSweep, error) {
+	policies, err := s.loadPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := s.firestore.Collection("conversations").Where("status", "==", model.StatusInProgress).Documents(ctx)
+	defer iter.Stop()
+
+	result := &model.AutoCloseSweep{}
+	now := time.Now()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+
+		var conv model.Conversation
+		if err := doc.DataTo(&conv); err != nil {
+			continue
+		}
+		conv.ID = doc.Ref.ID
+		result.Checked++
+
+		category := conversationCategory(&conv)
+		policy, ok := policies[category]
+		if !ok {
+			policy = policies[DefaultAutoCloseCategory]
+		}
+		action := autoCloseAction(&conv, policy, now)
+		if action == "" {
+			continue
+		}
+
+		done, err := s.apply(ctx, conv.ID, action, category, policy)
+		if err != nil {
+			log.Printf("Gagal memproses penutupan otomatis tiket %s: %v", conv.ID, err)
+			continue
+		}
+		switch {
+		case done && action == model.AutoCloseReminder:
+			result.Reminded++
+		case done && action == model.AutoCloseClosed:
+			result.Closed++
+		}
+	}
+}
+
+// apply menjalankan pengingat atau penutupan dalam transaksi, false jika tiket sudah
+// berubah (misal mahasiswa membalas) sejak dibaca
+func (s *AutoCloseService) apply(ctx context.Context, convID, action, category string, policy model.AutoClosePolicy) (bool, error) {
+	ref := s.firestore.Collection("conversations").Doc(convID)
+	now := time.Now()
+	var conv model.Conversation
+	var msg model.Message
+	var idle int
+	changed := false
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		changed = false
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		conv = model.Conversation{}
+		if err := doc.DataTo(&conv); err != nil {
+			return err
+		}
+		conv.ID = doc.Ref.ID
+		if autoCloseAction(&conv, policy, now) != action {
+			changed = true
+			return nil
+		}
+
+		since, _ := waitingSince(&conv)
+		idle = int(now.Sub(since).Hours() / 24)
+		text := autoCloseText(conv.Language, action, policy.CloseDays, since.AddDate(0, 0, policy.CloseDays).Sub(now))
+		msg = model.Message{Sender: "system", Text: text, Timestamp: now, Language: DetectLanguage(text), Channel: MessageChannel(&conv)}
+
+		updates := []firestore.Update{
+			{Path: "messages", Value: firestore.ArrayUnion(msg)},
+			{Path: "last_message", Value: text},
+			{Path: "updated_at", Value: now},
+			{Path: "version", Value: firestore.Increment(1)},
+		}
+		if action == model.AutoCloseReminder {
+			updates = append(updates, firestore.Update{Path: "reminder_sent_at", Value: now})
+		} else {
+			updates = append(updates,
+				firestore.Update{Path: "status", Value: model.StatusResolved},
+				firestore.Update{Path: "resolved_at", Value: now},
+				firestore.Update{Path: "auto_closed_at", Value: now},
+			)
+		}
+		return tx.Update(ref, updates)
+	})
+	if err != nil || changed {
+		return false, err
+	}
+
+	event := model.AutoCloseEvent{ConversationID: convID, Type: action, Category: category, IdleDays: idle, At: now}
+	if _, _, err := s.firestore.Collection("auto_close_events").Add(ctx, event); err != nil {
+		log.Printf("gagal mencatat kejadian penutupan otomatis %s: %v", convID, err)
+	}
+
+	events := []model.EmailEvent{{Type: model.EmailEventAgentReply, Text: msg.Text, At: now}}
+	s.webhooks.Publish(ctx, model.WebhookMessageCreated, convID, map[string]interface{}{"message": msg})
+	if action == model.AutoCloseClosed {
+		events = append(events, model.EmailEvent{Type: model.EmailEventStatusChanged, Status: model.StatusResolved, At: now})
+		s.webhooks.Publish(ctx, model.WebhookStatusChanged, convID, map[string]interface{}{"from": model.StatusInProgress, "to": model.StatusResolved, "auto_closed": true})
+	}
+	if err := s.notifier.Notify(ctx, convID, events...); err != nil {
+		log.Printf("gagal menjadwalkan email notifikasi %s: %v", convID, err)
+	}
+	if err := s.chat.Relay(ctx, &conv, msg.Text); err != nil {
+		log.Printf("gagal meneruskan pesan otomatis %s ke chat: %v", convID, err)
+	}
+	if action == model.AutoCloseClosed {
+		if err := s.csat.Request(ctx, convID, ""); err != nil {
+			log.Printf("gagal meminta survei kepuasan %s: %v", convID, err)
+		}
+	}
+	return true, nil
+}
+
+// autoCloseAction menentukan langkah untuk tiket: tutup, kirim pengingat, atau kosong.
+// Dipakai saat Sweep dan saat dicek ulang di transaksi apply.
+func autoCloseAction(conv *model.Conversation, policy model.AutoClosePolicy, now time.Time) string {
+	if conv.Status != model.StatusInProgress || !policy.Enabled || policy.CloseDays < 1 {
+		return ""
+	}
+	//tiket krisis (dipin di inbox) yang sepi justru harus ditindaklanjuti agent, bukan ditutup timer
+	if conv.Flag == ModerationCrisis || conv.AIAnalysis.Moderation == ModerationCrisis {
+		return ""
+	}
+	since, ok := waitingSince(conv)
+	if !ok {
+		return ""
+	}
+	if !now.Before(since.AddDate(0, 0, policy.CloseDays)) {
+		return model.AutoCloseClosed
+	}
+	//pengingat hanya sekali per pertanyaan agent
+	reminded := conv.ReminderSentAt != nil && !conv.ReminderSentAt.Before(since)
+	if policy.ReminderDays > 0 && !reminded && !now.Before(since.AddDate(0, 0, policy.ReminderDays)) {
+		return model.AutoCloseReminder
+	}
+	return ""
+}
+
+// waitingSince adalah waktu pesan support terakhir jika belum dibalas mahasiswa.
+// Pesan sistem (pengingat) tidak dihitung.
+func waitingSince(conv *model.Conversation) (time.Time, bool) {
+	for i := len(conv.Messages) - 1; i >= 0; i-- {
+		switch conv.Messages[i].Sender {
+		case "system":
+			continue
+		case "student":
+			return time.Time{}, false
+		default:
+			return conv.Messages[i].Timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// conversationCategory memakai kategori hasil koreksi agent jika ada
+func conversationCategory(conv *model.Conversation) string {
+	if conv.HumanOverride != nil && conv.HumanOverride.Human.Category != "" {
+		return conv.HumanOverride.Human.Category
+	}
+	return conv.AIAnalysis.Category
+}
+
+// autoCloseText adalah isi pesan sistem sesuai bahasa mahasiswa. remaining adalah sisa
+// waktu sampai tiket ditutup, dipakai di pengingat.
+func autoCloseText(language, action string, closeDays int, remaining time.Duration) string {
+	days := max(1, int(math.Ceil(remaining.Hours()/24)))
+	en := language == "en"
+	switch {
+	case action == model.AutoCloseReminder && en:
+		return fmt.Sprintf("Hi, we are still waiting for your reply to continue with this ticket. If we don't hear from you within %d day(s), the ticket will be closed automatically.", days)
+	case action == model.AutoCloseReminder:
+		return fmt.Sprintf("Halo, kami masih menunggu balasan Anda untuk melanjutkan tiket ini. Jika tidak ada balasan dalam %d hari, tiket akan ditutup otomatis.", days)
+	case en:
+		return fmt.Sprintf("This ticket was closed automatically because we received no reply for %d day(s). Please open a new ticket if you still need help.", closeDays)
+	default:
+		return fmt.Sprintf("Tiket ini ditutup otomatis karena tidak ada balasan selama %d hari. Silakan buat tiket baru jika Anda masih membutuhkan bantuan.", closeDays)
+	}
+}
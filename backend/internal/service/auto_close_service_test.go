@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+)
+
+func TestWaitingSince(t *testing.T) {
+	t0 := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	msg := func(sender string, hours int) model.Message {
+		return model.Message{Sender: sender, Timestamp: t0.Add(time.Duration(hours) * time.Hour)}
+	}
+
+	tests := []struct {
+		name     string
+		messages []model.Message
+		want     time.Time
+		ok       bool
+	}{
+		{"tanpa pesan", nil, time.Time{}, false},
+		{"terakhir dari mahasiswa", []model.Message{msg("support", 0), msg("student", 1)}, time.Time{}, false},
+		{"terakhir dari support", []model.Message{msg("student", 0), msg("support", 2)}, t0.Add(2 * time.Hour), true},
+		{"pengingat sistem diabaikan", []model.Message{msg("student", 0), msg("support", 2), msg("system", 50)}, t0.Add(2 * time.Hour), true},
+		{"mahasiswa membalas setelah pengingat", []model.Message{msg("support", 2), msg("system", 50), msg("student", 60)}, time.Time{}, false},
+		{"hanya pesan sistem", []model.Message{msg("system", 0)}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := waitingSince(&model.Conversation{Messages: tt.messages})
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("%s: waitingSince = %v, %v, ingin %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAutoCloseAction(t *testing.T) {
+	asked := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	policy := model.AutoClosePolicy{Enabled: true, ReminderDays: 3, CloseDays: 7}
+	remindedAt := asked.Add(3 * day)
+	oldReminder := asked.Add(-day)
+
+	tests := []struct {
+		name     string
+		status   string
+		flag     string
+		aiMod    string
+		reminder *time.Time
+		policy   model.AutoClosePolicy
+		now      time.Time
+		want     string
+	}{
+		{"belum waktunya", model.StatusInProgress, "", "", nil, policy, asked.Add(2 * day), ""},
+		{"waktunya pengingat", model.StatusInProgress, "", "", nil, policy, asked.Add(3 * day), model.AutoCloseReminder},
+		{"pengingat sudah dikirim", model.StatusInProgress, "", "", &remindedAt, policy, asked.Add(5 * day), ""},
+		{"pengingat untuk pertanyaan lama", model.StatusInProgress, "", "", &oldReminder, policy, asked.Add(4 * day), model.AutoCloseReminder},
+		{"waktunya ditutup", model.StatusInProgress, "", "", &remindedAt, policy, asked.Add(7 * day), model.AutoCloseClosed},
+		{"ditutup tanpa pengingat", model.StatusInProgress, "", "", nil, model.AutoClosePolicy{Enabled: true, CloseDays: 2}, asked.Add(2 * day), model.AutoCloseClosed},
+		{"kebijakan nonaktif", model.StatusInProgress, "", "", nil, model.AutoClosePolicy{CloseDays: 7}, asked.Add(30 * day), ""},
+		{"CloseDays tidak valid", model.StatusInProgress, "", "", nil, model.AutoClosePolicy{Enabled: true}, asked.Add(30 * day), ""},
+		{"bukan in_progress", model.StatusResolved, "", "", nil, policy, asked.Add(30 * day), ""},
+		{"tiket krisis tidak ditutup", model.StatusInProgress, ModerationCrisis, "", nil, policy, asked.Add(30 * day), ""},
+		{"krisis dari AI tidak diingatkan", model.StatusInProgress, "", ModerationCrisis, nil, policy, asked.Add(4 * day), ""},
+		{"tiket abusive tetap diproses", model.StatusInProgress, ModerationAbusive, "", nil, policy, asked.Add(30 * day), model.AutoCloseClosed},
+	}
+	for _, tt := range tests {
+		conv := &model.Conversation{
+			Status:         tt.status,
+			Flag:           tt.flag,
+			ReminderSentAt: tt.reminder,
+			Messages: []model.Message{
+				{Sender: "student", Timestamp: asked.Add(-time.Hour)},
+				{Sender: "support", Timestamp: asked},
+			},
+		}
+		conv.AIAnalysis.Moderation = tt.aiMod
+		if got := autoCloseAction(conv, tt.policy, tt.now); got != tt.want {
+			t.Errorf("%s: autoCloseAction = %q, ingin %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package service
+
+import "context"
+
+type callInfoKey struct{}
+
+// CallInfo membawa konteks pemanggilan AI (percakapan & user) untuk keperluan audit
+type CallInfo struct {
+	ConversationID string
+	UserID         string
+}
+
+func WithCallInfo(ctx context.Context, info CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+func callInfoFrom(ctx context.Context) CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(CallInfo)
+	return info
+}
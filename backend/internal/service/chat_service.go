@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/chat"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrChannelNotFound  = errors.New("channel chat tidak tersedia")
+	ErrChatLinkNotFound = errors.New("akun chat belum terhubung")
+)
+
+// huruf & angka yang tidak mudah tertukar (tanpa 0/O, 1/I/L)
+const chatCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// balasan bot, pesan chat selalu dalam bahasa Indonesia
+const (
+	chatMsgNotLinked   = "Akun ini belum terhubung dengan akun mahasiswa. Buka dashboard mahasiswa, pilih Hubungkan Telegram, lalu kirim /start <kode> ke bot ini."
+	chatMsgLinked      = "Akun berhasil terhubung. Silakan kirim keluhan Anda di sini, balasan tim support juga akan dikirim ke chat ini."
+	chatMsgInvalidCode = "Kode tidak valid atau sudah kedaluwarsa. Buat kode baru dari dashboard mahasiswa."
+	chatMsgNewTicket   = "Pesan berikutnya akan dibuka sebagai keluhan baru."
+	chatMsgTextOnly    = "Saat ini hanya pesan teks yang bisa diproses. Kirim lampiran lewat dashboard mahasiswa."
+	chatMsgCreated     = "Keluhan Anda sudah kami terima (tiket %s). Balasan tim support akan dikirim di chat ini. Kirim /baru untuk membuka keluhan lain."
+)
+
+// ChatService menerima pesan dari aplikasi chat, menautkan akun chat dengan mahasiswa,
+// menyambungkan pesan ke percakapan, dan meneruskan balasan agent kembali ke chat
+type ChatService struct {
+	firestore  *firestore.Client
+	complaints *ComplaintService
+	cfg        *config.ChatConfig
+	channels   map[string]chat.Channel
+}
+
+func NewChatService(f *firestore.Client, complaints *ComplaintService, cfg *config.ChatConfig, channels ...chat.Channel) *ChatService {
+	s := &ChatService{firestore: f, complaints: complaints, cfg: cfg, channels: make(map[string]chat.Channel)}
+	for _, ch := range channels {
+		s.channels[ch.Name()] = ch
+	}
+	return s
+}
+
+func (s *ChatService) Channel(name string) (chat.Channel, bool) {
+	ch, ok := s.channels[name]
+	return ch, ok
+}
+
+// Receive memproses satu pesan masuk. Error hanya untuk kegagalan sementara agar
+// platform mengirim ulang webhook, update yang sama tidak diproses dua kali.
+func (s *ChatService) Receive(ctx context.Context, ch chat.Channel, in *chat.Inbound) error {
+	seen := s.firestore.Collection("chat_updates").Doc(ch.Name() + "_" + in.UpdateID)
+	if _, err := seen.Create(ctx, map[string]interface{}{"received_at": time.Now()}); err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return nil
+		}
+		return err
+	}
+	if err := s.receive(ctx, ch, in); err != nil {
+		seen.Delete(ctx)
+		return err
+	}
+	return nil
+}
+
+func (s *ChatService) receive(ctx context.Context, ch chat.Channel, in *chat.Inbound) error {
+	command, arg, _ := strings.Cut(in.Text, " ")
+	if command == "/start" && strings.TrimSpace(arg) != "" {
+		return s.link(ctx, ch, in, strings.TrimSpace(arg))
+	}
+
+	linkRef := s.firestore.Collection("chat_links").Doc(chatLinkID(ch.Name(), in.UserID))
+	doc, err := linkRef.Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return s.reply(ctx, ch, in.ChatID, chatMsgNotLinked)
+	}
+	if err != nil {
+		return err
+	}
+	var link model.ChatLink
+	if err := doc.DataTo(&link); err != nil {
+		return err
+	}
+
+	switch {
+	case command == "/start":
+		return s.reply(ctx, ch, in.ChatID, chatMsgLinked)
+	case command == "/baru" || command == "/new":
+		if _, err := linkRef.Update(ctx, []firestore.Update{{Path: "active_conversation_id", Value: firestore.Delete}}); err != nil {
+			return err
+		}
+		return s.reply(ctx, ch, in.ChatID, chatMsgNewTicket)
+	case in.Text == "":
+		return s.reply(ctx, ch, in.ChatID, chatMsgTextOnly)
+	}
+
+	student, err := s.firestore.Collection("users").Doc(link.StudentID).Get(ctx)
+	if err != nil {
+		return err
+	}
+	name, _ := student.Data()["name"].(string)
+	email, _ := student.Data()["verified_email"].(string)
+	input := ComplaintInput{
+		StudentID:    link.StudentID,
+		StudentName:  name,
+		StudentEmail: email,
+		Text:         in.Text,
+		Channel:      ch.Name(),
+	}
+
+	//pesan lanjutan masuk ke tiket aktif selama belum resolved
+	if link.ActiveConversationID != "" {
+		conv, err := s.activeConversation(ctx, link)
+		if err != nil {
+			return err
+		}
+		if conv != nil {
+			_, err := s.complaints.Reply(ctx, conv.ID, input)
+			return err
+		}
+	}
+
+	input.Chat = &model.ChatThread{Channel: ch.Name(), ChatID: in.ChatID, UserID: in.UserID, Username: in.Username}
+	conv, err := s.complaints.Create(ctx, input)
+	if err != nil {
+		return err
+	}
+	if _, err := linkRef.Update(ctx, []firestore.Update{{Path: "active_conversation_id", Value: conv.ID}}); err != nil {
+		log.Printf("gagal menyimpan tiket aktif chat %s: %v", linkRef.ID, err)
+	}
+	return s.reply(ctx, ch, in.ChatID, fmt.Sprintf(chatMsgCreated, conv.ID))
+}
+
+func (s *ChatService) activeConversation(ctx context.Context, link model.ChatLink) (*model.Conversation, error) {
+	doc, err := s.firestore.Collection("conversations").Doc(link.ActiveConversationID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		return nil, err
+	}
+	if conv.StudentId != link.StudentID || conv.Status == model.StatusResolved {
+		return nil, nil
+	}
+	conv.ID = doc.Ref.ID
+	return &conv, nil
+}
+
+// link menukar kode sekali pakai menjadi tautan akun chat. Tautan lama mahasiswa di
+// channel yang sama dilepas agar satu mahasiswa hanya punya satu akun chat per channel.
+func (s *ChatService) link(ctx context.Context, ch chat.Channel, in *chat.Inbound, code string) error {
+	codeRef := s.firestore.Collection("chat_link_codes").Doc(strings.ToUpper(code))
+	var studentID string
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(codeRef)
+		if status.Code(err) == codes.NotFound {
+			return ErrChatLinkNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var c model.ChatLinkCode
+		if err := doc.DataTo(&c); err != nil {
+			return err
+		}
+		if c.Channel != ch.Name() || time.Now().After(c.ExpiresAt) {
+			return ErrChatLinkNotFound
+		}
+		studentID = c.StudentID
+		return tx.Delete(codeRef)
+	})
+	if errors.Is(err, ErrChatLinkNotFound) {
+		return s.reply(ctx, ch, in.ChatID, chatMsgInvalidCode)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.Unlink(ctx, studentID, ch.Name()); err != nil && !errors.Is(err, ErrChatLinkNotFound) {
+		return err
+	}
+	link := model.ChatLink{
+		Channel:   ch.Name(),
+		UserID:    in.UserID,
+		ChatID:    in.ChatID,
+		Username:  in.Username,
+		StudentID: studentID,
+		LinkedAt:  time.Now(),
+	}
+	if _, err := s.firestore.Collection("chat_links").Doc(chatLinkID(ch.Name(), in.UserID)).Set(ctx, link); err != nil {
+		return err
+	}
+	return s.reply(ctx, ch, in.ChatID, chatMsgLinked)
+}
+
+// CreateLinkCode membuat kode sekali pakai untuk menautkan akun chat mahasiswa
+func (s *ChatService) CreateLinkCode(ctx context.Context, studentID, channel string) (*model.ChatLinkCode, error) {
+	if _, ok := s.channels[channel]; !ok {
+		return nil, ErrChannelNotFound
+	}
+	code := model.ChatLinkCode{
+		Code:      newChatCode(),
+		Channel:   channel,
+		StudentID: studentID,
+		ExpiresAt: time.Now().Add(s.cfg.LinkCodeTTL),
+	}
+	if _, err := s.firestore.Collection("chat_link_codes").Doc(code.Code).Create(ctx, code); err != nil {
+		return nil, err
+	}
+	if channel == model.ChannelTelegram && s.cfg.TelegramBotUsername != "" {
+		code.DeepLink = "https://t.me/" + s.cfg.TelegramBotUsername + "?start=" + code.Code
+	}
+	return &code, nil
+}
+
+func (s *ChatService) ListLinks(ctx context.Context, studentID string) ([]model.ChatLink, error) {
+	iter := s.firestore.Collection("chat_links").Where("student_id", "==", studentID).Documents(ctx)
+	defer iter.Stop()
+
+	links := []model.ChatLink{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return links, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var l model.ChatLink
+		if err := doc.DataTo(&l); err != nil {
+			continue
+		}
+		links = append(links, l)
+	}
+}
+
+// Unlink melepas akun chat mahasiswa di satu channel. Balasan agent untuk tiket
+// dari chat tersebut tidak lagi diteruskan.
+func (s *ChatService) Unlink(ctx context.Context, studentID, channel string) error {
+	iter := s.firestore.Collection("chat_links").
+		Where("student_id", "==", studentID).
+		Where("channel", "==", channel).
+		Documents(ctx)
+	defer iter.Stop()
+
+	found := false
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return ErrChatLinkNotFound
+	}
+	return nil
+}
+
+// Relay meneruskan balasan agent ke chat asal percakapan. Percakapan yang bukan dari
+// chat diabaikan. Pesan tidak dikirim jika akun chat sudah dilepas dari mahasiswa.
+func (s *ChatService) Relay(ctx context.Context, conv *model.Conversation, text string) error {
+	if s == nil || conv.Chat == nil {
+		return nil
+	}
+	ch, ok := s.channels[conv.Chat.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrChannelNotFound, conv.Chat.Channel)
+	}
+
+	doc, err := s.firestore.Collection("chat_links").Doc(chatLinkID(conv.Chat.Channel, conv.Chat.UserID)).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return ErrChatLinkNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if studentID, _ := doc.Data()["student_id"].(string); studentID != conv.StudentId {
+		return ErrChatLinkNotFound
+	}
+	_, err = ch.Send(ctx, conv.Chat.ChatID, text)
+	return err
+}
+
+// MessageChannel adalah channel yang dicatat untuk balasan agent di sebuah percakapan
+func MessageChannel(conv *model.Conversation) string {
+	if conv.Chat != nil {
+		return conv.Chat.Channel
+	}
+	//pengunjung widget yang belum mendaftar membaca balasan dari widget
+	if conv.WidgetSessionID != "" && conv.StudentId == "" {
+		return model.ChannelWidget
+	}
+	return model.ChannelWeb
+}
+
+func (s *ChatService) reply(ctx context.Context, ch chat.Channel, chatID, text string) error {
+	//balasan bot hanya informasi, gagal kirim tidak perlu membuat platform mengulang webhook
+	if _, err := ch.Send(ctx, chatID, text); err != nil {
+		log.Printf("gagal membalas chat %s %s: %v", ch.Name(), chatID, err)
+	}
+	return nil
+}
+
+func chatLinkID(channel, userID string) string {
+	return channel + "_" + userID
+}
+
+func newChatCode() string {
+	var b [8]byte
+	rand.Read(b[:])
+	code := make([]byte, len(b))
+	for i, v := range b {
+		code[i] = chatCodeAlphabet[int(v)%len(chatCodeAlphabet)]
+	}
+	return string(code)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+)
+
+// ComplaintInput adalah pesan mahasiswa dari channel mana pun (form web, email)
+type ComplaintInput struct {
+	StudentID    string
+	StudentName  string
+	StudentEmail string
+	Text         string
+	Files        []Upload
+	Channel      string // channel asal pesan, misal model.ChannelWeb
+
+	// chat asal jika keluhan dikirim lewat aplikasi chat
+	Chat *model.ChatThread
+
+	// sesi widget jika keluhan dikirim pengunjung anonim
+	WidgetSessionID string
+
+	// Message-ID email masuk, dipakai untuk menyambungkan balasan email berikutnya
+	EmailMessageIDs []string
+
+	// konteks dari sistem luar (LMS), ikut dibaca AI saat analisis awal
+	Context *model.TicketContext
+
+	// mata kuliah terkait beserta tenggat terdekat, ikut dibaca AI untuk menentukan prioritas
+	Course *model.CourseSnapshot
+}
+
+// ComplaintService adalah pipeline bersama untuk keluhan baru dan balasan mahasiswa:
+// simpan lampiran, analisis AI, simpan percakapan, eskalasi, dan antrean analisis ulang
+type ComplaintService struct {
+	firestore   *firestore.Client
+	ai          *AIService
+	moderation  *ModerationService
+	attachments *AttachmentService
+	webhooks    *WebhookService
+}
+
+func NewComplaintService(f *firestore.Client, aiSvc *AIService, moderation *ModerationService, attachments *AttachmentService, webhooks *WebhookService) *ComplaintService {
+	return &ComplaintService{firestore: f, ai: aiSvc, moderation: moderation, attachments: attachments, webhooks: webhooks}
+}
+
+// Create membuat percakapan baru. Lampiran divalidasi & disimpan lebih dulu supaya AI
+// tidak dipanggil untuk keluhan yang ditolak (ErrInvalidAttachment).
+func (s *ComplaintService) Create(ctx context.Context, in ComplaintInput) (*model.Conversation, error) {
+	ref := s.firestore.Collection("conversations").NewDoc()
+	var attachments []model.Attachment
+	if len(in.Files) > 0 {
+		var err error
+		if attachments, err = s.attachments.Save(ctx, ref.ID, in.StudentID, in.Files); err != nil {
+			return nil, err
+		}
+	}
+
+	aiCtx := WithCallInfo(ctx, CallInfo{UserID: in.StudentID})
+	var images []ai.Image
+	if len(attachments) > 0 && s.ai.SupportsVision() {
+		images = s.attachments.Images(aiCtx, attachments)
+	}
+	analysis, analysisErr := s.ai.ProcessComplaint(aiCtx, in.Text+describeContext(in.Context), in.Course, images...)
+	if analysisErr != nil {
+		analysis = &model.AIAnalysis{
+			PriorityScore: 5,
+			Category:      "Uncategorized",
+			Summary:       "AI Analysis Failed",
+			Moderation:    ScreenText(in.Text),
+			IsProcessed:   false,
+		}
+	}
+
+	language := DetectLanguage(in.Text)
+	now := time.Now()
+	conv := model.Conversation{
+		ID:           ref.ID,
+		StudentId:    in.StudentID,
+		StudentName:  in.StudentName,
+		StudentEmail: in.StudentEmail,
+		Status:       model.StatusOpen,
+		Language:     language,
+		LastMessage:  in.Text,
+		AIAnalysis:   *analysis,
+		Messages: []model.Message{
+			{
+				Sender:      "student",
+				Text:        in.Text,
+				Timestamp:   now,
+				Language:    language,
+				Channel:     in.Channel,
+				Attachments: attachments,
+			},
+		},
+		EmailMessageIDs: in.EmailMessageIDs,
+		Context:         in.Context,
+		Course:          in.Course,
+		Chat:            in.Chat,
+		WidgetSessionID: in.WidgetSessionID,
+		Version:         1,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if _, err := ref.Create(ctx, conv); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan keluhan: %w", err)
+	}
+	s.webhooks.Publish(ctx, model.WebhookConversationCreated, ref.ID, map[string]interface{}{
+		"student_id":   conv.StudentId,
+		"student_name": conv.StudentName,
+		"status":       conv.Status,
+		"language":     conv.Language,
+		"message":      conv.Messages[0],
+	})
+
+	//tiket krisis langsung dipin & support lead dinotifikasi
+	if err := s.moderation.Escalate(ctx, ref.ID, analysis.Moderation, analysis.Summary); err != nil {
+		log.Printf("Gagal eskalasi percakapan %s: %v", ref.ID, err)
+	}
+
+	if analysis.IsProcessed {
+		if err := s.ai.RecordAnalysis(ctx, ref.ID, *analysis, "initial"); err != nil {
+			log.Printf("Gagal menyimpan riwayat analisis %s: %v", ref.ID, err)
+		}
+	} else {
+		//analisis gagal atau budget AI habis, diproses ulang oleh worker antrean
+		if err := s.ai.QueueAnalysis(ctx, ref.ID, analysisErr.Error()); err != nil {
+			log.Printf("Gagal memasukkan analisis %s ke antrean: %v", ref.ID, err)
+		}
+	}
+
+	return &conv, nil
+}
+
+// Reply menambahkan balasan mahasiswa ke percakapan yang sudah ada.
+// Kepemilikan percakapan dicek oleh pemanggil.
+func (s *ComplaintService) Reply(ctx context.Context, convID string, in ComplaintInput) (*model.Message, error) {
+	msg := model.Message{
+		Sender:    "student",
+		Text:      in.Text,
+		Timestamp: time.Now(),
+		Language:  DetectLanguage(in.Text),
+		Channel:   in.Channel,
+	}
+	if len(in.Files) > 0 {
+		var err error
+		if msg.Attachments, err = s.attachments.Save(ctx, convID, in.StudentID, in.Files); err != nil {
+			return nil, err
+		}
+	}
+
+	updates := []firestore.Update{
+		{Path: "messages", Value: firestore.ArrayUnion(msg)},
+		{Path: "last_message", Value: in.Text},
+		{Path: "language", Value: msg.Language},
+		{Path: "updated_at", Value: time.Now()},
+		{Path: "version", Value: firestore.Increment(1)},
+	}
+	if len(in.EmailMessageIDs) > 0 {
+		updates = append(updates, firestore.Update{Path: "email_message_ids", Value: firestore.ArrayUnion(toInterfaces(in.EmailMessageIDs)...)})
+	}
+	if _, err := s.firestore.Collection("conversations").Doc(convID).Update(ctx, updates); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan balasan: %w", err)
+	}
+	s.webhooks.Publish(ctx, model.WebhookMessageCreated, convID, map[string]interface{}{"message": msg})
+
+	//screening cepat agar pesan krisis langsung dieskalasi tanpa menunggu ringkasan AI
+	if level := ScreenText(in.Text); level != ModerationNone {
+		if err := s.moderation.Escalate(ctx, convID, level, in.Text); err != nil {
+			log.Printf("Gagal eskalasi percakapan %s: %v", convID, err)
+		}
+	}
+
+	go s.RefreshAnalysisInBackground(convID)
+
+	return &msg, nil
+}
+
+// RefreshAnalysisInBackground memperbarui analisis setelah ada pesan baru.
+// request sudah selesai saat goroutine berjalan, jadi pakai context.Background()
+func (s *ComplaintService) RefreshAnalysisInBackground(convID string) {
+	ctx := context.Background()
+	analysis, err := s.ai.MaybeRefreshAnalysis(ctx, convID)
+	if err != nil {
+		if !errors.Is(err, ErrAnalysisConflict) {
+			log.Printf("Gagal memperbarui analisis percakapan %s: %v", convID, err)
+		}
+		return
+	}
+
+	if analysis != nil {
+		if err := s.moderation.Escalate(ctx, convID, analysis.Moderation, analysis.Summary); err != nil {
+			log.Printf("Gagal eskalasi percakapan %s: %v", convID, err)
+		}
+	}
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+// describeContext menuliskan konteks sistem luar sebagai tambahan teks keluhan untuk AI
+func describeContext(tc *model.TicketContext) string {
+	if tc == nil {
+		return ""
+	}
+	var parts []string
+	for _, f := range []struct{ label, value string }{
+		{"sumber", tc.Source},
+		{"mata kuliah", tc.CourseID},
+		{"tugas", tc.AssignmentID},
+		{"kode error", tc.ErrorCode},
+	} {
+		if f.value != "" {
+			parts = append(parts, f.label+": "+f.value)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "\n\n[Konteks " + strings.Join(parts, ", ") + "]"
+}
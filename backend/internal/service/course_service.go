@@ -0,0 +1,582 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrCourseNotFound = errors.New("mata kuliah tidak ditemukan")
+	ErrNotEnrolled    = errors.New("mahasiswa tidak terdaftar di mata kuliah ini")
+	ErrInvalidCourse  = errors.New("data mata kuliah tidak valid")
+)
+
+const (
+	// tenggat yang ikut ke tiket & prompt AI hanya yang jatuh dalam 14 hari ke depan
+	courseDeadlineWindow = 14 * 24 * time.Hour
+	courseMaxDeadlines   = 5
+	courseBatchSize      = 400
+)
+
+// jenis file CSV yang bisa diimport
+const (
+	CourseImportCourses     = "courses"     // kolom: course_id, name, lecturer
+	CourseImportEnrollments = "enrollments" // kolom: course_id, student_id atau student_email
+	CourseImportDeadlines   = "deadlines"   // kolom: course_id, title, due_at, type
+)
+
+// CourseService mengelola mata kuliah, tenggat, dan daftar mahasiswa per mata kuliah
+type CourseService struct {
+	firestore *firestore.Client
+}
+
+func NewCourseService(f *firestore.Client) *CourseService {
+	return &CourseService{firestore: f}
+}
+
+func (s *CourseService) List(ctx context.Context) ([]model.Course, error) {
+	iter := s.firestore.Collection("courses").Documents(ctx)
+	defer iter.Stop()
+
+	courses := []model.Course{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return courses, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var c model.Course
+		if err := doc.DataTo(&c); err != nil {
+			continue
+		}
+		c.ID = doc.Ref.ID
+		courses = append(courses, c)
+	}
+}
+
+func (s *CourseService) Get(ctx context.Context, id string) (*model.Course, error) {
+	doc, err := s.firestore.Collection("courses").Doc(normalizeCourseID(id)).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrCourseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c model.Course
+	if err := doc.DataTo(&c); err != nil {
+		return nil, err
+	}
+	c.ID = doc.Ref.ID
+	return &c, nil
+}
+
+// Save membuat atau mengganti mata kuliah beserta seluruh tenggatnya
+func (s *CourseService) Save(ctx context.Context, c model.Course) (*model.Course, error) {
+	c.ID = normalizeCourseID(c.ID)
+	c.Name = strings.TrimSpace(c.Name)
+	if err := validateCourseID(c.ID); err != nil {
+		return nil, err
+	}
+	if c.Name == "" {
+		return nil, fmt.Errorf("%w: name wajib diisi", ErrInvalidCourse)
+	}
+	seen := make(map[string]bool)
+	for i, d := range c.Deadlines {
+		c.Deadlines[i].Title = strings.TrimSpace(d.Title)
+		key := strings.ToLower(c.Deadlines[i].Title)
+		if key == "" || d.DueAt.IsZero() {
+			return nil, fmt.Errorf("%w: setiap tenggat wajib memiliki title dan due_at", ErrInvalidCourse)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: tenggat %q duplikat", ErrInvalidCourse, c.Deadlines[i].Title)
+		}
+		seen[key] = true
+	}
+	if c.Deadlines == nil {
+		c.Deadlines = []model.CourseDeadline{}
+	}
+	sortDeadlines(c.Deadlines)
+
+	ref := s.firestore.Collection("courses").Doc(c.ID)
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		c.CreatedAt, c.UpdatedAt = time.Now(), time.Now()
+		doc, err := tx.Get(ref)
+		if err == nil {
+			if created, ok := doc.Data()["created_at"].(time.Time); ok {
+				c.CreatedAt = created
+			}
+		} else if status.Code(err) != codes.NotFound {
+			return err
+		}
+		return tx.Set(ref, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Delete menghapus mata kuliah beserta data pendaftarannya.
+// Snapshot mata kuliah di tiket lama tetap tersimpan.
+func (s *CourseService) Delete(ctx context.Context, id string) error {
+	id = normalizeCourseID(id)
+	if _, err := s.firestore.Collection("courses").Doc(id).Delete(ctx, firestore.Exists); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrCourseNotFound
+		}
+		return err
+	}
+
+	iter := s.firestore.Collection("enrollments").Where("course_id", "==", id).Documents(ctx)
+	defer iter.Stop()
+	batch, pending := s.firestore.Batch(), 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		batch.Delete(doc.Ref)
+		if pending++; pending == courseBatchSize {
+			if _, err := batch.Commit(ctx); err != nil {
+				return err
+			}
+			batch, pending = s.firestore.Batch(), 0
+		}
+	}
+	if pending > 0 {
+		_, err := batch.Commit(ctx)
+		return err
+	}
+	return nil
+}
+
+// ListForStudent mengembalikan mata kuliah yang diikuti mahasiswa, hanya dengan tenggat yang belum lewat
+func (s *CourseService) ListForStudent(ctx context.Context, studentID string) ([]model.Course, error) {
+	iter := s.firestore.Collection("enrollments").Where("student_id", "==", studentID).Documents(ctx)
+	defer iter.Stop()
+
+	var refs []*firestore.DocumentRef
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		courseID, _ := doc.Data()["course_id"].(string)
+		refs = append(refs, s.firestore.Collection("courses").Doc(courseID))
+	}
+
+	courses := []model.Course{}
+	if len(refs) == 0 {
+		return courses, nil
+	}
+	docs, err := s.firestore.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, doc := range docs {
+		var c model.Course
+		if !doc.Exists() || doc.DataTo(&c) != nil {
+			continue
+		}
+		c.ID = doc.Ref.ID
+		upcoming := []model.CourseDeadline{}
+		for _, d := range c.Deadlines {
+			if d.DueAt.After(now) {
+				upcoming = append(upcoming, d)
+			}
+		}
+		c.Deadlines = upcoming
+		courses = append(courses, c)
+	}
+	sort.Slice(courses, func(i, j int) bool { return courses[i].ID < courses[j].ID })
+	return courses, nil
+}
+
+// SnapshotForStudent dipakai saat mahasiswa memilih mata kuliah di form keluhan
+func (s *CourseService) SnapshotForStudent(ctx context.Context, courseID, studentID string) (*model.CourseSnapshot, error) {
+	courseID = normalizeCourseID(courseID)
+	_, err := s.firestore.Collection("enrollments").Doc(enrollmentID(courseID, studentID)).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Snapshot(ctx, courseID)
+}
+
+// Snapshot mengambil mata kuliah beserta tenggat terdekat untuk disalin ke tiket
+func (s *CourseService) Snapshot(ctx context.Context, courseID string) (*model.CourseSnapshot, error) {
+	c, err := s.Get(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	return courseSnapshot(c, time.Now()), nil
+}
+
+func courseSnapshot(c *model.Course, now time.Time) *model.CourseSnapshot {
+	snap := &model.CourseSnapshot{ID: c.ID, Name: c.Name}
+	for _, d := range c.Deadlines {
+		if d.DueAt.After(now) && d.DueAt.Sub(now) <= courseDeadlineWindow {
+			snap.Deadlines = append(snap.Deadlines, d)
+		}
+	}
+	sortDeadlines(snap.Deadlines)
+	if len(snap.Deadlines) > courseMaxDeadlines {
+		snap.Deadlines = snap.Deadlines[:courseMaxDeadlines]
+	}
+	return snap
+}
+
+// Import membaca CSV dengan baris header. Kolom dicari berdasarkan nama header
+// sehingga urutan kolom bebas dan kolom lain diabaikan.
+func (s *CourseService) Import(ctx context.Context, kind string, r io.Reader) (*model.CourseImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file CSV kosong", ErrInvalidCourse)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCourse, err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+
+	var rows []csvRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: baris %d: %v", ErrInvalidCourse, line, err)
+		}
+		rows = append(rows, csvRow{line: line, record: record, columns: columns})
+	}
+
+	switch kind {
+	case CourseImportCourses:
+		if err := requireColumns(columns, "course_id", "name"); err != nil {
+			return nil, err
+		}
+		return s.importCourses(ctx, rows)
+	case CourseImportEnrollments:
+		if err := requireColumns(columns, "course_id"); err != nil {
+			return nil, err
+		}
+		if _, ok := columns["student_id"]; !ok {
+			if err := requireColumns(columns, "student_email"); err != nil {
+				return nil, fmt.Errorf("%w: butuh kolom student_id atau student_email", ErrInvalidCourse)
+			}
+		}
+		return s.importEnrollments(ctx, rows)
+	case CourseImportDeadlines:
+		if err := requireColumns(columns, "course_id", "title", "due_at"); err != nil {
+			return nil, err
+		}
+		return s.importDeadlines(ctx, rows)
+	}
+	return nil, fmt.Errorf("%w: jenis import %q tidak dikenal", ErrInvalidCourse, kind)
+}
+
+func (s *CourseService) importCourses(ctx context.Context, rows []csvRow) (*model.CourseImportResult, error) {
+	result := &model.CourseImportResult{Errors: []model.CourseImportError{}}
+	known, err := s.courseIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := s.newBatch()
+	now := time.Now()
+	for _, row := range rows {
+		id, name := normalizeCourseID(row.get("course_id")), row.get("name")
+		if err := validateCourseID(id); err != nil {
+			importFail(result, row.line, err)
+			continue
+		}
+		if name == "" {
+			importFail(result, row.line, fmt.Errorf("%w: name wajib diisi", ErrInvalidCourse))
+			continue
+		}
+
+		ref := s.firestore.Collection("courses").Doc(id)
+		if !known[id] {
+			known[id] = true
+			course := model.Course{Name: name, Lecturer: row.get("lecturer"), Deadlines: []model.CourseDeadline{}, CreatedAt: now, UpdatedAt: now}
+			err = w.set(ctx, ref, course)
+		} else {
+			//mata kuliah yang sudah ada: tenggat & created_at tidak disentuh
+			data := map[string]interface{}{"name": name, "updated_at": now}
+			if lecturer := row.get("lecturer"); lecturer != "" {
+				data["lecturer"] = lecturer
+			}
+			err = w.set(ctx, ref, data, firestore.MergeAll)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Imported++
+	}
+	if err := w.flush(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *CourseService) importEnrollments(ctx context.Context, rows []csvRow) (*model.CourseImportResult, error) {
+	result := &model.CourseImportResult{Errors: []model.CourseImportError{}}
+	known, err := s.courseIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := s.newBatch()
+	seen := make(map[string]bool)
+	now := time.Now()
+	for _, row := range rows {
+		courseID := normalizeCourseID(row.get("course_id"))
+		if !known[courseID] {
+			importFail(result, row.line, fmt.Errorf("%w: %s", ErrCourseNotFound, courseID))
+			continue
+		}
+		studentID := row.get("student_id")
+		if studentID == "" {
+			email := strings.ToLower(row.get("student_email"))
+			if email == "" {
+				importFail(result, row.line, fmt.Errorf("%w: student_id atau student_email wajib diisi", ErrInvalidCourse))
+				continue
+			}
+			//hanya email akun yang sudah diverifikasi, agar roster tidak bisa diklaim lewat email palsu
+			student, err := findStudentByEmail(ctx, s.firestore, email)
+			if err != nil {
+				return nil, err
+			}
+			if student == nil {
+				importFail(result, row.line, fmt.Errorf("%w: %s", ErrStudentNotFound, email))
+				continue
+			}
+			studentID = student.ID
+		}
+
+		id := enrollmentID(courseID, studentID)
+		if seen[id] {
+			result.Skipped++
+			continue
+		}
+		seen[id] = true
+		enrollment := model.Enrollment{CourseID: courseID, StudentID: studentID, CreatedAt: now}
+		if err := w.set(ctx, s.firestore.Collection("enrollments").Doc(id), enrollment); err != nil {
+			return nil, err
+		}
+		result.Imported++
+	}
+	if err := w.flush(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importDeadlines menggabungkan tenggat berdasarkan judul: judul yang sama diperbarui, yang baru ditambahkan
+func (s *CourseService) importDeadlines(ctx context.Context, rows []csvRow) (*model.CourseImportResult, error) {
+	result := &model.CourseImportResult{Errors: []model.CourseImportError{}}
+	byCourse := make(map[string][]model.CourseDeadline)
+	var order []string
+	for _, row := range rows {
+		courseID, title := normalizeCourseID(row.get("course_id")), row.get("title")
+		if err := validateCourseID(courseID); err != nil {
+			importFail(result, row.line, err)
+			continue
+		}
+		if title == "" {
+			importFail(result, row.line, fmt.Errorf("%w: title wajib diisi", ErrInvalidCourse))
+			continue
+		}
+		dueAt, err := parseDeadline(row.get("due_at"))
+		if err != nil {
+			importFail(result, row.line, err)
+			continue
+		}
+		if _, ok := byCourse[courseID]; !ok {
+			order = append(order, courseID)
+		}
+		byCourse[courseID] = append(byCourse[courseID], model.CourseDeadline{Title: title, Type: row.get("type"), DueAt: dueAt})
+	}
+
+	for _, courseID := range order {
+		ref := s.firestore.Collection("courses").Doc(courseID)
+		err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			doc, err := tx.Get(ref)
+			if status.Code(err) == codes.NotFound {
+				return ErrCourseNotFound
+			}
+			if err != nil {
+				return err
+			}
+			var c model.Course
+			if err := doc.DataTo(&c); err != nil {
+				return err
+			}
+			index := make(map[string]int)
+			for i, d := range c.Deadlines {
+				index[strings.ToLower(d.Title)] = i
+			}
+			for _, d := range byCourse[courseID] {
+				if i, ok := index[strings.ToLower(d.Title)]; ok {
+					c.Deadlines[i] = d
+					continue
+				}
+				index[strings.ToLower(d.Title)] = len(c.Deadlines)
+				c.Deadlines = append(c.Deadlines, d)
+			}
+			sortDeadlines(c.Deadlines)
+			return tx.Update(ref, []firestore.Update{
+				{Path: "deadlines", Value: c.Deadlines},
+				{Path: "updated_at", Value: time.Now()},
+			})
+		})
+		if errors.Is(err, ErrCourseNotFound) {
+			for _, row := range rows {
+				if normalizeCourseID(row.get("course_id")) == courseID {
+					importFail(result, row.line, fmt.Errorf("%w: %s", ErrCourseNotFound, courseID))
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Imported += len(byCourse[courseID])
+	}
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Line < result.Errors[j].Line })
+	return result, nil
+}
+
+func (s *CourseService) courseIDs(ctx context.Context) (map[string]bool, error) {
+	iter := s.firestore.Collection("courses").Select().Documents(ctx)
+	defer iter.Stop()
+	ids := make(map[string]bool)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids[doc.Ref.ID] = true
+	}
+}
+
+// courseBatch menulis dokumen per batch, firestore membatasi jumlah operasi per commit
+type courseBatch struct {
+	client  *firestore.Client
+	batch   *firestore.WriteBatch
+	pending int
+}
+
+func (s *CourseService) newBatch() *courseBatch {
+	return &courseBatch{client: s.firestore, batch: s.firestore.Batch()}
+}
+
+func (b *courseBatch) set(ctx context.Context, ref *firestore.DocumentRef, data interface{}, opts ...firestore.SetOption) error {
+	b.batch.Set(ref, data, opts...)
+	if b.pending++; b.pending < courseBatchSize {
+		return nil
+	}
+	return b.flush(ctx)
+}
+
+func (b *courseBatch) flush(ctx context.Context) error {
+	if b.pending == 0 {
+		return nil
+	}
+	_, err := b.batch.Commit(ctx)
+	b.batch, b.pending = b.client.Batch(), 0
+	return err
+}
+
+type csvRow struct {
+	line    int
+	record  []string
+	columns map[string]int
+}
+
+func (r csvRow) get(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+func requireColumns(columns map[string]int, names ...string) error {
+	for _, name := range names {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("%w: kolom %s tidak ada di header CSV", ErrInvalidCourse, name)
+		}
+	}
+	return nil
+}
+
+func importFail(result *model.CourseImportResult, line int, err error) {
+	result.Errors = append(result.Errors, model.CourseImportError{Line: line, Error: err.Error()})
+}
+
+// kode mata kuliah dipakai sebagai ID dokumen, jadi dinormalisasi ke huruf besar
+func normalizeCourseID(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+func validateCourseID(id string) error {
+	if id == "" || len(id) > 40 || strings.ContainsAny(id, "/ ") || strings.HasPrefix(id, "__") {
+		return fmt.Errorf("%w: course_id %q tidak valid", ErrInvalidCourse, id)
+	}
+	return nil
+}
+
+func enrollmentID(courseID, studentID string) string {
+	return courseID + "_" + studentID
+}
+
+func sortDeadlines(deadlines []model.CourseDeadline) {
+	sort.SliceStable(deadlines, func(i, j int) bool { return deadlines[i].DueAt.Before(deadlines[j].DueAt) })
+}
+
+// parseDeadline menerima RFC3339, "2006-01-02 15:04", atau tanggal saja (dianggap akhir hari)
+func parseDeadline(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t.Add(24*time.Hour - time.Minute), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: due_at %q harus berformat RFC3339, YYYY-MM-DD HH:MM, atau YYYY-MM-DD", ErrInvalidCourse, value)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+)
+
+func TestParseDeadline(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		valid bool
+	}{
+		{"2026-02-10T23:59:00+07:00", time.Date(2026, 2, 10, 23, 59, 0, 0, time.FixedZone("", 7*3600)), true},
+		{"2026-02-10T16:59:00Z", time.Date(2026, 2, 10, 16, 59, 0, 0, time.UTC), true},
+		{"2026-02-10 13:00", time.Date(2026, 2, 10, 13, 0, 0, 0, time.Local), true},
+		{"2026-02-10", time.Date(2026, 2, 10, 23, 59, 0, 0, time.Local), true},
+		{"10/02/2026", time.Time{}, false},
+		{"2026-02-30", time.Time{}, false},
+		{"besok", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseDeadline(tt.value)
+		if !tt.valid {
+			if !errors.Is(err, ErrInvalidCourse) {
+				t.Errorf("parseDeadline(%q) err = %v, ingin ErrInvalidCourse", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseDeadline(%q) = %v, %v, ingin %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestCourseSnapshot(t *testing.T) {
+	now := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	course := &model.Course{ID: "IF2110", Name: "Algoritma dan Struktur Data", Deadlines: []model.CourseDeadline{
+		{Title: "lewat", DueAt: now.Add(-time.Hour)},
+		{Title: "tepat sekarang", DueAt: now},
+		{Title: "terlalu jauh", DueAt: now.Add(14*day + time.Minute)},
+		{Title: "batas jendela", DueAt: now.Add(14 * day)},
+		{Title: "kuis 3", DueAt: now.Add(2 * day)},
+		{Title: "tugas 1", DueAt: now.Add(time.Hour)},
+	}}
+
+	snap := courseSnapshot(course, now)
+	if snap.ID != "IF2110" || snap.Name != course.Name {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	want := []string{"tugas 1", "kuis 3", "batas jendela"}
+	if len(snap.Deadlines) != len(want) {
+		t.Fatalf("tenggat = %+v, ingin %v", snap.Deadlines, want)
+	}
+	for i, d := range snap.Deadlines {
+		if d.Title != want[i] {
+			t.Errorf("tenggat[%d] = %q, ingin %q", i, d.Title, want[i])
+		}
+	}
+
+	//hanya courseMaxDeadlines tenggat terdekat yang disalin
+	many := &model.Course{ID: "IF2120"}
+	for i := 8; i > 0; i-- {
+		many.Deadlines = append(many.Deadlines, model.CourseDeadline{Title: fmt.Sprintf("tugas %d", i), DueAt: now.Add(time.Duration(i) * day)})
+	}
+	snap = courseSnapshot(many, now)
+	if len(snap.Deadlines) != courseMaxDeadlines {
+		t.Fatalf("jumlah tenggat = %d, ingin %d", len(snap.Deadlines), courseMaxDeadlines)
+	}
+	if first, last := snap.Deadlines[0].Title, snap.Deadlines[courseMaxDeadlines-1].Title; first != "tugas 1" || last != "tugas 5" {
+		t.Errorf("tenggat = %q ... %q, ingin tugas 1 ... tugas 5", first, last)
+	}
+}
+
+func TestValidateCourseID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"IF2110", true},
+		{"MK-001_A", true},
+		{"", false},
+		{"IF 2110", false},
+		{"IF/2110", false},
+		{"__name__", false},
+		{"A234567890123456789012345678901234567890", true},
+		{"A2345678901234567890123456789012345678901", false},
+	}
+	for _, tt := range tests {
+		err := validateCourseID(tt.id)
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("validateCourseID(%q) = %v, ingin valid %v", tt.id, err, tt.valid)
+		}
+	}
+}
+
+func TestCSVRowGet(t *testing.T) {
+	row := csvRow{
+		line:    2,
+		record:  []string{" IF2110 ", "budi@student.kampus.ac.id"},
+		columns: map[string]int{"course_id": 0, "student_email": 1, "student_id": 2},
+	}
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{"course_id", "IF2110"},
+		{"student_email", "budi@student.kampus.ac.id"},
+		{"student_id", ""}, //baris lebih pendek dari header
+		{"lecturer", ""},   //kolom tidak ada di header
+	}
+	for _, tt := range tests {
+		if got := row.get(tt.column); got != tt.want {
+			t.Errorf("get(%q) = %q, ingin %q", tt.column, got, tt.want)
+		}
+	}
+}
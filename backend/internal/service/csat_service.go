@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/prompt"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrInvalidCSAT      = errors.New("survei kepuasan tidak valid")
+	ErrCSATNotRequested = errors.New("survei kepuasan belum tersedia, tiket belum diselesaikan")
+	ErrCSATSubmitted    = errors.New("survei kepuasan untuk tiket ini sudah diisi")
+)
+
+const csatMaxComment = 1000
+
+// CSATService meminta survei kepuasan saat tiket resolved, menyimpan jawaban mahasiswa
+// di percakapan, lalu menganalisis sentimen komentarnya di background.
+type CSATService struct {
+	firestore *firestore.Client
+	aiService *AIService
+	notifier  *EmailNotifier
+}
+
+func NewCSATService(f *firestore.Client, aiSvc *AIService, notifier *EmailNotifier) *CSATService {
+	return &CSATService{firestore: f, aiService: aiSvc, notifier: notifier}
+}
+
+// Request menandai survei diminta untuk tiket yang baru resolved dan mengirim emailnya.
+// agentID adalah agent yang menyelesaikan tiket, kosong jika ditutup otomatis. Tiket yang
+// surveinya sudah diisi tidak diminta ulang. Aman dipanggil dengan receiver nil.
+func (s *CSATService) Request(ctx context.Context, convID, agentID string) error {
+	if s == nil {
+		return nil
+	}
+
+	now := time.Now()
+	ref := s.firestore.Collection("conversations").Doc(convID)
+	requested := false
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		requested = false
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrConversationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var conv model.Conversation
+		if err := doc.DataTo(&conv); err != nil {
+			return err
+		}
+		if conv.CSAT != nil && conv.CSAT.SubmittedAt != nil {
+			return nil
+		}
+		requested = true
+		return tx.Update(ref, []firestore.Update{
+			{Path: "csat", Value: model.CSAT{RequestedAt: now, AgentID: agentID}},
+		})
+	})
+	if err != nil || !requested {
+		return err
+	}
+	return s.notifier.Notify(ctx, convID, model.EmailEvent{Type: model.EmailEventCSATRequest, At: now})
+}
+
+// Submit menyimpan rating 1-5 dan komentar. owns memeriksa kepemilikan tiket (mahasiswa
+// atau sesi widget), tiket milik orang lain dilaporkan sebagai tidak ditemukan.
+func (s *CSATService) Submit(ctx context.Context, convID string, owns func(*model.Conversation) bool, rating int, comment string) (*model.CSAT, error) {
+	comment = strings.TrimSpace(comment)
+	if rating < 1 || rating > 5 {
+		return nil, fmt.Errorf("%w: rating harus 1-5", ErrInvalidCSAT)
+	}
+	if utf8.RuneCountInString(comment) > csatMaxComment {
+		return nil, fmt.Errorf("%w: comment maksimal %d karakter", ErrInvalidCSAT, csatMaxComment)
+	}
+
+	ref := s.firestore.Collection("conversations").Doc(convID)
+	var csat model.CSAT
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrConversationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var conv model.Conversation
+		if err := doc.DataTo(&conv); err != nil {
+			return err
+		}
+		if !owns(&conv) {
+			return ErrConversationNotFound
+		}
+		if conv.CSAT == nil {
+			return ErrCSATNotRequested
+		}
+		if conv.CSAT.SubmittedAt != nil {
+			return ErrCSATSubmitted
+		}
+
+		now := time.Now()
+		csat = *conv.CSAT
+		csat.Rating, csat.Comment, csat.SubmittedAt = rating, comment, &now
+		return tx.Update(ref, []firestore.Update{
+			{Path: "csat.rating", Value: rating},
+			{Path: "csat.comment", Value: comment},
+			{Path: "csat.submitted_at", Value: now},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if comment != "" {
+		go s.analyzeInBackground(convID, rating, comment)
+	}
+	return &csat, nil
+}
+
+// request sudah selesai saat goroutine berjalan, jadi pakai context.Background()
+func (s *CSATService) analyzeInBackground(convID string, rating int, comment string) {
+	ctx := WithCallInfo(context.Background(), CallInfo{ConversationID: convID})
+	result, err := s.aiService.AnalyzeCSATComment(ctx, rating, comment)
+	if err != nil {
+		log.Printf("Gagal menganalisis komentar survei %s: %v", convID, err)
+		return
+	}
+	_, err = s.firestore.Collection("conversations").Doc(convID).Update(ctx, []firestore.Update{
+		{Path: "csat.sentiment", Value: result.Sentiment},
+		{Path: "csat.themes", Value: result.Themes},
+	})
+	if err != nil {
+		log.Printf("Gagal menyimpan sentimen survei %s: %v", convID, err)
+	}
+}
+
+// AnalyzeCSATComment menilai sentimen komentar survei kepuasan beserta topik yang disebut
+func (s *AIService) AnalyzeCSATComment(ctx context.Context, rating int, comment string) (*model.CSATSentiment, error) {
+	redaction := s.redactor.NewSession()
+	defer s.auditRedaction(ctx, "csat", redaction)
+
+	tmpl, err := s.prompts.Select(prompt.CSAT, abKey(ctx, comment))
+	if err != nil {
+		return nil, err
+	}
+	promptText, err := tmpl.Execute(prompt.CSATInput{Rating: rating, Comment: redaction.Redact(comment)})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.generate(ctx, "csat", ai.Request{Prompt: promptText, JSON: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.CSATSentiment
+	if err := json.Unmarshal([]byte(resp.Text), &result); err != nil {
+		return nil, fmt.Errorf("gagal Unmarshal JSON AI: %w", err)
+	}
+	result.Sentiment = strings.ToLower(result.Sentiment)
+	if !validSentiments[result.Sentiment] {
+		result.Sentiment = "netral"
+	}
+	if len(result.Themes) > 3 {
+		result.Themes = result.Themes[:3]
+	}
+	for i := range result.Themes {
+		result.Themes[i] = redaction.Restore(result.Themes[i])
+	}
+	if result.Themes == nil {
+		result.Themes = []string{}
+	}
+	return &result, nil
+}
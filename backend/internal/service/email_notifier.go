@@ -0,0 +1,443 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/mail"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:embed email_templates/*.tmpl
+var emailTemplateFS embed.FS
+
+var ErrInvalidPreferences = errors.New("pengaturan notifikasi tidak valid")
+
+// email yang gagal dikirim sebanyak ini dibuang dari outbox
+const maxOutboxAttempts = 5
+
+// bahasa template email yang tersedia, bahasa lain memakai DefaultLanguage
+var emailLanguages = []string{"id", "en"}
+
+type emailTemplates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// data yang tersedia di template email
+type emailTemplateData struct {
+	StudentName string
+	Token       string // penanda tiket di subjek, misal [#AbC123]
+	Replies     []model.EmailEvent
+	Status      string // status terbaru jika berubah, kosong jika tidak
+	Link        string
+	CSAT        bool   // ajakan mengisi survei kepuasan
+	SurveyLink  string // halaman tiket dengan form survei, kosong jika DashboardURL tidak diisi
+	CanReply    bool
+	ReplyMarker string
+}
+
+// EmailNotifier mengirim email ke mahasiswa saat agent membalas atau status tiket berubah.
+// Kejadian ditampung dulu di email_outbox per percakapan sehingga balasan beruntun
+// dikirim sebagai satu email.
+type EmailNotifier struct {
+	firestore *firestore.Client
+	sender    *mail.SMTPSender // nil jika relay SMTP belum dikonfigurasi
+	cfg       *config.EmailConfig
+	templates map[string]emailTemplates
+}
+
+func NewEmailNotifier(f *firestore.Client, cfg *config.EmailConfig) (*EmailNotifier, error) {
+	n := &EmailNotifier{firestore: f, cfg: cfg, templates: make(map[string]emailTemplates)}
+	if cfg.OutboundAddr != "" && cfg.FromAddress != "" {
+		n.sender = &mail.SMTPSender{Addr: cfg.OutboundAddr, Username: cfg.OutboundUser, Password: cfg.OutboundPassword}
+	}
+
+	for _, lang := range emailLanguages {
+		text, err := texttemplate.ParseFS(emailTemplateFS, "email_templates/"+lang+".txt.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("gagal memuat template email %s: %w", lang, err)
+		}
+		html, err := htmltemplate.ParseFS(emailTemplateFS, "email_templates/"+lang+".html.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("gagal memuat template email %s: %w", lang, err)
+		}
+		n.templates[lang] = emailTemplates{text: text, html: html}
+	}
+	return n, nil
+}
+
+// Enabled bernilai false jika relay SMTP keluar belum dikonfigurasi, notifikasi dilewati
+func (n *EmailNotifier) Enabled() bool {
+	return n.sender != nil
+}
+
+func defaultPreferences() *model.NotificationPreferences {
+	return &model.NotificationPreferences{Email: true, AgentReplies: true, StatusChanges: true}
+}
+
+// GetPreferences mengembalikan pengaturan mahasiswa, default semua notifikasi aktif
+func (n *EmailNotifier) GetPreferences(ctx context.Context, uid string) (*model.NotificationPreferences, error) {
+	doc, err := n.firestore.Collection("notification_preferences").Doc(uid).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return defaultPreferences(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefs := defaultPreferences()
+	if err := doc.DataTo(prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (n *EmailNotifier) SavePreferences(ctx context.Context, uid string, prefs model.NotificationPreferences) (*model.NotificationPreferences, error) {
+	if prefs.Language != "" && !isEmailLanguage(prefs.Language) {
+		return nil, fmt.Errorf("%w: bahasa %q tidak didukung", ErrInvalidPreferences, prefs.Language)
+	}
+	prefs.UpdatedAt = time.Now()
+	if _, err := n.firestore.Collection("notification_preferences").Doc(uid).Set(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// Notify memasukkan kejadian ke outbox percakapan. Setiap kejadian baru menunda pengiriman
+// selama BatchWindow, tetapi tidak lebih dari BatchMaxDelay sejak kejadian pertama.
+func (n *EmailNotifier) Notify(ctx context.Context, convID string, events ...model.EmailEvent) error {
+	if !n.Enabled() || len(events) == 0 {
+		return nil
+	}
+
+	conv, err := n.getConversation(ctx, convID)
+	if err != nil {
+		return err
+	}
+	to, err := n.recipient(ctx, conv)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		return nil
+	}
+	//tiket dari aplikasi chat: balasan agent sudah diteruskan langsung ke chat
+	if conv.Chat != nil {
+		events = withoutAgentReplies(events)
+	}
+	prefs := defaultPreferences()
+	if conv.StudentId != "" {
+		if prefs, err = n.GetPreferences(ctx, conv.StudentId); err != nil {
+			return err
+		}
+	}
+	if events = filterEvents(prefs, events); len(events) == 0 {
+		return nil
+	}
+
+	ref := n.firestore.Collection("email_outbox").Doc(convID)
+	return n.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := time.Now()
+		box := model.EmailOutbox{ConversationID: convID, FirstAt: now}
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(&box); err != nil {
+				return err
+			}
+		}
+
+		box.StudentID, box.To = conv.StudentId, to
+		box.Events = append(box.Events, events...)
+		box.SendAfter = now.Add(n.cfg.BatchWindow)
+		if deadline := box.FirstAt.Add(n.cfg.BatchMaxDelay); box.SendAfter.After(deadline) {
+			box.SendAfter = deadline
+		}
+		return tx.Set(ref, box)
+	})
+}
+
+// recipient memakai email yang tersimpan di tiket, atau email terverifikasi akun mahasiswa
+// untuk tiket yang dibuat lewat form web. Kosong jika keduanya tidak ada.
+func (n *EmailNotifier) recipient(ctx context.Context, conv *model.Conversation) (string, error) {
+	if conv.StudentEmail != "" || conv.StudentId == "" {
+		return conv.StudentEmail, nil
+	}
+	doc, err := n.firestore.Collection("users").Doc(conv.StudentId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	email, _ := doc.Data()["verified_email"].(string)
+	return email, nil
+}
+
+// RunOutbox mengirim email yang sudah melewati jeda batch secara berkala sampai ctx dibatalkan
+func (n *EmailNotifier) RunOutbox(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.processOutbox(ctx)
+		}
+	}
+}
+
+func (n *EmailNotifier) processOutbox(ctx context.Context) {
+	iter := n.firestore.Collection("email_outbox").Where("send_after", "<=", time.Now()).Limit(20).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return
+		}
+		if err != nil {
+			log.Printf("Gagal membaca outbox email: %v", err)
+			return
+		}
+
+		box, err := n.claim(ctx, doc.Ref)
+		if err != nil {
+			log.Printf("Gagal mengambil outbox email %s: %v", doc.Ref.ID, err)
+			continue
+		}
+		if box == nil {
+			continue
+		}
+		if err := n.send(ctx, *box); err != nil {
+			n.retry(ctx, *box, err)
+		}
+	}
+}
+
+// claim menghapus outbox dalam transaksi agar kejadian yang masuk sesudahnya
+// menjadi batch baru, nil jika batch masih menunggu kejadian lain
+func (n *EmailNotifier) claim(ctx context.Context, ref *firestore.DocumentRef) (*model.EmailOutbox, error) {
+	var box *model.EmailOutbox
+	err := n.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		box = nil
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var pending model.EmailOutbox
+		if err := doc.DataTo(&pending); err != nil {
+			return err
+		}
+		if pending.SendAfter.After(time.Now()) {
+			return nil
+		}
+		box = &pending
+		return tx.Delete(ref)
+	})
+	return box, err
+}
+
+// retry mengembalikan batch yang gagal ke outbox dengan backoff, digabung dengan
+// kejadian yang masuk selama pengiriman
+func (n *EmailNotifier) retry(ctx context.Context, box model.EmailOutbox, sendErr error) {
+	box.Attempts++
+	if box.Attempts >= maxOutboxAttempts {
+		log.Printf("Email notifikasi tiket %s gagal %d kali, dibuang: %v", box.ConversationID, box.Attempts, sendErr)
+		return
+	}
+
+	ref := n.firestore.Collection("email_outbox").Doc(box.ConversationID)
+	err := n.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		retry := box
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			var newer model.EmailOutbox
+			if err := doc.DataTo(&newer); err != nil {
+				return err
+			}
+			retry.Events = append(append([]model.EmailEvent{}, box.Events...), newer.Events...)
+		}
+		retry.LastError = sendErr.Error()
+		retry.SendAfter = time.Now().Add(time.Minute << uint(retry.Attempts))
+		return tx.Set(ref, retry)
+	})
+	if err != nil {
+		log.Printf("Gagal menjadwalkan ulang email notifikasi tiket %s: %v", box.ConversationID, err)
+	}
+}
+
+// send merender satu email untuk seluruh kejadian dalam batch. Message-ID-nya disimpan
+// di percakapan supaya balasan mahasiswa via email tersambung ke tiket yang sama.
+func (n *EmailNotifier) send(ctx context.Context, box model.EmailOutbox) error {
+	conv, err := n.getConversation(ctx, box.ConversationID)
+	if errors.Is(err, ErrConversationNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	prefs := defaultPreferences()
+	if box.StudentID != "" {
+		if prefs, err = n.GetPreferences(ctx, box.StudentID); err != nil {
+			return err
+		}
+	}
+	//mahasiswa bisa saja mematikan notifikasi selama batch menunggu
+	events := filterEvents(prefs, box.Events)
+	if len(events) == 0 {
+		return nil
+	}
+
+	data := emailTemplateData{
+		StudentName: conv.StudentName,
+		Token:       mail.SubjectToken(conv.ID),
+		CanReply:    n.cfg.ReplyTo != "",
+		ReplyMarker: mail.ReplyMarker,
+	}
+	for _, e := range events {
+		switch e.Type {
+		case model.EmailEventAgentReply:
+			data.Replies = append(data.Replies, e)
+		case model.EmailEventStatusChanged:
+			data.Status = e.Status
+		case model.EmailEventCSATRequest:
+			//tiket bisa saja dibuka kembali atau surveinya sudah diisi selama batch menunggu
+			data.CSAT = conv.Status == model.StatusResolved && (conv.CSAT == nil || conv.CSAT.SubmittedAt == nil)
+		}
+	}
+	if len(data.Replies) == 0 && data.Status == "" && !data.CSAT {
+		return nil
+	}
+	if n.cfg.DashboardURL != "" {
+		data.Link = n.cfg.DashboardURL + "/" + conv.ID
+		if data.CSAT {
+			data.SurveyLink = data.Link + "?csat=1"
+		}
+	}
+
+	lang := prefs.Language
+	if lang == "" {
+		lang = conv.Language
+	}
+	if !isEmailLanguage(lang) {
+		lang = DefaultLanguage
+	}
+	tmpl := n.templates[lang]
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return err
+	}
+
+	_, domain, _ := strings.Cut(n.cfg.FromAddress, "@")
+	msg := &mail.Outgoing{
+		From:      n.cfg.FromAddress,
+		FromName:  n.cfg.FromName,
+		To:        box.To,
+		ReplyTo:   n.cfg.ReplyTo,
+		Subject:   strings.TrimSpace(subject.String()),
+		MessageID: mail.NewMessageID(domain),
+		Text:      strings.TrimSpace(text.String()),
+		HTML:      html.String(),
+	}
+	if ids := conv.EmailMessageIDs; len(ids) > 0 {
+		msg.InReplyTo = ids[len(ids)-1]
+		if len(ids) > 10 {
+			ids = ids[len(ids)-10:]
+		}
+		msg.References = ids
+	}
+	if err := n.sender.Send(msg); err != nil {
+		return err
+	}
+
+	_, err = n.firestore.Collection("conversations").Doc(conv.ID).Update(ctx, []firestore.Update{
+		{Path: "email_message_ids", Value: firestore.ArrayUnion(msg.MessageID)},
+	})
+	if err != nil {
+		log.Printf("gagal mencatat Message-ID email keluar tiket %s: %v", conv.ID, err)
+	}
+	log.Printf("Email notifikasi tiket %s dikirim ke %s (%d kejadian)", conv.ID, box.To, len(events))
+	return nil
+}
+
+func (n *EmailNotifier) getConversation(ctx context.Context, convID string) (*model.Conversation, error) {
+	doc, err := n.firestore.Collection("conversations").Doc(convID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		return nil, err
+	}
+	conv.ID = doc.Ref.ID
+	return &conv, nil
+}
+
+func filterEvents(prefs *model.NotificationPreferences, events []model.EmailEvent) []model.EmailEvent {
+	if !prefs.Email {
+		return nil
+	}
+	var kept []model.EmailEvent
+	for _, e := range events {
+		//ajakan survei ikut pengaturan perubahan status karena dikirim bersama status resolved
+		if (e.Type == model.EmailEventAgentReply && prefs.AgentReplies) ||
+			((e.Type == model.EmailEventStatusChanged || e.Type == model.EmailEventCSATRequest) && prefs.StatusChanges) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+func withoutAgentReplies(events []model.EmailEvent) []model.EmailEvent {
+	var kept []model.EmailEvent
+	for _, e := range events {
+		if e.Type != model.EmailEventAgentReply {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+func isEmailLanguage(lang string) bool {
+	for _, l := range emailLanguages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
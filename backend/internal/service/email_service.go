@@ -0,0 +1,353 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/mail"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// jumlah email yang diproses per putaran polling IMAP
+const imapBatchSize = 50
+
+// EmailService menerima keluhan lewat email (polling IMAP atau penerima SMTP) dan
+// meneruskannya ke pipeline yang sama dengan form web
+type EmailService struct {
+	firestore  *firestore.Client
+	complaints *ComplaintService
+	cfg        *config.EmailConfig
+}
+
+func NewEmailService(f *firestore.Client, complaints *ComplaintService, cfg *config.EmailConfig) *EmailService {
+	return &EmailService{firestore: f, complaints: complaints, cfg: cfg}
+}
+
+// Ingest memproses satu email mentah. Email yang sengaja diabaikan (duplikat, auto-reply,
+// pengirim tidak dikenal) tetap dicatat dan tidak mengembalikan error; error hanya untuk
+// kegagalan sementara sehingga email bisa diproses ulang.
+func (s *EmailService) Ingest(ctx context.Context, source string, raw []byte) (*model.EmailLog, error) {
+	entry := &model.EmailLog{Source: source, Status: model.EmailProcessing, ReceivedAt: time.Now()}
+
+	msg, parseErr := mail.Parse(bytes.NewReader(raw))
+	if parseErr == nil {
+		entry.MessageID, entry.From, entry.Subject = msg.MessageID, msg.From, msg.Subject
+	}
+	ref := s.firestore.Collection("inbound_emails").Doc(emailLogID(entry.MessageID, raw))
+	entry.ID = ref.ID
+
+	if _, err := ref.Create(ctx, entry); err != nil {
+		if status.Code(err) != codes.AlreadyExists {
+			return nil, err
+		}
+		//sudah pernah diterima (misal lewat IMAP dan SMTP sekaligus)
+		return s.getLog(ctx, ref)
+	}
+
+	finish := func(status, convID, reason string) (*model.EmailLog, error) {
+		entry.Status, entry.ConversationID, entry.Reason = status, convID, reason
+		if _, err := ref.Set(ctx, entry); err != nil {
+			log.Printf("gagal mencatat email masuk %s: %v", ref.ID, err)
+		}
+		return entry, nil
+	}
+
+	switch {
+	case parseErr != nil:
+		return finish(model.EmailRejected, "", parseErr.Error())
+	case int64(len(raw)) > s.cfg.MaxSize:
+		return finish(model.EmailRejected, "", mail.ErrTooLarge.Error())
+	case msg.AutoReply:
+		return finish(model.EmailAutoReply, "", "")
+	}
+
+	//header From bisa dipalsukan, akun mahasiswa hanya dicocokkan jika MTA tepercaya
+	//menyatakan pengirimnya lolos DMARC/DKIM/SPF. Selain itu diperlakukan sebagai pengirim tak dikenal.
+	var student *studentRef
+	reason := "pengirim tidak terautentikasi"
+	if msg.SenderAuthenticated(s.cfg.TrustedAuthServIDs) {
+		var err error
+		if student, err = findStudentByEmail(ctx, s.firestore, msg.From); err != nil {
+			ref.Delete(ctx)
+			return nil, err
+		}
+		reason = "alamat tidak terdaftar sebagai mahasiswa"
+	}
+	if student == nil {
+		if !s.cfg.AcceptUnknownSenders {
+			return finish(model.EmailUnknownSender, "", reason)
+		}
+		student = &studentRef{Name: msg.FromName}
+	}
+
+	conv, err := s.findThread(ctx, msg, student)
+	if err != nil {
+		ref.Delete(ctx)
+		return nil, err
+	}
+
+	in := ComplaintInput{
+		StudentID:    student.ID,
+		StudentName:  student.Name,
+		StudentEmail: msg.From,
+		Text:         msg.Text,
+		Channel:      model.ChannelEmail,
+	}
+	if msg.MessageID != "" {
+		in.EmailMessageIDs = []string{msg.MessageID}
+	}
+	for _, att := range msg.Attachments {
+		in.Files = append(in.Files, BytesUpload(att.FileName, att.Data))
+	}
+	//keluhan baru: subjek biasanya berisi inti masalah
+	if conv == nil && msg.Subject != "" {
+		in.Text = strings.TrimSpace(msg.Subject + "\n\n" + in.Text)
+	}
+	if in.Text == "" {
+		if len(in.Files) == 0 {
+			return finish(model.EmailRejected, "", "email tidak berisi teks maupun lampiran")
+		}
+		in.Text = "(lampiran tanpa teks)"
+	}
+
+	convID, err := s.deliver(ctx, conv, in)
+	if errors.Is(err, ErrInvalidAttachment) {
+		//lampiran ditolak: teks tetap diproses supaya keluhan tidak hilang
+		in.Text += fmt.Sprintf("\n\n[Lampiran email tidak disimpan: %v]", err)
+		in.Files = nil
+		convID, err = s.deliver(ctx, conv, in)
+	}
+	if err != nil {
+		ref.Delete(ctx)
+		return nil, err
+	}
+
+	if conv != nil {
+		return finish(model.EmailReplied, convID, "")
+	}
+	return finish(model.EmailCreated, convID, "")
+}
+
+func (s *EmailService) deliver(ctx context.Context, conv *model.Conversation, in ComplaintInput) (string, error) {
+	if conv != nil {
+		_, err := s.complaints.Reply(ctx, conv.ID, in)
+		return conv.ID, err
+	}
+	created, err := s.complaints.Create(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+type studentRef struct {
+	ID   string
+	Name string
+}
+
+// findStudentByEmail mencocokkan alamat email dengan users (role customer). Hanya
+// verified_email yang dipakai: field email diisi sendiri oleh user saat registrasi
+// sehingga bisa berisi alamat milik orang lain.
+func findStudentByEmail(ctx context.Context, f *firestore.Client, address string) (*studentRef, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if address == "" {
+		return nil, nil
+	}
+	iter := f.Collection("users").Where("verified_email", "==", address).Limit(5).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data := doc.Data()
+		if role, _ := data["role"].(string); role != "customer" {
+			continue
+		}
+		name, _ := data["name"].(string)
+		return &studentRef{ID: doc.Ref.ID, Name: name}, nil
+	}
+}
+
+// findThread mencari percakapan yang dibalas: token [#id] di subjek lebih dulu,
+// lalu In-Reply-To/References. Percakapan hanya dipakai jika milik pengirim yang sama.
+func (s *EmailService) findThread(ctx context.Context, msg *mail.Message, student *studentRef) (*model.Conversation, error) {
+	owns := func(conv model.Conversation) bool {
+		if student.ID != "" {
+			return conv.StudentId == student.ID
+		}
+		return conv.StudentId == "" && strings.EqualFold(conv.StudentEmail, msg.From)
+	}
+
+	if id := mail.FindSubjectToken(msg.Subject); id != "" {
+		doc, err := s.firestore.Collection("conversations").Doc(id).Get(ctx)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		if err == nil {
+			var conv model.Conversation
+			if err := doc.DataTo(&conv); err == nil && owns(conv) {
+				conv.ID = doc.Ref.ID
+				return &conv, nil
+			}
+		}
+	}
+
+	ids := msg.ThreadIDs()
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if len(ids) > 30 {
+		ids = ids[:30] // batas array-contains-any firestore
+	}
+	iter := s.firestore.Collection("conversations").Where("email_message_ids", "array-contains-any", ids).Limit(10).Documents(ctx)
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var conv model.Conversation
+		if err := doc.DataTo(&conv); err == nil && owns(conv) {
+			conv.ID = doc.Ref.ID
+			return &conv, nil
+		}
+	}
+}
+
+func (s *EmailService) getLog(ctx context.Context, ref *firestore.DocumentRef) (*model.EmailLog, error) {
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var entry model.EmailLog
+	if err := doc.DataTo(&entry); err != nil {
+		return nil, err
+	}
+	entry.ID = doc.Ref.ID
+	return &entry, nil
+}
+
+// ListLogs mengembalikan email masuk terbaru, opsional difilter status (misal unknown_sender)
+func (s *EmailService) ListLogs(ctx context.Context, statusFilter string, limit int) ([]model.EmailLog, error) {
+	query := s.firestore.Collection("inbound_emails").OrderBy("received_at", firestore.Desc).Limit(limit)
+	if statusFilter != "" {
+		query = s.firestore.Collection("inbound_emails").Where("status", "==", statusFilter).OrderBy("received_at", firestore.Desc).Limit(limit)
+	}
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	logs := []model.EmailLog{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return logs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var entry model.EmailLog
+		if err := doc.DataTo(&entry); err != nil {
+			continue
+		}
+		entry.ID = doc.Ref.ID
+		logs = append(logs, entry)
+	}
+}
+
+// Deliver dipakai sebagai handler mail.SMTPServer
+func (s *EmailService) Deliver(ctx context.Context, from string, to []string, data []byte) error {
+	entry, err := s.Ingest(ctx, "smtp", data)
+	if err == nil {
+		log.Printf("Email masuk dari %s via SMTP: %s", entry.From, entry.Status)
+	}
+	return err
+}
+
+// RunIMAPPoller mengambil email belum dibaca secara berkala sampai ctx dibatalkan
+func (s *EmailService) RunIMAPPoller(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.PollIMAP(ctx); err != nil {
+			log.Printf("Gagal polling IMAP: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PollIMAP memproses satu batch email belum dibaca. Email baru ditandai \Seen setelah
+// berhasil diproses, yang gagal sementara akan dicoba lagi di putaran berikutnya.
+func (s *EmailService) PollIMAP(ctx context.Context) error {
+	client, err := mail.DialIMAP(s.cfg.IMAPAddr, s.cfg.IMAPTLS, time.Minute)
+	if err != nil {
+		return err
+	}
+	defer client.Logout()
+
+	if err := client.Login(s.cfg.IMAPUser, s.cfg.IMAPPassword); err != nil {
+		return err
+	}
+	if err := client.Select(s.cfg.IMAPMailbox); err != nil {
+		return err
+	}
+	uids, err := client.SearchUnseen()
+	if err != nil {
+		return err
+	}
+	if len(uids) > imapBatchSize {
+		uids = uids[:imapBatchSize]
+	}
+
+	for _, uid := range uids {
+		raw, err := client.Fetch(uid)
+		if err != nil {
+			return err
+		}
+		entry, err := s.Ingest(ctx, "imap", raw)
+		if err != nil {
+			log.Printf("Gagal memproses email IMAP %d: %v", uid, err)
+			continue
+		}
+		log.Printf("Email masuk dari %s via IMAP: %s", entry.From, entry.Status)
+		if err := client.MarkSeen(uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ID log = hash Message-ID, atau hash isi email jika Message-ID kosong
+func emailLogID(messageID string, raw []byte) string {
+	var sum [32]byte
+	if messageID != "" {
+		sum = sha256.Sum256([]byte(strings.ToLower(messageID)))
+	} else {
+		sum = sha256.Sum256(raw)
+	}
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrInvalidOverride  = errors.New("koreksi analisis tidak valid")
+	ErrAnalysisNotReady = errors.New("percakapan belum memiliki analisis AI")
+)
+
+var validSentiments = map[string]bool{"positif": true, "negatif": true, "netral": true}
+
+// OverrideInput berisi koreksi agent, field nil berarti nilai tidak diubah
+type OverrideInput struct {
+	Category      *string
+	PriorityScore *int
+	Sentiment     *string
+	Reason        string
+}
+
+// FeedbackService menyimpan koreksi agent atas analysis AI sebagai dataset berlabel
+type FeedbackService struct {
+	firestore *firestore.Client
+}
+
+func NewFeedbackService(f *firestore.Client) *FeedbackService {
+	return &FeedbackService{firestore: f}
+}
+
+// Override menerapkan koreksi agent ke ai_analysis percakapan. Nilai asli dari AI disimpan
+// di human_override.ai dan setiap koreksi dicatat di analysis_corrections.
+func (s *FeedbackService) Override(ctx context.Context, convID, agentID string, in OverrideInput) (*model.AnalysisOverride, error) {
+	if strings.TrimSpace(in.Reason) == "" {
+		return nil, ErrInvalidOverride
+	}
+	if in.PriorityScore != nil && (*in.PriorityScore < 1 || *in.PriorityScore > 10) {
+		return nil, ErrInvalidOverride
+	}
+	if in.Sentiment != nil && !validSentiments[strings.ToLower(*in.Sentiment)] {
+		return nil, ErrInvalidOverride
+	}
+	if in.Category != nil && strings.TrimSpace(*in.Category) == "" {
+		return nil, ErrInvalidOverride
+	}
+
+	ref := s.firestore.Collection("conversations").Doc(convID)
+	var override model.AnalysisOverride
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return ErrConversationNotFound
+		}
+		var conv model.Conversation
+		if err := snap.DataTo(&conv); err != nil {
+			return err
+		}
+		if !conv.AIAnalysis.IsProcessed {
+			return ErrAnalysisNotReady
+		}
+
+		//ai_analysis yang sudah dikoreksi berisi label agent, nilai AI asli dari analisis
+		//terakhir diambil dari riwayat analyses (tetap terkini setelah analisis ulang)
+		aiLabels := conv.AIAnalysis
+		if conv.AIAnalysis.Overridden {
+			record, err := tx.Get(ref.Collection("analyses").Doc(strconv.Itoa(conv.AIAnalysis.Version)))
+			if err != nil && status.Code(err) != codes.NotFound {
+				return err
+			}
+			var rec model.AnalysisRecord
+			if err == nil && record.DataTo(&rec) == nil {
+				aiLabels = rec.Analysis
+			}
+		}
+		override = model.AnalysisOverride{
+			AI: model.AnalysisLabels{
+				Category:      aiLabels.Category,
+				PriorityScore: aiLabels.PriorityScore,
+				Sentiment:     aiLabels.Sentiment,
+			},
+			Reason:    in.Reason,
+			AgentID:   agentID,
+			CreatedAt: time.Now(),
+		}
+		override.Human = model.AnalysisLabels{
+			Category:      conv.AIAnalysis.Category,
+			PriorityScore: conv.AIAnalysis.PriorityScore,
+			Sentiment:     conv.AIAnalysis.Sentiment,
+		}
+		if in.Category != nil {
+			override.Human.Category = strings.TrimSpace(*in.Category)
+		}
+		if in.PriorityScore != nil {
+			override.Human.PriorityScore = *in.PriorityScore
+		}
+		if in.Sentiment != nil {
+			override.Human.Sentiment = strings.ToLower(*in.Sentiment)
+		}
+
+		updates := []firestore.Update{
+			{Path: "human_override", Value: override},
+			{Path: "ai_analysis.category", Value: override.Human.Category},
+			{Path: "ai_analysis.sentiment", Value: override.Human.Sentiment},
+			{Path: "ai_analysis.overridden", Value: true},
+			{Path: "updated_at", Value: time.Now()},
+		}
+		//sama dengan applyOverride: tiket krisis tetap prioritas tertinggi walau dikoreksi
+		if conv.AIAnalysis.Moderation != ModerationCrisis && conv.Flag != ModerationCrisis {
+			updates = append(updates, firestore.Update{Path: "ai_analysis.priority_score", Value: override.Human.PriorityScore})
+		}
+		if err := tx.Update(ref, updates); err != nil {
+			return err
+		}
+
+		text := ""
+		for _, m := range conv.Messages {
+			if m.Sender == "student" {
+				text = m.Text
+				break
+			}
+		}
+		return tx.Create(s.firestore.Collection("analysis_corrections").NewDoc(), model.AnalysisCorrection{
+			ConversationID:   convID,
+			Text:             text,
+			Model:            conv.AIAnalysis.Model,
+			PromptVersion:    conv.AIAnalysis.PromptVersion,
+			AnalysisOverride: override,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// ListCorrections mengambil koreksi terbaru, satu per percakapan (koreksi terakhir yang dipakai).
+// limit <= 0 berarti semua koreksi.
+func (s *FeedbackService) ListCorrections(ctx context.Context, limit int) ([]model.AnalysisCorrection, error) {
+	return LatestCorrections(ctx, s.firestore, limit)
+}
+
+// LatestCorrections dipakai juga oleh AIService untuk contoh few-shot di prompt analisis
+func LatestCorrections(ctx context.Context, f *firestore.Client, limit int) ([]model.AnalysisCorrection, error) {
+	iter := f.Collection("analysis_corrections").OrderBy("created_at", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+
+	seen := make(map[string]bool)
+	corrections := []model.AnalysisCorrection{}
+	for limit <= 0 || len(corrections) < limit {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var c model.AnalysisCorrection
+		if err := doc.DataTo(&c); err != nil || c.Text == "" || seen[c.ConversationID] {
+			continue
+		}
+		seen[c.ConversationID] = true
+		corrections = append(corrections, c)
+	}
+	return corrections, nil
+}
+
+// applyOverride menimpa label hasil AI dengan koreksi agent yang masih berlaku
+func applyOverride(analysis *model.AIAnalysis, override *model.AnalysisOverride) {
+	if override == nil {
+		return
+	}
+	analysis.Category = override.Human.Category
+	//tiket krisis tetap prioritas tertinggi walau sudah dikoreksi
+	if analysis.Moderation != ModerationCrisis {
+		analysis.PriorityScore = override.Human.PriorityScore
+	}
+	analysis.Sentiment = override.Human.Sentiment
+	analysis.Overridden = true
+}
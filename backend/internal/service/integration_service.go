@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrIntegrationRequest  = errors.New("permintaan integrasi tidak valid")
+	ErrStudentNotFound     = errors.New("mahasiswa tidak ditemukan")
+	ErrIdempotencyConflict = errors.New("permintaan dengan Idempotency-Key yang sama masih diproses")
+)
+
+// IntegrationInput adalah tiket yang dibuka sistem luar atas nama mahasiswa
+type IntegrationInput struct {
+	StudentID      string
+	StudentEmail   string
+	Text           string
+	Context        model.TicketContext
+	IdempotencyKey string // opsional, request ulang dengan key yang sama mengembalikan tiket yang sama
+}
+
+// IntegrationService membuka dan membaca tiket untuk sistem luar yang memakai API key
+type IntegrationService struct {
+	firestore  *firestore.Client
+	complaints *ComplaintService
+	courses    *CourseService
+}
+
+func NewIntegrationService(f *firestore.Client, complaints *ComplaintService, courses *CourseService) *IntegrationService {
+	return &IntegrationService{firestore: f, complaints: complaints, courses: courses}
+}
+
+// CreateConversation membuat tiket lewat pipeline yang sama dengan form web.
+// created bernilai false jika tiket sudah pernah dibuat dengan Idempotency-Key yang sama.
+func (s *IntegrationService) CreateConversation(ctx context.Context, key *model.APIKey, in IntegrationInput) (conv *model.Conversation, created bool, err error) {
+	if strings.TrimSpace(in.Text) == "" {
+		return nil, false, fmt.Errorf("%w: text wajib diisi", ErrIntegrationRequest)
+	}
+	student, email, err := s.findStudent(ctx, in.StudentID, in.StudentEmail)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var idemRef *firestore.DocumentRef
+	if in.IdempotencyKey != "" {
+		sum := sha256.Sum256([]byte(key.ID + ":" + in.IdempotencyKey))
+		idemRef = s.firestore.Collection("integration_requests").Doc(hex.EncodeToString(sum[:16]))
+		_, err := idemRef.Create(ctx, map[string]interface{}{"api_key_id": key.ID, "created_at": time.Now()})
+		if status.Code(err) == codes.AlreadyExists {
+			return s.existing(ctx, idemRef)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	tc := in.Context
+	tc.Source, tc.APIKeyID = key.Name, key.ID
+	//LMS adalah sumber data pendaftaran, jadi course_id tidak dicek ke enrollments.
+	//Mata kuliah yang belum diimport tetap diterima, hanya tanpa snapshot tenggat.
+	var course *model.CourseSnapshot
+	if tc.CourseID != "" {
+		course, err = s.courses.Snapshot(ctx, tc.CourseID)
+		if err != nil && !errors.Is(err, ErrCourseNotFound) {
+			return nil, false, err
+		}
+	}
+	conv, err = s.complaints.Create(ctx, ComplaintInput{
+		StudentID:    student.ID,
+		StudentName:  student.Name,
+		StudentEmail: email,
+		Text:         in.Text,
+		Channel:      model.ChannelAPI,
+		Context:      &tc,
+		Course:       course,
+	})
+	if err != nil {
+		if idemRef != nil {
+			idemRef.Delete(ctx) //boleh dicoba ulang dengan key yang sama
+		}
+		return nil, false, err
+	}
+	if idemRef != nil {
+		if _, err := idemRef.Update(ctx, []firestore.Update{{Path: "conversation_id", Value: conv.ID}}); err != nil {
+			return nil, false, err
+		}
+	}
+	return conv, true, nil
+}
+
+// GetConversation hanya mengembalikan tiket yang dibuat oleh API key yang sama
+func (s *IntegrationService) GetConversation(ctx context.Context, key *model.APIKey, convID string) (*model.Conversation, error) {
+	doc, err := s.firestore.Collection("conversations").Doc(convID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		return nil, err
+	}
+	if conv.Context == nil || conv.Context.APIKeyID != key.ID {
+		return nil, ErrConversationNotFound
+	}
+	conv.ID = doc.Ref.ID
+	return &conv, nil
+}
+
+func (s *IntegrationService) existing(ctx context.Context, ref *firestore.DocumentRef) (*model.Conversation, bool, error) {
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	convID, _ := doc.Data()["conversation_id"].(string)
+	if convID == "" {
+		return nil, false, ErrIdempotencyConflict
+	}
+
+	convDoc, err := s.firestore.Collection("conversations").Doc(convID).Get(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var conv model.Conversation
+	if err := convDoc.DataTo(&conv); err != nil {
+		return nil, false, err
+	}
+	conv.ID = convDoc.Ref.ID
+	return &conv, false, nil
+}
+
+// findStudent mencari mahasiswa berdasarkan student_id (UID) atau email
+func (s *IntegrationService) findStudent(ctx context.Context, studentID, email string) (*studentRef, string, error) {
+	if studentID == "" {
+		if email == "" {
+			return nil, "", fmt.Errorf("%w: student_id atau student_email wajib diisi", ErrIntegrationRequest)
+		}
+		email = strings.ToLower(strings.TrimSpace(email))
+		student, err := findStudentByEmail(ctx, s.firestore, email)
+		if err != nil {
+			return nil, "", err
+		}
+		if student == nil {
+			return nil, "", ErrStudentNotFound
+		}
+		return student, email, nil
+	}
+
+	doc, err := s.firestore.Collection("users").Doc(studentID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, "", ErrStudentNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	data := doc.Data()
+	if role, _ := data["role"].(string); role != "customer" {
+		return nil, "", ErrStudentNotFound
+	}
+	name, _ := data["name"].(string)
+	//hanya email terverifikasi yang boleh menerima notifikasi tiket
+	address, _ := data["verified_email"].(string)
+	return &studentRef{ID: doc.Ref.ID, Name: name}, address, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+// bahasa default institusi, dipakai saat teks terlalu pendek untuk dideteksi
+const DefaultLanguage = "id"
+
+var languageNames = map[string]string{
+	"id": "Indonesian",
+	"en": "English",
+	"jv": "Javanese",
+	"su": "Sundanese",
+}
+
+// kata-kata umum per bahasa untuk deteksi berbasis stopword
+var languageStopwords = map[string][]string{
+	"id": {"saya", "tidak", "yang", "dan", "ini", "itu", "bisa", "sudah", "belum", "tolong", "mohon", "kenapa", "bagaimana", "apa", "kami", "dengan", "untuk", "ada", "pak", "bu", "kak", "terima", "kasih", "masih", "tapi", "jadi", "gimana", "gak", "nggak"},
+	"en": {"the", "is", "are", "i", "my", "you", "not", "can", "please", "why", "how", "what", "with", "for", "have", "this", "that", "was", "it", "and", "thanks", "thank", "still", "but", "cannot", "can't", "don't"},
+	"jv": {"aku", "kulo", "kula", "ora", "mboten", "sampun", "nggih", "piye", "kok", "wis", "durung", "opo", "sing", "karo", "njaluk", "tulung", "nopo", "panjenengan", "matur", "nuwun", "niki", "menika", "dereng"},
+	"su": {"abdi", "teu", "henteu", "tos", "parantos", "kumaha", "naon", "punten", "hatur", "nuhun", "atuh", "mah", "sareng", "acan", "teh", "ieu", "eta", "kuring", "nyuhunkeun", "tiasa"},
+}
+
+var stopwordIndex = func() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range languageStopwords {
+		for _, w := range words {
+			index[w] = append(index[w], lang)
+		}
+	}
+	return index
+}()
+
+// DetectLanguage menebak bahasa teks (kode ISO 639-1) dari frekuensi stopword.
+// Jika tidak ada sinyal yang jelas, dianggap bahasa Indonesia.
+func DetectLanguage(text string) string {
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, w := range words {
+		for _, lang := range stopwordIndex[w] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore := DefaultLanguage, scores[DefaultLanguage]
+	for _, lang := range []string{"en", "jv", "su"} {
+		if scores[lang] > bestScore {
+			best, bestScore = lang, scores[lang]
+		}
+	}
+	return best
+}
+
+// LanguageName mengembalikan nama bahasa dalam bahasa Inggris untuk dipakai di prompt
+func LanguageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return languageNames[DefaultLanguage]
+}
+
+func IsSupportedLanguage(code string) bool {
+	_, ok := languageNames[code]
+	return ok
+}
@@ -0,0 +1,47 @@
+package service
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"indonesia", "Saya tidak bisa login ke portal, tolong dibantu", "id"},
+		{"indonesia santai", "kak gimana ini udah bayar tapi gak masuk", "id"},
+		{"inggris", "I cannot access my account, please help", "en"},
+		{"inggris dengan apostrof", "Why can't I see the schedule? It still doesn't work", "en"},
+		{"jawa", "Nyuwun tulung, kulo dereng saged mlebet, matur nuwun", "jv"},
+		{"sunda", "Punten, abdi teu tiasa login, kumaha atuh", "su"},
+		{"campuran dominan indonesia", "Saya sudah coba reset password tapi tidak bisa, thanks", "id"},
+		{"campuran dominan inggris", "Pak, I have paid the fee but my status is still unpaid", "en"},
+		{"seri memilih bahasa default", "thanks kak", "id"},
+		{"kosong", "", DefaultLanguage},
+		{"hanya angka dan tanda baca", "123 ??? !!!", DefaultLanguage},
+		{"satu kata tanpa stopword", "KRS", DefaultLanguage},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("%s: DetectLanguage(%q) = %q, ingin %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageName(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"id", "Indonesian"},
+		{"en", "English"},
+		{"jv", "Javanese"},
+		{"fr", "Indonesian"}, // tidak didukung, kembali ke bahasa default
+		{"", "Indonesian"},
+	}
+	for _, tt := range tests {
+		if got := LanguageName(tt.code); got != tt.want {
+			t.Errorf("LanguageName(%q) = %q, ingin %q", tt.code, got, tt.want)
+		}
+	}
+}
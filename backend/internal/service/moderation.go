@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+)
+
+// tingkat moderasi, urut dari yang paling ringan
+const (
+	ModerationNone    = "none"
+	ModerationAbusive = "abusive"
+	ModerationCrisis  = "crisis"
+)
+
+var moderationRank = map[string]int{ModerationNone: 0, ModerationAbusive: 1, ModerationCrisis: 2}
+
+// frasa pemicu untuk screening cepat tanpa menunggu LLM
+var crisisPhrases = []string{
+	"bunuh diri", "ingin mati", "pengen mati", "pengin mati", "mau mati aja", "mending mati",
+	"mengakhiri hidup", "akhiri hidup", "menyakiti diri", "melukai diri", "gak kuat hidup", "tidak ingin hidup",
+	"suicide", "kill myself", "end my life", "self harm", "self-harm", "hurt myself",
+}
+
+// "anjing" dan "babi" hanya dihitung sebagai umpatan dalam frasa, karena kata itu sendiri
+// juga dipakai untuk hewan
+var abusivePhrases = []string{
+	"dasar anjing", "anjing lu", "anjing lo", "anjing kau", "bangsat", "goblok", "tolol", "bajingan", "brengsek",
+	"kontol", "memek", "ngentot", "babi lu", "dasar babi",
+	"fuck", "bitch", "asshole",
+}
+
+var (
+	crisisRe  = phraseRegexp(crisisPhrases)
+	abusiveRe = phraseRegexp(abusivePhrases)
+)
+
+// phraseRegexp mencocokkan frasa utuh di batas kata, agar "ingin matikan notifikasi"
+// tidak terbaca sebagai "ingin mati"
+func phraseRegexp(phrases []string) *regexp.Regexp {
+	parts := make([]string, len(phrases))
+	for i, p := range phrases {
+		parts[i] = strings.ReplaceAll(regexp.QuoteMeta(p), " ", `\s+`)
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(parts, "|") + `)\b`)
+}
+
+// ScreenText melakukan klasifikasi cepat berbasis kata kunci
+func ScreenText(text string) string {
+	lower := strings.ToLower(text)
+	if crisisRe.MatchString(lower) {
+		return ModerationCrisis
+	}
+	if abusiveRe.MatchString(lower) {
+		return ModerationAbusive
+	}
+	return ModerationNone
+}
+
+// MaxModeration mengembalikan tingkat yang lebih berat dari dua hasil klasifikasi.
+// Nilai dari LLM bisa berbeda kapitalisasi atau berspasi, jadi dinormalkan dulu.
+func MaxModeration(a, b string) string {
+	a = strings.ToLower(strings.TrimSpace(a))
+	b = strings.ToLower(strings.TrimSpace(b))
+	if moderationRank[b] > moderationRank[a] {
+		return b
+	}
+	if _, ok := moderationRank[a]; !ok {
+		return ModerationNone
+	}
+	return a
+}
+
+// SafeResponseSuggestions adalah template balasan yang sudah disetujui untuk tiket krisis.
+// Dipakai sebagai pengganti draf LLM agar agent tidak mengirim balasan yang tidak tepat.
+func SafeResponseSuggestions(language string) []model.Suggestion {
+	if language == "en" {
+		return []model.Suggestion{
+			{Tone: "Safe Response", Content: "Thank you for telling us. We are concerned about your safety and a member of our student support team will contact you shortly. If you are in immediate danger, please call 112, or reach the SEJIWA mental health service at 119 ext. 8, available 24 hours."},
+			{Tone: "Safe Response (Follow-up)", Content: "You are not alone, and what you are going through matters to us. Could you share a phone number where our counselor can reach you today? If you feel unsafe right now, please contact 112 or 119 ext. 8."},
+		}
+	}
+	return []model.Suggestion{
+		{Tone: "Safe Response", Content: "Terima kasih sudah bercerita kepada kami. Kami peduli dengan keselamatan Anda dan tim layanan mahasiswa akan segera menghubungi Anda. Jika Anda dalam bahaya, segera hubungi 112 atau layanan kesehatan jiwa SEJIWA di 119 ext. 8 yang tersedia 24 jam."},
+		{Tone: "Safe Response (Follow-up)", Content: "Anda tidak sendirian, dan apa yang Anda alami penting bagi kami. Boleh kami minta nomor telepon yang bisa dihubungi konselor kami hari ini? Jika saat ini Anda merasa tidak aman, silakan hubungi 112 atau 119 ext. 8."},
+	}
+}
+
+type ModerationService struct {
+	firestore     *firestore.Client
+	notifications *NotificationService
+}
+
+func NewModerationService(f *firestore.Client, n *NotificationService) *ModerationService {
+	return &ModerationService{firestore: f, notifications: n}
+}
+
+// Escalate menandai percakapan sesuai tingkat moderasi. Tanda hanya bisa naik (abusive -> crisis),
+// dan support lead dinotifikasi sekali saat percakapan pertama kali ditandai krisis.
+func (s *ModerationService) Escalate(ctx context.Context, convID string, level string, reason string) error {
+	if level == "" || level == ModerationNone {
+		return nil
+	}
+
+	ref := s.firestore.Collection("conversations").Doc(convID)
+	var newlyCrisis bool
+	var conv model.Conversation
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		newlyCrisis = false
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		if err := snap.DataTo(&conv); err != nil {
+			return err
+		}
+
+		current := conv.Flag
+		if current == "" {
+			current = ModerationNone
+		}
+		if MaxModeration(current, level) == current {
+			return nil
+		}
+
+		newlyCrisis = level == ModerationCrisis
+		updates := []firestore.Update{
+			{Path: "flag", Value: level},
+			{Path: "flag_reason", Value: reason},
+		}
+		if newlyCrisis {
+			updates = append(updates, firestore.Update{Path: "ai_analysis.priority_score", Value: 10})
+		}
+		return tx.Update(ref, updates)
+	})
+	if err != nil {
+		return err
+	}
+
+	if newlyCrisis {
+		name := conv.StudentName
+		if name == "" {
+			name = "Mahasiswa"
+		}
+		return s.notifications.NotifyRole(ctx, "support_lead", model.Notification{
+			Type:           ModerationCrisis,
+			Title:          "Tiket krisis membutuhkan perhatian segera",
+			Body:           name + ": " + reason,
+			ConversationID: convID,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import "testing"
+
+func TestScreenText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Saya ingin mati saja rasanya", ModerationCrisis},
+		{"aku pengen   mati", ModerationCrisis},
+		{"I want to KILL MYSELF", ModerationCrisis},
+		{"sering self-harm akhir-akhir ini", ModerationCrisis},
+		{"Bagaimana cara ingin matikan notifikasi email?", ModerationNone},
+		{"pengen matiin alarm di aplikasi", ModerationNone},
+		{"anjing saya sakit jadi izin tidak masuk", ModerationNone},
+		{"dasar anjing, admin lambat!", ModerationAbusive},
+		{"kalian semua goblok", ModerationAbusive},
+		{"nilai saya belum keluar", ModerationNone},
+	}
+	for _, tt := range tests {
+		if got := ScreenText(tt.text); got != tt.want {
+			t.Errorf("ScreenText(%q) = %q, ingin %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMaxModeration(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{ModerationNone, ModerationCrisis, ModerationCrisis},
+		{"Crisis", ModerationNone, ModerationCrisis},
+		{ModerationNone, " ABUSIVE ", ModerationAbusive},
+		{ModerationCrisis, ModerationAbusive, ModerationCrisis},
+		{"", ModerationNone, ModerationNone},
+		{"lainnya", "", ModerationNone},
+	}
+	for _, tt := range tests {
+		if got := MaxModeration(tt.a, tt.b); got != tt.want {
+			t.Errorf("MaxModeration(%q, %q) = %q, ingin %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+)
+
+var ErrNotificationNotFound = errors.New("notifikasi tidak ditemukan")
+
+type NotificationService struct {
+	firestore *firestore.Client
+}
+
+func NewNotificationService(f *firestore.Client) *NotificationService {
+	return &NotificationService{firestore: f}
+}
+
+// NotifyRole mengirim notifikasi in-app ke semua user dengan role tertentu
+func (s *NotificationService) NotifyRole(ctx context.Context, role string, n model.Notification) error {
+	iter := s.firestore.Collection("users").Where("role", "==", role).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		n.UserID = doc.Ref.ID
+		n.CreatedAt = time.Now()
+		if _, _, err := s.firestore.Collection("notifications").Add(ctx, n); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Notifikasi %s dikirim ke role %s untuk percakapan %s", n.Type, role, n.ConversationID)
+	return nil
+}
+
+func (s *NotificationService) ListForUser(ctx context.Context, uid string) ([]model.Notification, error) {
+	iter := s.firestore.Collection("notifications").
+		Where("user_id", "==", uid).
+		OrderBy("created_at", firestore.Desc).
+		Limit(50).
+		Documents(ctx)
+	defer iter.Stop()
+
+	notifications := []model.Notification{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var n model.Notification
+		if err := doc.DataTo(&n); err != nil {
+			continue
+		}
+		n.ID = doc.Ref.ID
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, uid string, id string) error {
+	ref := s.firestore.Collection("notifications").Doc(id)
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return ErrNotificationNotFound
+	}
+	if owner, _ := doc.Data()["user_id"].(string); owner != uid {
+		return ErrNotificationNotFound
+	}
+
+	_, err = ref.Update(ctx, []firestore.Update{{Path: "read", Value: true}})
+	return err
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/pii"
+)
+
+// auditRedaction mencatat jenis dan jumlah PII yang diredaksi per panggilan LLM.
+// Nilai aslinya tidak pernah disimpan.
+func (s *AIService) auditRedaction(ctx context.Context, feature string, session *pii.Session) {
+	counts := session.Counts()
+	if len(counts) == 0 || s.firestore == nil {
+		return
+	}
+
+	info := callInfoFrom(ctx)
+	_, _, err := s.firestore.Collection("pii_audit").Add(ctx, map[string]interface{}{
+		"feature":         feature,
+		"conversation_id": info.ConversationID,
+		"user_id":         info.UserID,
+		"counts":          counts,
+		"created_at":      time.Now(),
+	})
+	if err != nil {
+		log.Printf("Gagal menyimpan audit PII: %v", err)
+	}
+}
+
+// auditImages mencatat jumlah gambar yang dikirim ke LLM tanpa redaksi,
+// karena PII di dalam screenshot tidak bisa disamarkan
+func (s *AIService) auditImages(ctx context.Context, feature string, count int) {
+	if count == 0 || s.firestore == nil {
+		return
+	}
+
+	info := callInfoFrom(ctx)
+	_, _, err := s.firestore.Collection("pii_audit").Add(ctx, map[string]interface{}{
+		"feature":           feature,
+		"conversation_id":   info.ConversationID,
+		"user_id":           info.UserID,
+		"unredacted_images": count,
+		"created_at":        time.Now(),
+	})
+	if err != nil {
+		log.Printf("Gagal menyimpan audit gambar: %v", err)
+	}
+}
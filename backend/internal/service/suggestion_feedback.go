@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SuggestionFeedbackService mencatat draf balasan yang ditampilkan ke agent dan apakah
+// draf tersebut dipakai apa adanya, diedit, atau diabaikan saat agent membalas.
+type SuggestionFeedbackService struct {
+	firestore *firestore.Client
+}
+
+func NewSuggestionFeedbackService(f *firestore.Client) *SuggestionFeedbackService {
+	return &SuggestionFeedbackService{firestore: f}
+}
+
+func newSuggestionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "sug_" + hex.EncodeToString(b)
+}
+
+// SafeResponseIDs memberi ID tetap ke template balasan krisis agar tidak tercatat ulang
+// setiap kali agent membuka percakapan yang sama
+func SafeResponseIDs(convID string, version int64, suggestions []model.Suggestion) []model.Suggestion {
+	for i := range suggestions {
+		suggestions[i].ID = fmt.Sprintf("safe_%s_%d_%d", convID, version, i)
+	}
+	return suggestions
+}
+
+// Track menyimpan draf yang ditampilkan. Aman dipanggil berulang untuk draf yang sama
+// (misal dari cache), draf yang sudah tercatat tidak ditimpa.
+func (s *SuggestionFeedbackService) Track(ctx context.Context, convID string, suggestions []model.Suggestion) error {
+	for _, sug := range suggestions {
+		if sug.ID == "" {
+			continue
+		}
+		_, err := s.firestore.Collection("suggestions").Doc(sug.ID).Create(ctx, model.SuggestionRecord{
+			ConversationID: convID,
+			Tone:           sug.Tone,
+			Content:        sug.Content,
+			PromptVersion:  sug.PromptVersion,
+			Outcome:        model.SuggestionPending,
+			CreatedAt:      time.Now(),
+		})
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve dipanggil saat agent membalas: draf yang dipilih (suggestionID) ditandai verbatim
+// atau edited sesuai edit distance terhadap teks balasan, draf lain yang masih pending diabaikan.
+// Mengembalikan outcome draf yang dipilih, kosong jika tidak ada.
+func (s *SuggestionFeedbackService) Resolve(ctx context.Context, convID, agentID, suggestionID, replyText string) (string, error) {
+	iter := s.firestore.Collection("suggestions").
+		Where("conversation_id", "==", convID).
+		Where("outcome", "==", model.SuggestionPending).
+		Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now()
+	batch := s.firestore.Batch()
+	pending, outcome := 0, ""
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		var record model.SuggestionRecord
+		if err := doc.DataTo(&record); err != nil {
+			continue
+		}
+
+		updates := []firestore.Update{
+			{Path: "outcome", Value: model.SuggestionIgnored},
+			{Path: "agent_id", Value: agentID},
+			{Path: "resolved_at", Value: now},
+		}
+		if doc.Ref.ID == suggestionID {
+			distance := EditDistance(strings.TrimSpace(record.Content), strings.TrimSpace(replyText))
+			outcome = model.SuggestionEdited
+			if distance == 0 {
+				outcome = model.SuggestionVerbatim
+			}
+			updates[0].Value = outcome
+			updates = append(updates, firestore.Update{Path: "edit_distance", Value: distance})
+		}
+		batch.Update(doc.Ref, updates)
+		pending++
+	}
+
+	if pending > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return "", err
+		}
+	}
+	if suggestionID != "" && outcome == "" {
+		return "", fmt.Errorf("draf %s tidak ditemukan atau sudah dipakai", suggestionID)
+	}
+	return outcome, nil
+}
+
+// GetAdoptionReport menghitung tingkat adopsi draf per tone dan per versi prompt
+func (s *SuggestionFeedbackService) GetAdoptionReport(ctx context.Context, days int) (*model.SuggestionAdoptionReport, error) {
+	since := time.Now().AddDate(0, 0, -days)
+	iter := s.firestore.Collection("suggestions").Where("created_at", ">=", since).Documents(ctx)
+	defer iter.Stop()
+
+	report := &model.SuggestionAdoptionReport{
+		Days:           days,
+		Tones:          make(map[string]*model.ToneAdoption),
+		PromptVersions: make(map[string]*model.ToneAdoption),
+	}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record model.SuggestionRecord
+		if err := doc.DataTo(&record); err != nil {
+			continue
+		}
+		if report.Tones[record.Tone] == nil {
+			report.Tones[record.Tone] = &model.ToneAdoption{}
+		}
+		report.Tones[record.Tone].Add(record)
+
+		version := record.PromptVersion
+		if version == "" {
+			version = "safe-response"
+		}
+		if report.PromptVersions[version] == nil {
+			report.PromptVersions[version] = &model.ToneAdoption{}
+		}
+		report.PromptVersions[version].Add(record)
+	}
+	return report, nil
+}
+
+// EditDistance menghitung jarak Levenshtein per karakter (rune)
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
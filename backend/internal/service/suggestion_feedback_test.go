@@ -0,0 +1,24 @@
+package service
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"halo", "", 4},
+		{"", "halo", 4},
+		{"halo", "halo", 0},
+		{"kitten", "sitting", 3},
+		{"Mohon maaf", "Mohon maaf ya", 3},
+		{"café", "cafe", 1}, // per rune, bukan per byte
+		{"terima kasih", "kasih terima", 10},
+	}
+	for _, tt := range tests {
+		if got := EditDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("EditDistance(%q, %q) = %d, ingin %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/cache"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tim untuk agent yang belum punya field team di profilnya
+const DefaultTeam = "default"
+
+const (
+	maxSuggestionCount    = 6
+	maxForbiddenPhrases   = 20
+	maxSuggestionToneName = 40
+)
+
+var (
+	ErrInvalidSuggestionSettings = errors.New("pengaturan draf balasan tidak valid")
+	ErrInvalidSuggestions        = errors.New("respon AI tidak sesuai pengaturan draf balasan")
+)
+
+// DefaultSuggestionSettings sama dengan perilaku prompt sebelum bisa dikonfigurasi
+func DefaultSuggestionSettings() model.SuggestionSettings {
+	return model.SuggestionSettings{
+		Team:        DefaultTeam,
+		PersonaName: "Alex",
+		PersonaRole: "a Senior Customer Support Lead",
+		Tones:       []string{"Formal", "Empathetic"},
+		Count:       2,
+	}
+}
+
+// defaultSuggestionPrompt menandakan settings menghasilkan prompt yang sama dengan
+// pengaturan bawaan (persona dan tone), sehingga template versi lama masih cocok
+func defaultSuggestionPrompt(settings model.SuggestionSettings) bool {
+	def := DefaultSuggestionSettings()
+	return settings.PersonaName == def.PersonaName && settings.PersonaRole == def.PersonaRole &&
+		settings.Signature == "" && len(settings.ForbiddenPhrases) == 0 &&
+		slices.Equal(settings.ExpectedTones(), def.ExpectedTones())
+}
+
+type SuggestionSettingsService struct {
+	firestore *firestore.Client
+	cache     *cache.Cache
+}
+
+func NewSuggestionSettingsService(f *firestore.Client) *SuggestionSettingsService {
+	return &SuggestionSettingsService{firestore: f, cache: cache.New(time.Minute, 100)}
+}
+
+// Get mengambil pengaturan tim, fallback ke tim default lalu ke pengaturan bawaan
+func (s *SuggestionSettingsService) Get(ctx context.Context, team string) (model.SuggestionSettings, error) {
+	if team == "" {
+		team = DefaultTeam
+	}
+
+	value, err := s.cache.GetOrLoad(ctx, "settings", team, func(ctx context.Context) (interface{}, error) {
+		for _, t := range []string{team, DefaultTeam} {
+			doc, err := s.firestore.Collection("suggestion_settings").Doc(t).Get(ctx)
+			if status.Code(err) == codes.NotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			var settings model.SuggestionSettings
+			if err := doc.DataTo(&settings); err != nil {
+				return nil, err
+			}
+			settings.Team = t
+			return settings, nil
+		}
+		return DefaultSuggestionSettings(), nil
+	})
+	if err != nil {
+		return model.SuggestionSettings{}, err
+	}
+	return value.(model.SuggestionSettings), nil
+}
+
+func (s *SuggestionSettingsService) Save(ctx context.Context, settings model.SuggestionSettings) (model.SuggestionSettings, error) {
+	settings, err := NormalizeSuggestionSettings(settings)
+	if err != nil {
+		return settings, err
+	}
+	settings.UpdatedAt = time.Now()
+
+	if _, err := s.firestore.Collection("suggestion_settings").Doc(settings.Team).Set(ctx, settings); err != nil {
+		return settings, err
+	}
+	//tim lain yang fallback ke tim default akan ikut berubah setelah cache-nya kedaluwarsa
+	s.cache.Set("settings", settings.Team, settings)
+	return settings, nil
+}
+
+// NormalizeSuggestionSettings merapikan dan memvalidasi pengaturan sebelum disimpan
+func NormalizeSuggestionSettings(settings model.SuggestionSettings) (model.SuggestionSettings, error) {
+	settings.PersonaName = strings.TrimSpace(settings.PersonaName)
+	settings.PersonaRole = strings.TrimSpace(settings.PersonaRole)
+	settings.Signature = strings.TrimSpace(settings.Signature)
+	if settings.PersonaName == "" {
+		return settings, fmt.Errorf("%w: persona_name wajib diisi", ErrInvalidSuggestionSettings)
+	}
+
+	seen := make(map[string]bool)
+	var tones []string
+	for _, tone := range settings.Tones {
+		tone = strings.TrimSpace(tone)
+		if tone == "" || seen[strings.ToLower(tone)] {
+			continue
+		}
+		if len(tone) > maxSuggestionToneName {
+			return settings, fmt.Errorf("%w: nama tone maksimal %d karakter", ErrInvalidSuggestionSettings, maxSuggestionToneName)
+		}
+		seen[strings.ToLower(tone)] = true
+		tones = append(tones, tone)
+	}
+	if len(tones) == 0 {
+		return settings, fmt.Errorf("%w: minimal satu tone", ErrInvalidSuggestionSettings)
+	}
+	settings.Tones = tones
+
+	if settings.Count == 0 {
+		settings.Count = len(tones)
+	}
+	if settings.Count < 1 || settings.Count > maxSuggestionCount {
+		return settings, fmt.Errorf("%w: count harus 1-%d", ErrInvalidSuggestionSettings, maxSuggestionCount)
+	}
+
+	var phrases []string
+	for _, phrase := range settings.ForbiddenPhrases {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+	if len(phrases) > maxForbiddenPhrases {
+		return settings, fmt.Errorf("%w: forbidden_phrases maksimal %d", ErrInvalidSuggestionSettings, maxForbiddenPhrases)
+	}
+	settings.ForbiddenPhrases = phrases
+
+	return settings, nil
+}
+
+// conformSuggestions memastikan respon berisi tepat tone yang diminta dengan urutan
+// sesuai pengaturan, tanpa frasa terlarang, dan diakhiri tanda tangan.
+func conformSuggestions(got []model.Suggestion, settings model.SuggestionSettings) ([]model.Suggestion, error) {
+	expected := settings.ExpectedTones()
+	used := make([]bool, len(got))
+	result := make([]model.Suggestion, 0, len(expected))
+
+	for _, tone := range expected {
+		found := -1
+		for i, sug := range got {
+			if !used[i] && strings.EqualFold(strings.TrimSpace(sug.Tone), tone) {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return nil, fmt.Errorf("%w: tone %q tidak ada", ErrInvalidSuggestions, tone)
+		}
+		used[found] = true
+
+		sug := got[found]
+		sug.Tone = tone
+		if err := applyPersona(&sug, settings); err != nil {
+			return nil, err
+		}
+		result = append(result, sug)
+	}
+	return result, nil
+}
+
+// applyPersona memeriksa frasa terlarang dan menambahkan tanda tangan pada satu draf
+func applyPersona(sug *model.Suggestion, settings model.SuggestionSettings) error {
+	lower := strings.ToLower(sug.Content)
+	for _, phrase := range settings.ForbiddenPhrases {
+		if strings.Contains(lower, strings.ToLower(phrase)) {
+			return fmt.Errorf("%w: draf %q memakai frasa terlarang %q", ErrInvalidSuggestions, sug.Tone, phrase)
+		}
+	}
+	if settings.Signature != "" && !strings.Contains(sug.Content, settings.Signature) {
+		sug.Content = strings.TrimRight(sug.Content, " \n") + "\n\n" + settings.Signature
+	}
+	return nil
+}
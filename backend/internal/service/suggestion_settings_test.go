@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+)
+
+func TestDefaultSuggestionPrompt(t *testing.T) {
+	custom := func(change func(*model.SuggestionSettings)) model.SuggestionSettings {
+		s := DefaultSuggestionSettings()
+		s.Team = "finance"
+		change(&s)
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		settings model.SuggestionSettings
+		want     bool
+	}{
+		{"bawaan", DefaultSuggestionSettings(), true},
+		{"tim lain dengan isi bawaan", custom(func(*model.SuggestionSettings) {}), true},
+		{"tone berbeda", custom(func(s *model.SuggestionSettings) { s.Tones = []string{"Singkat"} }), false},
+		{"jumlah berbeda", custom(func(s *model.SuggestionSettings) { s.Count = 3 }), false},
+		{"persona berbeda", custom(func(s *model.SuggestionSettings) { s.PersonaName = "Sari" }), false},
+		{"tanda tangan", custom(func(s *model.SuggestionSettings) { s.Signature = "Tim Keuangan" }), false},
+		{"frasa terlarang", custom(func(s *model.SuggestionSettings) { s.ForbiddenPhrases = []string{"mohon bersabar"} }), false},
+	}
+	for _, tt := range tests {
+		if got := defaultSuggestionPrompt(tt.settings); got != tt.want {
+			t.Errorf("%s: defaultSuggestionPrompt = %v, ingin %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+)
+
+// jenis event streaming draf balasan
+const (
+	SuggestionDelta    = "delta"      // isi draf yang sedang ditulis (teks lengkap sejauh ini)
+	SuggestionComplete = "suggestion" // draf sudah lengkap dan punya ID
+	SuggestionReset    = "reset"      // draf yang sudah dikirim dibuang, akan dikirim ulang
+)
+
+type SuggestionEvent struct {
+	Type       string           `json:"-"`
+	Index      int              `json:"index"`
+	Suggestion model.Suggestion `json:"suggestion"`
+}
+
+// StreamSuggestions sama seperti GetSuggestions, tetapi setiap draf dikirim ke emit begitu
+// token-tokennya diterima dari model. Pembatalan ctx (agent menutup halaman) menghentikan
+// pemanggilan AI. Hasil akhir disimpan ke cache yang sama dengan GetSuggestions.
+// Jika respon akhir tidak memuat tone yang diminta, event reset dikirim lalu draf
+// dibuat ulang tanpa streaming.
+func (s *AIService) StreamSuggestions(ctx context.Context, convID string, conv model.Conversation, language string, settings model.SuggestionSettings, emit func(SuggestionEvent) error) ([]model.Suggestion, error) {
+	tmpl, err := s.suggestionTemplate(convID, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	key := suggestionCacheKey(convID, conv, tmpl, language, settings)
+	if value, ok := s.cache.Get("suggestions", key); ok {
+		suggestions := value.([]model.Suggestion)
+		return suggestions, emitAll(suggestions, emit)
+	}
+
+	if err := s.usage.CheckBudget("suggestions"); err != nil {
+		return nil, err
+	}
+
+	redaction := s.redactor.NewSession()
+	defer s.auditRedaction(ctx, "suggestions", redaction)
+
+	finalPrompt, err := tmpl.Execute(suggestionInput(language, redaction.Redact(conv.LastMessage), settings))
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &suggestionStreamParser{}
+	suggestions := []model.Suggestion{}
+	lastDelta := ""
+	temperature := float32(0.7)
+	resp, err := ai.GenerateStream(ctx, s.provider, ai.Request{Prompt: finalPrompt, JSON: true, Temperature: &temperature}, func(chunk string) error {
+		complete, partial := parser.Feed(chunk)
+		for _, sug := range complete {
+			sug.Content = redaction.Restore(sug.Content)
+			sug.ID = newSuggestionID()
+			sug.PromptVersion = tmpl.ID()
+			if err := applyPersona(&sug, settings); err != nil {
+				//frasa terlarang: jangan tampilkan, respon akhir akan gagal validasi
+				continue
+			}
+			suggestions = append(suggestions, sug)
+			lastDelta = ""
+			if err := emit(SuggestionEvent{Type: SuggestionComplete, Index: len(suggestions) - 1, Suggestion: sug}); err != nil {
+				return err
+			}
+		}
+		if partial != nil && partial.Content != lastDelta {
+			lastDelta = partial.Content
+			partial.Content = redaction.Restore(partial.Content)
+			return emit(SuggestionEvent{Type: SuggestionDelta, Index: len(suggestions), Suggestion: *partial})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal memanggil AI: %w", err)
+	}
+	s.usage.Record(ctx, "suggestions", resp.Model, resp.Usage)
+
+	//pastikan respon lengkap tetap JSON yang valid, sama seperti GenerateSuggestions
+	var all []model.Suggestion
+	if err := json.Unmarshal([]byte(resp.Text), &all); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v. Raw: %s", err, resp.Text)
+	}
+
+	conformed, err := conformSuggestions(suggestions, settings)
+	if errors.Is(err, ErrInvalidSuggestions) {
+		log.Printf("draf balasan streaming tidak sesuai pengaturan, dibuat ulang: %v", err)
+		if err := emit(SuggestionEvent{Type: SuggestionReset}); err != nil {
+			return nil, err
+		}
+		conformed, err = s.GenerateSuggestions(ctx, conv.LastMessage, language, settings)
+		if err != nil {
+			return nil, err
+		}
+		if err := emitAll(conformed, emit); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	s.cache.Set("suggestions", key, conformed)
+	return conformed, nil
+}
+
+func emitAll(suggestions []model.Suggestion, emit func(SuggestionEvent) error) error {
+	for i, sug := range suggestions {
+		if err := emit(SuggestionEvent{Type: SuggestionComplete, Index: i, Suggestion: sug}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var partialFieldRe = regexp.MustCompile(`"(tone|content)"\s*:\s*"((?:[^"\\]|\\.)*)`)
+
+// suggestionStreamParser membaca JSON array draf balasan yang datang sepotong-sepotong.
+// Objek yang sudah tertutup dikembalikan sebagai draf lengkap, objek yang masih terbuka
+// dikembalikan sebagai draf parsial (tone/content sejauh ini).
+type suggestionStreamParser struct {
+	buf     strings.Builder
+	emitted int
+}
+
+func (p *suggestionStreamParser) Feed(chunk string) ([]model.Suggestion, *model.Suggestion) {
+	p.buf.WriteString(chunk)
+	text := p.buf.String()
+
+	var objects []string
+	openStart := -1
+	depth, inString, escaped := 0, false, false
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case ch == '"':
+			inString = !inString
+		case inString:
+		case ch == '{':
+			depth++
+			if depth == 1 {
+				openStart = i
+			}
+		case ch == '}':
+			depth--
+			if depth == 0 && openStart >= 0 {
+				objects = append(objects, text[openStart:i+1])
+				openStart = -1
+			}
+		}
+	}
+
+	var complete []model.Suggestion
+	for _, obj := range objects[min(p.emitted, len(objects)):] {
+		var sug model.Suggestion
+		p.emitted++
+		if err := json.Unmarshal([]byte(obj), &sug); err != nil {
+			continue
+		}
+		complete = append(complete, sug)
+	}
+
+	if openStart < 0 {
+		return complete, nil
+	}
+	partial := &model.Suggestion{}
+	for _, m := range partialFieldRe.FindAllStringSubmatch(text[openStart:], -1) {
+		value := decodePartialString(m[2])
+		if m[1] == "tone" {
+			partial.Tone = value
+		} else {
+			partial.Content = value
+		}
+	}
+	return complete, partial
+}
+
+// decodePartialString mendekode isi string JSON yang mungkin terpotong di tengah escape
+func decodePartialString(raw string) string {
+	for cut := 0; cut <= 6 && cut <= len(raw); cut++ {
+		var value string
+		if err := json.Unmarshal([]byte(`"`+raw[:len(raw)-cut]+`"`), &value); err == nil {
+			return value
+		}
+	}
+	return ""
+}
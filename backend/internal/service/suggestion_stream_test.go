@@ -0,0 +1,85 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+)
+
+const streamSample = `[{"tone":"Formal","content":"Mohon maaf {atas} kendala \"UKT\" Anda.\nKami cek."},` +
+	` {"tone":"Empati","content":"Kami paham ini menyulitkan é \\ terima kasih."}]`
+
+func TestSuggestionStreamParserChunks(t *testing.T) {
+	want := []model.Suggestion{
+		{Tone: "Formal", Content: "Mohon maaf {atas} kendala \"UKT\" Anda.\nKami cek."},
+		{Tone: "Empati", Content: "Kami paham ini menyulitkan é \\ terima kasih."},
+	}
+	for _, size := range []int{1, 3, 7, 16, len(streamSample)} {
+		var p suggestionStreamParser
+		var got []model.Suggestion
+		for i := 0; i < len(streamSample); i += size {
+			complete, _ := p.Feed(streamSample[i:min(i+size, len(streamSample))])
+			got = append(got, complete...)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("potongan %d byte: %+v, ingin %+v", size, got, want)
+		}
+	}
+}
+
+func TestSuggestionStreamParserPartial(t *testing.T) {
+	tests := []struct {
+		name         string
+		chunks       []string
+		wantComplete int
+		wantPartial  *model.Suggestion
+	}{
+		{"belum ada objek", []string{`[`}, 0, nil},
+		{"objek baru dibuka", []string{`[{`}, 0, &model.Suggestion{}},
+		{"tone lengkap, content terpotong", []string{`[{"tone":"Formal",`, `"content":"Mohon ma`}, 0, &model.Suggestion{Tone: "Formal", Content: "Mohon ma"}},
+		{"terpotong di tengah escape", []string{`[{"content":"baris\`}, 0, &model.Suggestion{Content: "baris"}},
+		{"terpotong di tengah unicode", []string{`[{"content":"caf\u00`}, 0, &model.Suggestion{Content: "caf"}},
+		{"objek pertama selesai", []string{`[{"tone":"A","content":"x"}`, `,{"tone":"B"`}, 1, &model.Suggestion{Tone: "B"}},
+		{"kurung kurawal di dalam string", []string{`[{"content":"a } b {"`}, 0, &model.Suggestion{Content: "a } b {"}},
+		{"objek rusak dilewati", []string{`[{"tone":1}`, `]`}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p suggestionStreamParser
+			var complete []model.Suggestion
+			var partial *model.Suggestion
+			for _, c := range tt.chunks {
+				var done []model.Suggestion
+				done, partial = p.Feed(c)
+				complete = append(complete, done...)
+			}
+			if len(complete) != tt.wantComplete {
+				t.Errorf("draf lengkap = %d, ingin %d", len(complete), tt.wantComplete)
+			}
+			if !reflect.DeepEqual(partial, tt.wantPartial) {
+				t.Errorf("draf parsial = %+v, ingin %+v", partial, tt.wantPartial)
+			}
+		})
+	}
+}
+
+func TestDecodePartialString(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`halo`, "halo"},
+		{`baris\nbaru`, "baris\nbaru"},
+		{`kutip \"`, `kutip "`},
+		{`potong\`, "potong"},
+		{`café`, "café"},
+		{`caf\u00`, "caf"},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		if got := decodePartialString(tt.raw); got != tt.want {
+			t.Errorf("decodePartialString(%q) = %q, ingin %q", tt.raw, got, tt.want)
+		}
+	}
+}
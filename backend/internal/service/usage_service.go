@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"github.com/BimoAtaullahR/ai-customer-support/pkg/ai"
+)
+
+var ErrBudgetExceeded = errors.New("budget token AI harian sudah habis")
+
+// UsageService mencatat pemakaian token per fitur, percakapan, dan agent,
+// serta menjaga budget harian agar fitur bisa turun kelas dengan aman.
+type UsageService struct {
+	firestore *firestore.Client
+	cfg       *config.AIConfig
+
+	mu    sync.Mutex
+	day   string
+	used  map[string]int64 // total token hari ini per fitur
+	total int64
+}
+
+func NewUsageService(f *firestore.Client, cfg *config.AIConfig) *UsageService {
+	return &UsageService{
+		firestore: f,
+		cfg:       cfg,
+		day:       today(),
+		used:      make(map[string]int64),
+	}
+}
+
+// LoadToday mengisi counter in-memory dari rollup hari ini, dipanggil saat server start
+// agar restart tidak mereset budget.
+func (s *UsageService) LoadToday(ctx context.Context) error {
+	if s.firestore == nil {
+		return nil
+	}
+	doc, err := s.firestore.Collection("ai_usage_daily").Doc(today()).Get(ctx)
+	if err != nil {
+		return nil //belum ada pemakaian hari ini
+	}
+
+	var daily model.DailyAIUsage
+	if err := doc.DataTo(&daily); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.day = daily.Date
+	s.total = daily.Total.TotalTokens
+	for feature, usage := range daily.Features {
+		s.used[feature] = usage.TotalTokens
+	}
+	return nil
+}
+
+// CheckBudget mengembalikan ErrBudgetExceeded jika budget total atau budget fitur sudah habis
+func (s *UsageService) CheckBudget(feature string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollover()
+
+	if s.cfg.DailyTokenBudget > 0 && s.total >= s.cfg.DailyTokenBudget {
+		return ErrBudgetExceeded
+	}
+	if limit, ok := s.cfg.FeatureBudgets[feature]; ok && limit > 0 && s.used[feature] >= limit {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// Record mencatat pemakaian satu pemanggilan model. Penulisan ke firestore dilakukan di
+// background agar tidak menambah latensi request.
+func (s *UsageService) Record(ctx context.Context, feature string, modelName string, usage ai.Usage) {
+	s.mu.Lock()
+	s.rollover()
+	s.used[feature] += usage.TotalTokens
+	s.total += usage.TotalTokens
+	day := s.day
+	s.mu.Unlock()
+
+	if s.firestore == nil {
+		return
+	}
+
+	info := callInfoFrom(ctx)
+	cost := s.cost(usage)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, _, err := s.firestore.Collection("ai_usage_events").Add(ctx, map[string]interface{}{
+			"feature":          feature,
+			"model":            modelName,
+			"conversation_id":  info.ConversationID,
+			"user_id":          info.UserID,
+			"prompt_tokens":    usage.PromptTokens,
+			"candidate_tokens": usage.CandidateTokens,
+			"total_tokens":     usage.TotalTokens,
+			"cost_usd":         cost,
+			"created_at":       time.Now(),
+		})
+		if err != nil {
+			log.Printf("Gagal menyimpan event pemakaian AI: %v", err)
+		}
+
+		rollup := map[string]interface{}{
+			"date":     day,
+			"total":    usageIncrement(usage, cost),
+			"features": map[string]interface{}{feature: usageIncrement(usage, cost)},
+		}
+		if info.UserID != "" {
+			rollup["agents"] = map[string]interface{}{info.UserID: usageIncrement(usage, cost)}
+		}
+		_, err = s.firestore.Collection("ai_usage_daily").Doc(day).Set(ctx, rollup, firestore.MergeAll)
+		if err != nil {
+			log.Printf("Gagal memperbarui rollup pemakaian AI: %v", err)
+		}
+	}()
+}
+
+// GetReport mengambil rollup harian untuk N hari terakhir beserta status budget hari ini
+func (s *UsageService) GetReport(ctx context.Context, days int) (*model.AIUsageReport, error) {
+	report := &model.AIUsageReport{Days: []model.DailyAIUsage{}}
+
+	now := time.Now()
+	var refs []*firestore.DocumentRef
+	for i := days - 1; i >= 0; i-- {
+		refs = append(refs, s.firestore.Collection("ai_usage_daily").Doc(now.AddDate(0, 0, -i).Format("2006-01-02")))
+	}
+
+	docs, err := s.firestore.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		daily := model.DailyAIUsage{Date: refs[i].ID}
+		if doc.Exists() {
+			if err := doc.DataTo(&daily); err != nil {
+				continue
+			}
+		}
+		report.Days = append(report.Days, daily)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollover()
+	if s.cfg.DailyTokenBudget > 0 {
+		report.Budgets = append(report.Budgets, model.AIBudgetStatus{
+			Feature:  "total",
+			Limit:    s.cfg.DailyTokenBudget,
+			Used:     s.total,
+			Exceeded: s.total >= s.cfg.DailyTokenBudget,
+		})
+	}
+	features := make([]string, 0, len(s.cfg.FeatureBudgets))
+	for feature := range s.cfg.FeatureBudgets {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	for _, feature := range features {
+		limit := s.cfg.FeatureBudgets[feature]
+		report.Budgets = append(report.Budgets, model.AIBudgetStatus{
+			Feature:  feature,
+			Limit:    limit,
+			Used:     s.used[feature],
+			Exceeded: limit > 0 && s.used[feature] >= limit,
+		})
+	}
+
+	return report, nil
+}
+
+func (s *UsageService) cost(usage ai.Usage) float64 {
+	return float64(usage.PromptTokens)*s.cfg.PricePerMTokInput/1e6 +
+		float64(usage.CandidateTokens)*s.cfg.PricePerMTokOutput/1e6
+}
+
+// reset counter saat berganti hari, dipanggil dengan mu terkunci
+func (s *UsageService) rollover() {
+	if d := today(); d != s.day {
+		s.day = d
+		s.total = 0
+		s.used = make(map[string]int64)
+	}
+}
+
+func usageIncrement(usage ai.Usage, cost float64) map[string]interface{} {
+	return map[string]interface{}{
+		"calls":            firestore.Increment(1),
+		"prompt_tokens":    firestore.Increment(usage.PromptTokens),
+		"candidate_tokens": firestore.Increment(usage.CandidateTokens),
+		"total_tokens":     firestore.Increment(usage.TotalTokens),
+		"cost_usd":         firestore.Increment(cost),
+	}
+}
+
+func today() string {
+	return time.Now().Format("2006-01-02")
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var errBlockedAddress = errors.New("alamat tujuan webhook tidak diizinkan (jaringan internal)")
+
+// rentang yang tidak tercakup net.IP.IsPrivate/IsLoopback/IsLinkLocal*
+var blockedNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // "jaringan ini"
+		"100.64.0.0/10", // CGNAT, sering dipakai jaringan internal cloud
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved
+		"64:ff9b::/96",  // NAT64, bisa memetakan ke IPv4 internal
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// publicIP menandakan ip boleh dituju webhook: bukan loopback, privat, link-local
+// (termasuk metadata cloud 169.254.169.254), multicast, atau rentang khusus lain
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookHost menolak host yang jelas menuju jaringan internal saat webhook disimpan.
+// Hasil DNS bisa berubah, jadi alamat yang benar-benar dituju tetap diperiksa saat dial.
+func checkWebhookHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return errBlockedAddress
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicIP(ip) {
+			return errBlockedAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		//belum bisa di-resolve: dibiarkan, pemeriksaan saat dial tetap berlaku
+		return nil
+	}
+	for _, a := range addrs {
+		if !publicIP(a.IP) {
+			return errBlockedAddress
+		}
+	}
+	return nil
+}
+
+// newWebhookClient membuat klien HTTP yang hanya mau terhubung ke IP publik. Alamat diperiksa
+// setelah DNS di-resolve (Control dipanggil per koneksi), sehingga DNS rebinding tidak lolos.
+// Redirect tidak diikuti dan proxy dari environment tidak dipakai.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConns:        20,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
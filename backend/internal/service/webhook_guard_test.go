@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := publicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("publicIP(%s) = %v, ingin %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWebhookHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		blocked bool
+	}{
+		{"localhost", true},
+		{"api.localhost", true},
+		{"metadata.google.internal", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"::1", true},
+		{"8.8.8.8", false},
+	}
+	for _, tt := range tests {
+		err := checkWebhookHost(tt.host)
+		if blocked := errors.Is(err, errBlockedAddress); blocked != tt.blocked {
+			t.Errorf("checkWebhookHost(%q) = %v, ingin diblokir %v", tt.host, err, tt.blocked)
+		}
+	}
+}
+
+func TestWebhookClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := newWebhookClient().Get(srv.URL)
+	if !errors.Is(err, errBlockedAddress) {
+		t.Fatalf("koneksi ke %s seharusnya ditolak, err = %v", srv.URL, err)
+	}
+}
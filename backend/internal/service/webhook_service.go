@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook tidak ditemukan")
+	ErrDeliveryNotFound = errors.New("pengiriman webhook tidak ditemukan")
+	ErrInvalidWebhook   = errors.New("webhook tidak valid")
+)
+
+const (
+	// pengiriman yang gagal sebanyak ini dipindah ke dead-letter
+	maxWebhookAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	// selama lease, pengiriman yang sedang diproses tidak diambil worker lain
+	webhookLease   = time.Minute
+	webhookTimeout = 10 * time.Second
+)
+
+var webhookEvents = map[string]bool{
+	model.WebhookConversationCreated:  true,
+	model.WebhookConversationAnalyzed: true,
+	model.WebhookMessageCreated:       true,
+	model.WebhookStatusChanged:        true,
+}
+
+// WebhookService mengirim event siklus hidup tiket ke URL langganan. Setiap pengiriman
+// ditandatangani HMAC-SHA256, dicoba ulang dengan exponential backoff, dan masuk dead-letter
+// setelah maxWebhookAttempts sehingga bisa di-replay oleh support lead.
+type WebhookService struct {
+	firestore *firestore.Client
+	client    *http.Client
+}
+
+func NewWebhookService(f *firestore.Client) *WebhookService {
+	return &WebhookService{firestore: f, client: newWebhookClient()}
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]model.Webhook, error) {
+	iter := s.firestore.Collection("webhooks").OrderBy("created_at", firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	webhooks := []model.Webhook{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return webhooks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var w model.Webhook
+		if err := doc.DataTo(&w); err != nil {
+			continue
+		}
+		w.ID = doc.Ref.ID
+		w.Secret = ""
+		webhooks = append(webhooks, w)
+	}
+}
+
+// Create mendaftarkan webhook baru. Secret hanya dikembalikan di sini dan saat rotate.
+func (s *WebhookService) Create(ctx context.Context, w model.Webhook) (*model.Webhook, error) {
+	if err := validateWebhook(&w); err != nil {
+		return nil, err
+	}
+	w.Secret = newWebhookSecret()
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = w.CreatedAt
+
+	ref := s.firestore.Collection("webhooks").NewDoc()
+	if _, err := ref.Set(ctx, w); err != nil {
+		return nil, err
+	}
+	w.ID = ref.ID
+	return &w, nil
+}
+
+// Update mengganti url, events, deskripsi, dan status aktif. Secret tidak berubah.
+func (s *WebhookService) Update(ctx context.Context, id string, w model.Webhook) (*model.Webhook, error) {
+	if err := validateWebhook(&w); err != nil {
+		return nil, err
+	}
+	ref := s.firestore.Collection("webhooks").Doc(id)
+	_, err := ref.Update(ctx, []firestore.Update{
+		{Path: "url", Value: w.URL},
+		{Path: "events", Value: w.Events},
+		{Path: "description", Value: w.Description},
+		{Path: "active", Value: w.Active},
+		{Path: "updated_at", Value: time.Now()},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	updated.Secret = ""
+	return updated, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id string) error {
+	_, err := s.firestore.Collection("webhooks").Doc(id).Delete(ctx, firestore.Exists)
+	if status.Code(err) == codes.NotFound {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// RotateSecret membuat secret baru, secret lama langsung tidak berlaku
+func (s *WebhookService) RotateSecret(ctx context.Context, id string) (*model.Webhook, error) {
+	secret := newWebhookSecret()
+	_, err := s.firestore.Collection("webhooks").Doc(id).Update(ctx, []firestore.Update{
+		{Path: "secret", Value: secret},
+		{Path: "updated_at", Value: time.Now()},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, id)
+}
+
+// Publish mencatat pengiriman event ke setiap webhook aktif yang berlangganan lalu
+// mencobanya di background. Kegagalan hanya dicatat di log, tidak menggagalkan pemanggil.
+func (s *WebhookService) Publish(ctx context.Context, event, convID string, data interface{}) {
+	if s == nil {
+		return //tanpa webhook, misal saat evaluasi offline
+	}
+	iter := s.firestore.Collection("webhooks").Where("events", "array-contains", event).Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return
+		}
+		if err != nil {
+			log.Printf("Gagal membaca langganan webhook %s: %v", event, err)
+			return
+		}
+		if active, _ := doc.Data()["active"].(bool); !active {
+			continue
+		}
+
+		ref := s.firestore.Collection("webhook_deliveries").NewDoc()
+		payload, err := json.Marshal(model.WebhookPayload{ID: ref.ID, Event: event, ConversationID: convID, CreatedAt: now, Data: data})
+		if err != nil {
+			log.Printf("Gagal menyusun payload webhook %s: %v", event, err)
+			return
+		}
+		delivery := model.WebhookDelivery{
+			WebhookID:      doc.Ref.ID,
+			Event:          event,
+			ConversationID: convID,
+			Payload:        string(payload),
+			Status:         model.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+		if _, err := ref.Set(ctx, delivery); err != nil {
+			log.Printf("Gagal mencatat pengiriman webhook %s: %v", event, err)
+			continue
+		}
+		//request pemanggil bisa sudah selesai saat goroutine berjalan
+		go s.attempt(context.Background(), ref.ID)
+	}
+}
+
+// RunDeliveries mencoba ulang pengiriman yang jatuh tempo secara berkala sampai ctx dibatalkan
+func (s *WebhookService) RunDeliveries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.processDeliveries(ctx)
+		}
+	}
+}
+
+func (s *WebhookService) processDeliveries(ctx context.Context) {
+	iter := s.firestore.Collection("webhook_deliveries").
+		Where("status", "==", model.DeliveryPending).
+		Where("next_attempt_at", "<=", time.Now()).
+		OrderBy("next_attempt_at", firestore.Asc).
+		Limit(50).
+		Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return
+		}
+		if err != nil {
+			log.Printf("Gagal membaca antrean webhook: %v", err)
+			return
+		}
+		s.attempt(ctx, doc.Ref.ID)
+	}
+}
+
+// ListDeliveries mengembalikan pengiriman terbaru, opsional difilter status (misal dead) dan webhook
+func (s *WebhookService) ListDeliveries(ctx context.Context, statusFilter, webhookID string, limit int) ([]model.WebhookDelivery, error) {
+	query := s.firestore.Collection("webhook_deliveries").Query
+	if statusFilter != "" {
+		query = query.Where("status", "==", statusFilter)
+	}
+	if webhookID != "" {
+		query = query.Where("webhook_id", "==", webhookID)
+	}
+	iter := query.OrderBy("created_at", firestore.Desc).Limit(limit).Documents(ctx)
+	defer iter.Stop()
+
+	deliveries := []model.WebhookDelivery{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return deliveries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var d model.WebhookDelivery
+		if err := doc.DataTo(&d); err != nil {
+			continue
+		}
+		d.ID = doc.Ref.ID
+		deliveries = append(deliveries, d)
+	}
+}
+
+// Replay mengantrekan ulang pengiriman (biasanya dari dead-letter) dengan payload dan ID yang sama
+func (s *WebhookService) Replay(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	ref := s.firestore.Collection("webhook_deliveries").Doc(id)
+	_, err := ref.Update(ctx, []firestore.Update{
+		{Path: "status", Value: model.DeliveryPending},
+		{Path: "attempts", Value: 0},
+		{Path: "last_error", Value: firestore.Delete},
+		{Path: "next_attempt_at", Value: time.Now()},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.attempt(ctx, id)
+	return s.getDelivery(ctx, id)
+}
+
+// attempt mengirim satu pengiriman jika masih pending dan jatuh tempo, lalu mencatat hasilnya
+func (s *WebhookService) attempt(ctx context.Context, id string) {
+	ref := s.firestore.Collection("webhook_deliveries").Doc(id)
+	delivery, err := s.claimDelivery(ctx, ref)
+	if err != nil {
+		log.Printf("Gagal mengambil pengiriman webhook %s: %v", id, err)
+		return
+	}
+	if delivery == nil {
+		return
+	}
+
+	updates := []firestore.Update{{Path: "attempts", Value: delivery.Attempts + 1}}
+	hook, err := s.get(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, ErrWebhookNotFound):
+		updates = append(updates, firestore.Update{Path: "status", Value: model.DeliveryDead}, firestore.Update{Path: "last_error", Value: "webhook sudah dihapus"})
+	case err != nil:
+		log.Printf("Gagal membaca webhook %s: %v", delivery.WebhookID, err)
+		return //lease habis, dicoba lagi oleh worker
+	case !hook.Active:
+		updates = append(updates, firestore.Update{Path: "status", Value: model.DeliveryDead}, firestore.Update{Path: "last_error", Value: "webhook nonaktif"})
+	default:
+		code, sendErr := s.post(ctx, hook, delivery)
+		if code != 0 {
+			updates = append(updates, firestore.Update{Path: "response_code", Value: code})
+		}
+		switch {
+		case sendErr == nil:
+			updates = append(updates,
+				firestore.Update{Path: "status", Value: model.DeliveryDelivered},
+				firestore.Update{Path: "delivered_at", Value: time.Now()},
+				firestore.Update{Path: "last_error", Value: firestore.Delete},
+			)
+		case delivery.Attempts+1 >= maxWebhookAttempts:
+			log.Printf("Webhook %s gagal %d kali, pengiriman %s masuk dead-letter: %v", hook.ID, delivery.Attempts+1, id, sendErr)
+			updates = append(updates, firestore.Update{Path: "status", Value: model.DeliveryDead}, firestore.Update{Path: "last_error", Value: sendErr.Error()})
+		default:
+			updates = append(updates,
+				firestore.Update{Path: "next_attempt_at", Value: time.Now().Add(webhookBackoff(delivery.Attempts + 1))},
+				firestore.Update{Path: "last_error", Value: sendErr.Error()},
+			)
+		}
+	}
+
+	if _, err := ref.Update(ctx, updates); err != nil {
+		log.Printf("Gagal mencatat hasil pengiriman webhook %s: %v", id, err)
+	}
+}
+
+// claimDelivery memajukan next_attempt_at sebesar lease dalam transaksi sehingga pengiriman
+// yang sama tidak dikirim dua kali oleh goroutine Publish dan worker. nil jika tidak perlu dikirim.
+func (s *WebhookService) claimDelivery(ctx context.Context, ref *firestore.DocumentRef) (*model.WebhookDelivery, error) {
+	var claimed *model.WebhookDelivery
+	err := s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = nil
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var d model.WebhookDelivery
+		if err := doc.DataTo(&d); err != nil {
+			return err
+		}
+		now := time.Now()
+		if d.Status != model.DeliveryPending || d.NextAttemptAt.After(now) {
+			return nil
+		}
+		d.ID = doc.Ref.ID
+		claimed = &d
+		return tx.Update(ref, []firestore.Update{{Path: "next_attempt_at", Value: now.Add(webhookLease)}})
+	})
+	return claimed, err
+}
+
+// post mengirim payload dengan header tanda tangan:
+// X-Webhook-Signature = "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+func (s *WebhookService) post(ctx context.Context, hook *model.Webhook, d *model.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ai-customer-support-webhook/1.0")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", d.ID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(hook.Secret, timestamp, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	//isi respons tidak disimpan ke last_error agar endpoint penerima tidak bisa dibaca lewat log pengiriman
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook menghitung tanda tangan HMAC-SHA256 (hex) yang sama dengan yang harus
+// dihitung penerima untuk memverifikasi pengiriman
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff 30 detik, 1 menit, 2 menit, ... maksimal 6 jam
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+func (s *WebhookService) get(ctx context.Context, id string) (*model.Webhook, error) {
+	doc, err := s.firestore.Collection("webhooks").Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var w model.Webhook
+	if err := doc.DataTo(&w); err != nil {
+		return nil, err
+	}
+	w.ID = doc.Ref.ID
+	return &w, nil
+}
+
+func (s *WebhookService) getDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	doc, err := s.firestore.Collection("webhook_deliveries").Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d model.WebhookDelivery
+	if err := doc.DataTo(&d); err != nil {
+		return nil, err
+	}
+	d.ID = doc.Ref.ID
+	return &d, nil
+}
+
+func validateWebhook(w *model.Webhook) error {
+	w.URL = strings.TrimSpace(w.URL)
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: url harus berupa URL http(s) lengkap", ErrInvalidWebhook)
+	}
+	if err := checkWebhookHost(u.Hostname()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if len(w.Events) == 0 {
+		return fmt.Errorf("%w: minimal satu event", ErrInvalidWebhook)
+	}
+	seen := make(map[string]bool)
+	events := w.Events[:0]
+	for _, e := range w.Events {
+		if !webhookEvents[e] {
+			return fmt.Errorf("%w: event %q tidak dikenal", ErrInvalidWebhook, e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	w.Events = events
+	return nil
+}
+
+func newWebhookSecret() string {
+	var b [32]byte
+	rand.Read(b[:])
+	return "whsec_" + hex.EncodeToString(b[:])
+}
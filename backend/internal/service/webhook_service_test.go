@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"ticket.created"}`)
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		want      string
+	}{
+		//nilai acuan dihitung terpisah: HMAC-SHA256(secret, timestamp + "." + body)
+		{"dengan body", "whsec_test", "1769828400", body, "41b9c8e4a04d07dfc38c6663e39e79296bab3e3e661853160182d175c913b18b"},
+		{"body kosong", "whsec_test", "1769828400", nil, "d7e91a127c1613274908810f5b04d9626448f70afde197ef62a3207c4810d442"},
+	}
+	for _, tt := range tests {
+		if got := SignWebhook(tt.secret, tt.timestamp, tt.body); got != tt.want {
+			t.Errorf("%s: SignWebhook = %s, ingin %s", tt.name, got, tt.want)
+		}
+	}
+
+	//secret, timestamp, dan body sama-sama ikut ditandatangani
+	base := SignWebhook("whsec_test", "1769828400", body)
+	for name, got := range map[string]string{
+		"secret lain":    SignWebhook("whsec_lain", "1769828400", body),
+		"timestamp lain": SignWebhook("whsec_test", "1769828401", body),
+		"body lain":      SignWebhook("whsec_test", "1769828400", []byte(`{"event":"ticket.resolved"}`)),
+	} {
+		if got == base {
+			t.Errorf("%s menghasilkan signature yang sama", name)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, ingin %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+	"github.com/BimoAtaullahR/ai-customer-support/internal/model"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrWidgetDisabled       = errors.New("widget chat tidak aktif")
+	ErrInvalidWidgetSession = errors.New("sesi widget tidak valid atau kedaluwarsa")
+	ErrWidgetSessionLinked  = errors.New("sesi widget sudah ditautkan ke akun, silakan login")
+	ErrWidgetLimit          = errors.New("batas tiket terbuka untuk sesi ini sudah tercapai")
+	ErrWidgetRequest        = errors.New("permintaan widget tidak valid")
+)
+
+const (
+	widgetTokenPrefix = "wgt_"
+	widgetMaxText     = 4000
+	widgetVisitorName = "Pengunjung"
+)
+
+// WidgetService melayani pengunjung anonim dari widget chat. Sesi diidentifikasi dengan
+// token bertanda tangan HMAC (wgt_<id>.<exp>.<sig>), keluhan memakai pipeline yang sama
+// dengan form mahasiswa, dan ditautkan ke akun saat pengunjung mendaftar.
+type WidgetService struct {
+	firestore  *firestore.Client
+	complaints *ComplaintService
+	cfg        *config.WidgetConfig
+}
+
+func NewWidgetService(f *firestore.Client, complaints *ComplaintService, cfg *config.WidgetConfig) *WidgetService {
+	return &WidgetService{firestore: f, complaints: complaints, cfg: cfg}
+}
+
+func (s *WidgetService) Enabled() bool {
+	return s.cfg.SessionSecret != ""
+}
+
+// CreateSession membuat sesi pengunjung baru. name dan email boleh kosong.
+func (s *WidgetService) CreateSession(ctx context.Context, name, email, ip, userAgent string) (*model.WidgetSession, error) {
+	if !s.Enabled() {
+		return nil, ErrWidgetDisabled
+	}
+	name = strings.TrimSpace(name)
+	if len(name) > 100 {
+		return nil, fmt.Errorf("%w: name maksimal 100 karakter", ErrWidgetRequest)
+	}
+	if email = strings.TrimSpace(email); email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil {
+			return nil, fmt.Errorf("%w: email tidak valid", ErrWidgetRequest)
+		}
+		email = strings.ToLower(addr.Address)
+	}
+	if len(userAgent) > 300 {
+		userAgent = userAgent[:300]
+	}
+
+	ref := s.firestore.Collection("widget_sessions").NewDoc()
+	now := time.Now()
+	sess := model.WidgetSession{
+		Name:      name,
+		Email:     email,
+		IP:        ip,
+		UserAgent: userAgent,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.SessionTTL),
+	}
+	if _, err := ref.Create(ctx, sess); err != nil {
+		return nil, err
+	}
+	sess.ID = ref.ID
+	sess.Token = s.sign(ref.ID, sess.ExpiresAt)
+	return &sess, nil
+}
+
+// Authenticate memverifikasi token dan mengembalikan sesi yang masih aktif
+func (s *WidgetService) Authenticate(ctx context.Context, token string) (*model.WidgetSession, error) {
+	if !s.Enabled() {
+		return nil, ErrWidgetDisabled
+	}
+	id, err := s.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := s.getSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sess.LinkedStudentID != "" {
+		return nil, ErrWidgetSessionLinked
+	}
+	return sess, nil
+}
+
+// CreateConversation membuka tiket dari widget, dibatasi MaxOpenConversations tiket belum resolved per sesi
+func (s *WidgetService) CreateConversation(ctx context.Context, sess *model.WidgetSession, text string) (*model.Conversation, error) {
+	if err := validateWidgetText(text); err != nil {
+		return nil, err
+	}
+	convs, err := s.ListConversations(ctx, sess)
+	if err != nil {
+		return nil, err
+	}
+	open := 0
+	for _, conv := range convs {
+		if conv.Status != model.StatusResolved {
+			open++
+		}
+	}
+	if s.cfg.MaxOpenConversations > 0 && open >= s.cfg.MaxOpenConversations {
+		return nil, ErrWidgetLimit
+	}
+
+	name := sess.Name
+	if name == "" {
+		name = widgetVisitorName
+	}
+	//email sesi belum diverifikasi, jadi tidak disalin ke student_email yang dipakai notifikasi
+	//keluar. Tiket mendapat email mahasiswa setelah sesi ditautkan ke akun.
+	return s.complaints.Create(ctx, ComplaintInput{
+		StudentName:     name,
+		Text:            text,
+		Channel:         model.ChannelWidget,
+		WidgetSessionID: sess.ID,
+	})
+}
+
+func (s *WidgetService) ListConversations(ctx context.Context, sess *model.WidgetSession) ([]model.Conversation, error) {
+	iter := s.firestore.Collection("conversations").Where("widget_session_id", "==", sess.ID).Documents(ctx)
+	defer iter.Stop()
+
+	convs := []model.Conversation{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var conv model.Conversation
+		if err := doc.DataTo(&conv); err != nil {
+			continue
+		}
+		conv.ID = doc.Ref.ID
+		convs = append(convs, conv)
+	}
+	sort.Slice(convs, func(i, j int) bool { return convs[i].UpdatedAt.After(convs[j].UpdatedAt) })
+	return convs, nil
+}
+
+// GetConversation hanya mengembalikan tiket milik sesi ini
+func (s *WidgetService) GetConversation(ctx context.Context, sess *model.WidgetSession, convID string) (*model.Conversation, error) {
+	doc, err := s.firestore.Collection("conversations").Doc(convID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conv model.Conversation
+	if err := doc.DataTo(&conv); err != nil {
+		return nil, err
+	}
+	if conv.WidgetSessionID != sess.ID || conv.StudentId != "" {
+		return nil, ErrConversationNotFound
+	}
+	conv.ID = doc.Ref.ID
+	return &conv, nil
+}
+
+func (s *WidgetService) Reply(ctx context.Context, sess *model.WidgetSession, convID, text string) (*model.Message, error) {
+	if err := validateWidgetText(text); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetConversation(ctx, sess, convID); err != nil {
+		return nil, err
+	}
+	return s.complaints.Reply(ctx, convID, ComplaintInput{Text: text, Channel: model.ChannelWidget})
+}
+
+// Link menautkan sesi widget dan seluruh tiketnya ke akun mahasiswa. Memanggil ulang dengan
+// akun yang sama aman (idempoten); sesi yang sudah ditautkan ke akun lain ditolak.
+// Mengembalikan jumlah tiket yang dipindahkan ke akun.
+func (s *WidgetService) Link(ctx context.Context, token, studentID string) (int, error) {
+	if !s.Enabled() {
+		return 0, ErrWidgetDisabled
+	}
+	id, err := s.verify(token)
+	if err != nil {
+		return 0, err
+	}
+
+	ref := s.firestore.Collection("widget_sessions").Doc(id)
+	err = s.firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrInvalidWidgetSession
+		}
+		if err != nil {
+			return err
+		}
+		linked, _ := doc.Data()["linked_student_id"].(string)
+		if linked != "" && linked != studentID {
+			return ErrWidgetSessionLinked
+		}
+		return tx.Update(ref, []firestore.Update{
+			{Path: "linked_student_id", Value: studentID},
+			{Path: "linked_at", Value: time.Now()},
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	user, err := s.firestore.Collection("users").Doc(studentID).Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	name, _ := user.Data()["name"].(string)
+	//field email diisi sendiri saat registrasi, hanya email terverifikasi yang menerima notifikasi
+	email, _ := user.Data()["verified_email"].(string)
+
+	//tiket yang sudah ditautkan sebelumnya tidak lagi cocok dengan filter student_id kosong
+	iter := s.firestore.Collection("conversations").
+		Where("widget_session_id", "==", id).
+		Where("student_id", "==", "").
+		Documents(ctx)
+	defer iter.Stop()
+	linked := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return linked, nil
+		}
+		if err != nil {
+			return linked, err
+		}
+		_, err = doc.Ref.Update(ctx, []firestore.Update{
+			{Path: "student_id", Value: studentID},
+			{Path: "student_name", Value: name},
+			{Path: "student_email", Value: email},
+		})
+		if err != nil {
+			return linked, err
+		}
+		linked++
+	}
+}
+
+func (s *WidgetService) getSession(ctx context.Context, id string) (*model.WidgetSession, error) {
+	doc, err := s.firestore.Collection("widget_sessions").Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrInvalidWidgetSession
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sess model.WidgetSession
+	if err := doc.DataTo(&sess); err != nil {
+		return nil, err
+	}
+	sess.ID = doc.Ref.ID
+	return &sess, nil
+}
+
+func (s *WidgetService) sign(id string, expires time.Time) string {
+	payload := id + "." + strconv.FormatInt(expires.Unix(), 10)
+	return widgetTokenPrefix + payload + "." + s.signature(payload)
+}
+
+func (s *WidgetService) signature(payload string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.SessionSecret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify memeriksa tanda tangan dan masa berlaku token, mengembalikan ID sesi
+func (s *WidgetService) verify(token string) (string, error) {
+	rest, ok := strings.CutPrefix(token, widgetTokenPrefix)
+	if !ok {
+		return "", ErrInvalidWidgetSession
+	}
+	i := strings.LastIndex(rest, ".")
+	if i < 0 {
+		return "", ErrInvalidWidgetSession
+	}
+	payload, sig := rest[:i], rest[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.signature(payload))) {
+		return "", ErrInvalidWidgetSession
+	}
+	id, exp, ok := strings.Cut(payload, ".")
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if !ok || id == "" || err != nil || time.Now().Unix() > expUnix {
+		return "", ErrInvalidWidgetSession
+	}
+	return id, nil
+}
+
+func validateWidgetText(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("%w: text wajib diisi", ErrWidgetRequest)
+	}
+	if len([]rune(text)) > widgetMaxText {
+		return fmt.Errorf("%w: text maksimal %d karakter", ErrWidgetRequest, widgetMaxText)
+	}
+	return nil
+}
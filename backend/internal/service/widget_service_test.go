@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BimoAtaullahR/ai-customer-support/internal/config"
+)
+
+func TestWidgetToken(t *testing.T) {
+	svc := NewWidgetService(nil, nil, &config.WidgetConfig{SessionSecret: "rahasia-widget"})
+	other := NewWidgetService(nil, nil, &config.WidgetConfig{SessionSecret: "rahasia-lain"})
+	valid := svc.sign("sess123", time.Now().Add(time.Hour))
+	//format: wgt_<id>.<expires>.<signature>
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name   string
+		token  string
+		wantID string
+	}{
+		{"token valid", valid, "sess123"},
+		{"kedaluwarsa", svc.sign("sess123", time.Now().Add(-time.Minute)), ""},
+		{"secret berbeda", other.sign("sess123", time.Now().Add(time.Hour)), ""},
+		{"ID diganti", strings.Replace(valid, "sess123", "sess999", 1), ""},
+		{"masa berlaku diperpanjang", parts[0] + ".9" + parts[1] + "." + parts[2], ""},
+		{"tanda tangan dipotong", valid[:len(valid)-2], ""},
+		{"tanpa prefix", strings.TrimPrefix(valid, widgetTokenPrefix), ""},
+		{"tanpa tanda tangan", parts[0] + "." + parts[1], ""},
+		{"kosong", "", ""},
+	}
+	for _, tt := range tests {
+		id, err := svc.verify(tt.token)
+		if tt.wantID != "" {
+			if err != nil || id != tt.wantID {
+				t.Errorf("%s: verify = %q, %v, ingin %q", tt.name, id, err, tt.wantID)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidWidgetSession) {
+			t.Errorf("%s: verify = %q, %v, ingin ErrInvalidWidgetSession", tt.name, id, err)
+		}
+	}
+}
+
+func TestValidateWidgetText(t *testing.T) {
+	tests := []struct {
+		text string
+		ok   bool
+	}{
+		{"Halo, saya butuh bantuan", true},
+		{"   ", false},
+		{strings.Repeat("é", 4000), true},
+		{strings.Repeat("a", 4001), false},
+	}
+	for _, tt := range tests {
+		if err := validateWidgetText(tt.text); (err == nil) != tt.ok {
+			t.Errorf("validateWidgetText(%d karakter) = %v, ingin ok %v", len([]rune(tt.text)), err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FakeProvider adalah provider deterministik berbasis kata kunci tanpa panggilan jaringan.
+// Dipakai untuk evaluasi offline di CI dan pengembangan lokal tanpa GEMINI_API_KEY.
+type FakeProvider struct {
+	Delay  time.Duration // simulasi latensi
+	Vision bool          // "fake:vision", pura-pura membaca gambar di Request.Images
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (f *FakeProvider) Name() string {
+	if f.Vision {
+		return "fake:vision"
+	}
+	return "fake"
+}
+
+func (f *FakeProvider) SupportsVision() bool {
+	return f.Vision
+}
+
+var fakeCategories = []struct {
+	category string
+	priority int
+	keywords []string
+}{
+	{"Keuangan", 7, []string{"bayar", "ukt", "tagihan", "pembayaran", "transfer", "refund", "beasiswa", "payment", "invoice"}},
+	{"Akademik", 6, []string{"nilai", "kuis", "ujian", "tugas", "krs", "khs", "dosen", "skripsi", "quiz", "grade", "exam", "assignment"}},
+	{"Teknis", 7, []string{"login", "error", "aplikasi", "crash", "server", "lms", "password", "akun", "website", "bug"}},
+	{"Fasilitas", 4, []string{"wifi", "ruang", "kelas", "toilet", "parkir", "perpustakaan", "lab", "proyektor"}},
+}
+
+var (
+	fakeUrgent    = []string{"deadline", "hari ini", "besok", "menit", "segera", "urgent", "darurat"}
+	fakeNegative  = []string{"kecewa", "marah", "tidak bisa", "gak bisa", "gagal", "parah", "lambat", "rusak", "hilang", "error"}
+	fakePositive  = []string{"terima kasih", "makasih", "bagus", "puas", "thanks", "mantap"}
+	fakeInputRe   = regexp.MustCompile(`(?s)Input:\s*(\[.*\])\s*$`)
+	fakeTonesRe   = regexp.MustCompile(`one for each tone in this order: ([^\n]+)\.\n`)
+	fakeCommentRe = regexp.MustCompile(`(?m)^Comment: (.*)$`)
+	fakeThemes    = map[string]string{"lambat": "respons lambat", "cepat": "respons cepat", "jelas": "solusi jelas", "ramah": "agent ramah", "belum": "masalah belum selesai"}
+)
+
+func (f *FakeProvider) Generate(ctx context.Context, req Request) (*Response, error) {
+	if f.Delay > 0 {
+		if err := sleepContext(ctx, f.Delay); err != nil {
+			return nil, err
+		}
+	}
+
+	var text string
+	switch {
+	case strings.HasPrefix(req.Prompt, "Translate"):
+		text = fakeTranslate(req.Prompt)
+	case strings.HasPrefix(req.Prompt, "Classify the sentiment"):
+		text = fakeCSAT(req.Prompt)
+	case strings.Contains(req.Prompt, `"request_info"`):
+		text = fakeActions(req.Prompt)
+	case strings.Contains(req.Prompt, `"tone"`):
+		text = fakeSuggestions(req.Prompt)
+	default:
+		text = fakeAnalysis(req.Prompt, len(req.Images))
+	}
+
+	tokens := int64(len(strings.Fields(req.Prompt)))
+	return &Response{
+		Text:  text,
+		Usage: Usage{PromptTokens: tokens, CandidateTokens: int64(len(strings.Fields(text))), TotalTokens: tokens + int64(len(strings.Fields(text)))},
+		Model: f.Name(),
+	}, nil
+}
+
+// fakeSuggestions membuat satu draf per tone yang diminta prompt ("in this order: A, B."),
+// default Formal & Empathetic
+func fakeSuggestions(prompt string) string {
+	tones := []string{"Formal", "Empathetic"}
+	if m := fakeTonesRe.FindStringSubmatch(prompt); m != nil {
+		tones = strings.Split(m[1], ", ")
+	}
+
+	var suggestions []map[string]string
+	for _, tone := range tones {
+		suggestions = append(suggestions, map[string]string{
+			"tone":    tone,
+			"content": "Terima kasih atas laporan Anda, akan segera kami tindak lanjuti (" + strings.ToLower(tone) + ").",
+		})
+	}
+	out, _ := json.Marshal(suggestions)
+	return string(out)
+}
+
+// fakeActions merekomendasikan aksi berdasarkan kata kunci percakapan
+func fakeActions(prompt string) string {
+	lower := strings.ToLower(prompt)
+	actions := []map[string]interface{}{{
+		"type":       "request_info",
+		"label":      "Minta NIM mahasiswa",
+		"reason":     "NIM diperlukan untuk memeriksa data mahasiswa (fake provider).",
+		"confidence": 0.6,
+		"text":       "Mohon kirimkan NIM Anda agar kami dapat memeriksa data Anda.",
+	}}
+	if countContains(lower, []string{"bayar", "ukt", "tagihan", "keuangan"}) > 0 {
+		actions = append([]map[string]interface{}{{
+			"type":       "assign",
+			"label":      "Eskalasi ke tim keuangan",
+			"reason":     "Keluhan terkait pembayaran (fake provider).",
+			"confidence": 0.8,
+			"team":       "finance",
+		}}, actions...)
+	}
+	if countContains(lower, []string{"terima kasih", "sudah beres", "sudah bisa"}) > 0 {
+		actions = append(actions, map[string]interface{}{
+			"type":       "set_status",
+			"label":      "Tutup tiket",
+			"reason":     "Mahasiswa menyatakan masalah selesai (fake provider).",
+			"confidence": 0.7,
+			"status":     "resolved",
+		})
+	}
+	out, _ := json.Marshal(actions)
+	return string(out)
+}
+
+func fakeAnalysis(prompt string, images int) string {
+	//abaikan baris instruksi ("- category (string): ...") agar contoh kategori di prompt tidak ikut terhitung
+	var content strings.Builder
+	for _, line := range strings.Split(prompt, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "- ") {
+			content.WriteString(strings.ToLower(line))
+			content.WriteString("\n")
+		}
+	}
+	lower := content.String()
+
+	category, priority, bestHits := "Lainnya", 3, 0
+	for _, c := range fakeCategories {
+		hits := countContains(lower, c.keywords)
+		if hits > bestHits {
+			category, priority, bestHits = c.category, c.priority, hits
+		}
+	}
+	if countContains(lower, fakeUrgent) > 0 {
+		priority += 2
+	}
+	if priority > 10 {
+		priority = 10
+	}
+
+	sentiment := "netral"
+	switch {
+	case countContains(lower, fakeNegative) > 0:
+		sentiment = "negatif"
+	case countContains(lower, fakePositive) > 0:
+		sentiment = "positif"
+	}
+
+	result := map[string]interface{}{
+		"summary":        "Keluhan " + strings.ToLower(category) + " dari mahasiswa.",
+		"category":       category,
+		"priority_score": priority,
+		"reason":         "Klasifikasi berbasis kata kunci (fake provider).",
+		"sentiment":      sentiment,
+		"moderation":     "none",
+	}
+	//screenshot tanpa teks yang jelas dianggap laporan error teknis
+	if images > 0 {
+		if bestHits == 0 {
+			result["category"], result["priority_score"] = "Teknis", 7
+			result["summary"] = "Keluhan teknis dari mahasiswa berdasarkan screenshot."
+		}
+		result["image_findings"] = fmt.Sprintf("%d screenshot berisi pesan error (fake provider).", images)
+	}
+	out, _ := json.Marshal(result)
+	return string(out)
+}
+
+// terjemahan palsu: kembalikan input apa adanya dengan jumlah item yang sama
+func fakeTranslate(prompt string) string {
+	m := fakeInputRe.FindStringSubmatch(prompt)
+	if m == nil {
+		return "[]"
+	}
+	var texts []string
+	if err := json.Unmarshal([]byte(m[1]), &texts); err != nil {
+		return "[]"
+	}
+	out, _ := json.Marshal(texts)
+	return string(out)
+}
+
+// fakeCSAT menilai sentimen komentar survei dengan kata kunci yang sama seperti analisis
+func fakeCSAT(prompt string) string {
+	comment := ""
+	if m := fakeCommentRe.FindStringSubmatch(prompt); m != nil {
+		comment = strings.ToLower(m[1])
+	}
+	sentiment := "netral"
+	switch {
+	case countContains(comment, fakeNegative) > 0:
+		sentiment = "negatif"
+	case countContains(comment, fakePositive) > 0:
+		sentiment = "positif"
+	}
+	themes := []string{}
+	for word, theme := range fakeThemes {
+		if strings.Contains(comment, word) {
+			themes = append(themes, theme)
+		}
+	}
+	sort.Strings(themes)
+	themes = themes[:min(len(themes), 3)]
+	out, _ := json.Marshal(map[string]interface{}{"sentiment": sentiment, "themes": themes})
+	return string(out)
+}
+
+func countContains(text string, words []string) int {
+	n := 0
+	for _, w := range words {
+		if strings.Contains(text, w) {
+			n++
+		}
+	}
+	return n
+}
+
+// GenerateStream mengirim hasil Generate dalam potongan kecil untuk meniru streaming
+func (f *FakeProvider) GenerateStream(ctx context.Context, req Request, fn StreamFunc) (*Response, error) {
+	resp, err := f.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	const chunkSize = 16
+	text := []rune(resp.Text)
+	for start := 0; start < len(text); start += chunkSize {
+		end := min(start+chunkSize, len(text))
+		if err := fn(string(text[start:end])); err != nil {
+			return nil, err
+		}
+		if f.Delay > 0 {
+			if err := sleepContext(ctx, f.Delay/10); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resp, nil
+}
@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
 type GeminiClient struct {
-	Client *genai.Client
-	Model  *genai.GenerativeModel
+	Client    *genai.Client
+	Model     *genai.GenerativeModel
+	ModelName string
 }
 
 // menghubungkan backend dengan API Gemini
@@ -28,11 +31,108 @@ func InitGemini(ctx context.Context) (*GeminiClient, error) {
 		return nil, err
 	}
 
-	model := client.GenerativeModel("gemini-2.5-flash")
+	modelName := "gemini-2.5-flash"
+	model := client.GenerativeModel(modelName)
 	log.Println("Berhasil terhubung ke Gemini API")
 
 	return &GeminiClient{
-		Client: client,
-		Model:  model,
+		Client:    client,
+		Model:     model,
+		ModelName: modelName,
 	}, nil
 }
+
+// WithModel memakai koneksi yang sama untuk model Gemini lain (misal sebagai fallback)
+func (g *GeminiClient) WithModel(modelName string) *GeminiClient {
+	return &GeminiClient{
+		Client:    g.Client,
+		Model:     g.Client.GenerativeModel(modelName),
+		ModelName: modelName,
+	}
+}
+
+func (g *GeminiClient) Name() string {
+	return "gemini:" + g.ModelName
+}
+
+// semua model Gemini yang dipakai (2.x flash/pro) menerima input gambar
+func (g *GeminiClient) SupportsVision() bool {
+	return true
+}
+
+func (g *GeminiClient) Generate(ctx context.Context, req Request) (*Response, error) {
+	resp, err := g.model(req).GenerateContent(ctx, parts(req)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("gemini tidak memberikan respon")
+	}
+
+	return &Response{
+		Text:  fmt.Sprint(resp.Candidates[0].Content.Parts[0]),
+		Usage: UsageFromGemini(resp.UsageMetadata),
+		Model: g.ModelName,
+	}, nil
+}
+
+// GenerateStream memakai streaming API Gemini, fn dipanggil untuk setiap potongan teks
+func (g *GeminiClient) GenerateStream(ctx context.Context, req Request, fn StreamFunc) (*Response, error) {
+	iter := g.model(req).GenerateContentStream(ctx, parts(req)...)
+
+	var text strings.Builder
+	var usage Usage
+	for {
+		resp, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if resp.UsageMetadata != nil {
+			usage = UsageFromGemini(resp.UsageMetadata)
+		}
+		if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+			continue
+		}
+		for _, part := range resp.Candidates[0].Content.Parts {
+			chunk, ok := part.(genai.Text)
+			if !ok || chunk == "" {
+				continue
+			}
+			text.WriteString(string(chunk))
+			if err := fn(string(chunk)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("gemini tidak memberikan respon")
+	}
+	return &Response{Text: text.String(), Usage: usage, Model: g.ModelName}, nil
+}
+
+// model membuat instance model baru per pemanggilan supaya konfigurasi
+// (MIME type, temperature) tidak saling menimpa antar request yang berjalan bersamaan
+func (g *GeminiClient) model(req Request) *genai.GenerativeModel {
+	model := g.Client.GenerativeModel(g.ModelName)
+	if req.JSON {
+		model.ResponseMIMEType = "application/json"
+	}
+	if req.Temperature != nil {
+		model.SetTemperature(*req.Temperature)
+	}
+	return model
+}
+
+// parts menyusun prompt teks diikuti gambar (inline data) dari request
+func parts(req Request) []genai.Part {
+	parts := []genai.Part{genai.Text(req.Prompt)}
+	for _, img := range req.Images {
+		parts = append(parts, genai.Blob{MIMEType: img.MIMEType, Data: img.Data})
+	}
+	return parts
+}
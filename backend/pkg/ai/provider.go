@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Request adalah satu pemanggilan model teks
+type Request struct {
+	Prompt      string
+	JSON        bool     // minta output application/json
+	Temperature *float32 // nil = default model
+
+	//gambar yang dikirim bersama prompt, hanya untuk provider dengan dukungan vision.
+	//provider tanpa vision menerima TextPrompt (jika diisi) tanpa gambar.
+	Images     []Image
+	TextPrompt string
+}
+
+type Response struct {
+	Text  string
+	Usage Usage
+	Model string // model yang benar-benar menjawab (bisa fallback)
+}
+
+// Provider adalah backend LLM yang bisa dipakai AIService
+type Provider interface {
+	Name() string
+	Generate(ctx context.Context, req Request) (*Response, error)
+}
+
+// NewProviderChain membuat rantai provider dari spesifikasi "jenis:model", misal
+// "gemini:gemini-2.5-flash". Provider pertama dipakai lebih dulu, sisanya sebagai fallback.
+func NewProviderChain(ctx context.Context, specs []string, rc ResilienceConfig) (*Chain, error) {
+	if len(specs) == 0 {
+		specs = []string{"gemini:gemini-2.5-flash"}
+	}
+
+	var gemini *GeminiClient
+	var providers []Provider
+	for _, spec := range specs {
+		kind, modelName, ok := strings.Cut(spec, ":")
+		if !ok && spec != "fake" {
+			//tanpa prefix dianggap model gemini
+			kind, modelName = "gemini", spec
+		}
+
+		switch kind {
+		case "gemini":
+			if gemini == nil {
+				client, err := InitGemini(ctx)
+				if err != nil {
+					return nil, err
+				}
+				gemini = client
+			}
+			providers = append(providers, gemini.WithModel(modelName))
+		case "fake":
+			fake := NewFakeProvider()
+			fake.Vision = modelName == "vision"
+			providers = append(providers, fake)
+		default:
+			return nil, fmt.Errorf("provider AI tidak dikenal: %s", kind)
+		}
+	}
+
+	return NewChain(rc, providers...), nil
+}
@@ -0,0 +1,251 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrUnavailable dikembalikan saat semua provider gagal karena gangguan sementara
+	ErrUnavailable = errors.New("layanan AI sedang tidak tersedia")
+	ErrCircuitOpen = errors.New("circuit breaker terbuka")
+)
+
+type ResilienceConfig struct {
+	Timeout          time.Duration // batas waktu per pemanggilan provider
+	MaxRetries       int           // percobaan ulang per provider untuk error sementara
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	BreakerThreshold int           // jumlah kegagalan beruntun sebelum breaker terbuka
+	BreakerCooldown  time.Duration // lama breaker terbuka sebelum dicoba lagi (half-open)
+}
+
+// IsRetryable menentukan apakah error dari provider bersifat sementara
+// (kuota, overload, timeout) sehingga layak dicoba ulang atau dialihkan ke fallback
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= 500
+	}
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Internal, codes.Aborted:
+			return true
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// CircuitBreaker sederhana: closed -> open setelah N kegagalan beruntun,
+// lalu half-open setelah cooldown untuk satu pemanggilan percobaan
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+	//breaker terbuka: izinkan satu pemanggilan percobaan setelah cooldown
+	if time.Since(b.openedAt) >= b.cooldown && !b.probing {
+		b.probing = true
+		return true
+	}
+	return false
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.threshold <= 0 || b.failures < b.threshold:
+		return "closed"
+	case time.Since(b.openedAt) >= b.cooldown:
+		return "half_open"
+	default:
+		return "open"
+	}
+}
+
+// ProviderStatus dipakai untuk memantau kondisi setiap provider di rantai fallback
+type ProviderStatus struct {
+	Name    string `json:"name"`
+	Breaker string `json:"breaker"`
+}
+
+type guardedProvider struct {
+	provider Provider
+	breaker  *CircuitBreaker
+}
+
+// Chain membungkus provider dengan deadline per pemanggilan, retry ber-jitter,
+// circuit breaker per provider, dan fallback ke provider berikutnya
+type Chain struct {
+	cfg       ResilienceConfig
+	providers []guardedProvider
+}
+
+func NewChain(cfg ResilienceConfig, providers ...Provider) *Chain {
+	c := &Chain{cfg: cfg}
+	for _, p := range providers {
+		c.providers = append(c.providers, guardedProvider{
+			provider: p,
+			breaker:  NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		})
+	}
+	return c
+}
+
+func (c *Chain) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Providers mengembalikan provider asli di dalam rantai, urut sesuai prioritas
+func (c *Chain) Providers() []Provider {
+	providers := make([]Provider, len(c.providers))
+	for i, p := range c.providers {
+		providers[i] = p.provider
+	}
+	return providers
+}
+
+func (c *Chain) Status() []ProviderStatus {
+	statuses := make([]ProviderStatus, len(c.providers))
+	for i, p := range c.providers {
+		statuses[i] = ProviderStatus{Name: p.provider.Name(), Breaker: p.breaker.State()}
+	}
+	return statuses
+}
+
+func (c *Chain) Generate(ctx context.Context, req Request) (*Response, error) {
+	var errs []error
+	for _, p := range c.providers {
+		resp, err := c.generateWithRetry(ctx, p, req)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.provider.Name(), err))
+
+		//error permanen (misal prompt tidak valid) tidak akan berhasil di provider lain
+		if !IsRetryable(err) || ctx.Err() != nil {
+			return nil, errors.Join(errs...)
+		}
+	}
+	return nil, fmt.Errorf("%w: %w", ErrUnavailable, errors.Join(errs...))
+}
+
+func (c *Chain) generateWithRetry(ctx context.Context, p guardedProvider, req Request) (*Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if !p.breaker.Allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := c.call(ctx, p.provider, req)
+		if err == nil {
+			p.breaker.Success()
+			return resp, nil
+		}
+		lastErr = err
+
+		if !IsRetryable(err) || ctx.Err() != nil {
+			//kesalahan request bukan tanda provider sedang bermasalah
+			p.breaker.Success()
+			return nil, err
+		}
+		p.breaker.Failure()
+	}
+	return nil, lastErr
+}
+
+func (c *Chain) call(ctx context.Context, p Provider, req Request) (*Response, error) {
+	req = textOnly(p, req)
+	if c.cfg.Timeout <= 0 {
+		return p.Generate(ctx, req)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	return p.Generate(callCtx, req)
+}
+
+// full jitter: acak antara 0 dan min(MaxBackoff, BaseBackoff * 2^attempt)
+func (c *Chain) backoff(attempt int) time.Duration {
+	ceiling := c.cfg.BaseBackoff << attempt
+	if ceiling <= 0 || (c.cfg.MaxBackoff > 0 && ceiling > c.cfg.MaxBackoff) {
+		ceiling = c.cfg.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
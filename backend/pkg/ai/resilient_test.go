@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"dibatalkan", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, true},
+		{"deadline terbungkus", fmt.Errorf("gemini: %w", context.DeadlineExceeded), true},
+		{"breaker terbuka", ErrCircuitOpen, true},
+		{"kuota HTTP", &googleapi.Error{Code: http.StatusTooManyRequests}, true},
+		{"server HTTP", &googleapi.Error{Code: http.StatusServiceUnavailable}, true},
+		{"request HTTP salah", &googleapi.Error{Code: http.StatusBadRequest}, false},
+		{"gRPC unavailable", status.Error(codes.Unavailable, "overload"), true},
+		{"gRPC resource exhausted", status.Error(codes.ResourceExhausted, "kuota"), true},
+		{"gRPC invalid argument", status.Error(codes.InvalidArgument, "prompt"), false},
+		{"timeout jaringan", timeoutError{}, true},
+		{"error biasa", errors.New("json rusak"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetryable = %v, ingin %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	b := NewCircuitBreaker(2, cooldown)
+
+	steps := []struct {
+		name  string
+		do    func()
+		allow bool
+		state string
+	}{
+		{"awal", func() {}, true, "closed"},
+		{"satu kegagalan", b.Failure, true, "closed"},
+		{"ambang tercapai", b.Failure, false, "open"},
+		{"setelah cooldown", func() { time.Sleep(cooldown) }, true, "half_open"},
+		{"percobaan gagal", b.Failure, false, "open"},
+		{"cooldown lagi", func() { time.Sleep(cooldown) }, true, "half_open"},
+		{"percobaan berhasil", b.Success, true, "closed"},
+	}
+	for _, s := range steps {
+		s.do()
+		if got := b.State(); got != s.state {
+			t.Fatalf("%s: State = %s, ingin %s", s.name, got, s.state)
+		}
+		if got := b.Allow(); got != s.allow {
+			t.Fatalf("%s: Allow = %v, ingin %v", s.name, got, s.allow)
+		}
+		if s.state == "half_open" && b.Allow() {
+			t.Fatalf("%s: half-open hanya boleh mengizinkan satu percobaan", s.name)
+		}
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := NewCircuitBreaker(0, time.Hour)
+	for i := 0; i < 10; i++ {
+		b.Failure()
+	}
+	if !b.Allow() || b.State() != "closed" {
+		t.Error("breaker dengan threshold 0 tidak boleh terbuka")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ResilienceConfig
+		attempt int
+		ceiling time.Duration
+	}{
+		{"percobaan pertama", ResilienceConfig{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}, 1, 200 * time.Millisecond},
+		{"eksponensial", ResilienceConfig{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}, 3, 800 * time.Millisecond},
+		{"dibatasi MaxBackoff", ResilienceConfig{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}, 6, time.Second},
+		{"overflow dibatasi", ResilienceConfig{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}, 70, 5 * time.Second},
+		{"tanpa backoff", ResilienceConfig{}, 2, 0},
+	}
+	for _, tt := range tests {
+		c := NewChain(tt.cfg)
+		for i := 0; i < 100; i++ {
+			got := c.backoff(tt.attempt)
+			if got < 0 || (tt.ceiling == 0 && got != 0) || (tt.ceiling > 0 && got >= tt.ceiling) {
+				t.Fatalf("%s: backoff(%d) = %v, di luar [0, %v)", tt.name, tt.attempt, got, tt.ceiling)
+			}
+		}
+	}
+}
+
+type stubProvider struct {
+	name  string
+	errs  []error // error per pemanggilan, nil = berhasil
+	calls int
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Generate(ctx context.Context, req Request) (*Response, error) {
+	p.calls++
+	if p.calls <= len(p.errs) && p.errs[p.calls-1] != nil {
+		return nil, p.errs[p.calls-1]
+	}
+	return &Response{Text: "ok", Model: p.name}, nil
+}
+
+func TestChainGenerate(t *testing.T) {
+	overload := status.Error(codes.Unavailable, "overload")
+	invalid := status.Error(codes.InvalidArgument, "prompt")
+
+	tests := []struct {
+		name      string
+		primary   []error
+		fallback  []error
+		wantModel string
+		wantErr   error
+		wantCalls [2]int
+	}{
+		{"primer berhasil", nil, nil, "primer", nil, [2]int{1, 0}},
+		{"retry lalu berhasil", []error{overload}, nil, "primer", nil, [2]int{2, 0}},
+		{"fallback setelah retry habis", []error{overload, overload}, nil, "cadangan", nil, [2]int{2, 1}},
+		{"error permanen tidak di-fallback", []error{invalid}, nil, "", invalid, [2]int{1, 0}},
+		{"semua gagal", []error{overload, overload}, []error{overload, overload}, "", ErrUnavailable, [2]int{2, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &stubProvider{name: "primer", errs: tt.primary}
+			fallback := &stubProvider{name: "cadangan", errs: tt.fallback}
+			c := NewChain(ResilienceConfig{MaxRetries: 1, BreakerThreshold: 5}, primary, fallback)
+
+			resp, err := c.Generate(context.Background(), Request{Prompt: "halo"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, ingin %v", err, tt.wantErr)
+				}
+			} else if err != nil || resp.Model != tt.wantModel {
+				t.Fatalf("resp = %+v, err = %v, ingin model %s", resp, err, tt.wantModel)
+			}
+			if got := [2]int{primary.calls, fallback.calls}; got != tt.wantCalls {
+				t.Errorf("jumlah pemanggilan = %v, ingin %v", got, tt.wantCalls)
+			}
+		})
+	}
+}
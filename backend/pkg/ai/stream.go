@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// StreamFunc dipanggil untuk setiap potongan teks yang diterima dari model.
+// Mengembalikan error menghentikan streaming.
+type StreamFunc func(chunk string) error
+
+// StreamProvider adalah provider yang bisa mengirim hasil secara bertahap
+type StreamProvider interface {
+	Provider
+	GenerateStream(ctx context.Context, req Request, fn StreamFunc) (*Response, error)
+}
+
+// GenerateStream memanggil provider yang mendukung streaming, atau Generate biasa
+// (seluruh teks dikirim sebagai satu potongan) jika provider tidak mendukungnya.
+func GenerateStream(ctx context.Context, p Provider, req Request, fn StreamFunc) (*Response, error) {
+	if sp, ok := p.(StreamProvider); ok {
+		return sp.GenerateStream(ctx, req, fn)
+	}
+	resp, err := p.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(resp.Text); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// errStreamStarted menandai kegagalan setelah sebagian teks terkirim ke klien,
+// pada titik ini retry/fallback tidak lagi aman karena teks akan terduplikasi
+type errStreamStarted struct{ err error }
+
+func (e errStreamStarted) Error() string { return e.err.Error() }
+func (e errStreamStarted) Unwrap() error { return e.err }
+
+// GenerateStream pada Chain memakai retry dan fallback yang sama dengan Generate,
+// tetapi hanya selama belum ada potongan teks yang dikirim.
+func (c *Chain) GenerateStream(ctx context.Context, req Request, fn StreamFunc) (*Response, error) {
+	var errs []error
+	for _, p := range c.providers {
+		resp, err := c.streamWithRetry(ctx, p, req, fn)
+		if err == nil {
+			return resp, nil
+		}
+		var started errStreamStarted
+		if errors.As(err, &started) {
+			return nil, started.err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.provider.Name(), err))
+
+		if !IsRetryable(err) || ctx.Err() != nil {
+			return nil, errors.Join(errs...)
+		}
+	}
+	return nil, fmt.Errorf("%w: %w", ErrUnavailable, errors.Join(errs...))
+}
+
+func (c *Chain) streamWithRetry(ctx context.Context, p guardedProvider, req Request, fn StreamFunc) (*Response, error) {
+	started := false
+	track := func(chunk string) error {
+		started = true
+		return fn(chunk)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if !p.breaker.Allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := c.stream(ctx, p.provider, req, track)
+		if err == nil {
+			p.breaker.Success()
+			return resp, nil
+		}
+		lastErr = err
+
+		if !IsRetryable(err) || ctx.Err() != nil {
+			p.breaker.Success()
+		} else {
+			p.breaker.Failure()
+		}
+		if started {
+			return nil, errStreamStarted{err}
+		}
+		if !IsRetryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+func (c *Chain) stream(ctx context.Context, p Provider, req Request, fn StreamFunc) (*Response, error) {
+	req = textOnly(p, req)
+	if c.cfg.Timeout <= 0 {
+		return GenerateStream(ctx, p, req, fn)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	return GenerateStream(callCtx, p, req, fn)
+}
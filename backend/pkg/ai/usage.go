@@ -0,0 +1,21 @@
+package ai
+
+import "github.com/google/generative-ai-go/genai"
+
+// Usage adalah jumlah token yang dipakai satu pemanggilan model
+type Usage struct {
+	PromptTokens    int64
+	CandidateTokens int64
+	TotalTokens     int64
+}
+
+func UsageFromGemini(m *genai.UsageMetadata) Usage {
+	if m == nil {
+		return Usage{}
+	}
+	return Usage{
+		PromptTokens:    int64(m.PromptTokenCount),
+		CandidateTokens: int64(m.CandidatesTokenCount),
+		TotalTokens:     int64(m.TotalTokenCount),
+	}
+}
@@ -0,0 +1,42 @@
+package ai
+
+// Image adalah gambar mentah yang disertakan ke model, misal screenshot dari lampiran
+type Image struct {
+	MIMEType string
+	Data     []byte
+}
+
+// VisionProvider adalah provider yang bisa membaca gambar di Request.Images
+type VisionProvider interface {
+	Provider
+	SupportsVision() bool
+}
+
+// SupportsVision mengecek apakah provider bisa menerima gambar
+func SupportsVision(p Provider) bool {
+	vp, ok := p.(VisionProvider)
+	return ok && vp.SupportsVision()
+}
+
+// textOnly menurunkan request bergambar menjadi request teks untuk provider tanpa vision
+func textOnly(p Provider, req Request) Request {
+	if len(req.Images) == 0 || SupportsVision(p) {
+		return req
+	}
+	req.Images = nil
+	if req.TextPrompt != "" {
+		req.Prompt = req.TextPrompt
+	}
+	return req
+}
+
+// SupportsVision pada Chain bernilai true jika minimal satu provider mendukung gambar,
+// provider lain di rantai fallback otomatis menerima versi teks saja
+func (c *Chain) SupportsVision() bool {
+	for _, p := range c.providers {
+		if SupportsVision(p.provider) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Stats adalah statistik cache per namespace (misal "suggestions", "analysis")
+type Stats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Shared  int64 `json:"shared"` // request yang ikut menunggu pemanggilan identik yang sedang berjalan
+	Errors  int64 `json:"errors"`
+	Entries int   `json:"entries"`
+}
+
+type entry struct {
+	namespace string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// Cache menyimpan hasil pemanggilan LLM di memori dengan TTL, dan menggabungkan
+// request identik yang datang bersamaan menjadi satu pemanggilan (single-flight).
+type Cache struct {
+	mu         sync.Mutex
+	items      map[string]entry
+	stats      map[string]*Stats
+	ttl        time.Duration
+	maxEntries int
+	group      singleflight.Group
+}
+
+func New(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		items:      make(map[string]entry),
+		stats:      make(map[string]*Stats),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// GetOrLoad mengembalikan nilai dari cache, atau memanggil load jika belum ada.
+// load dijalankan dengan context yang tidak ikut dibatalkan oleh request pertama,
+// supaya request lain yang ikut menunggu tetap mendapat hasil.
+func (c *Cache) GetOrLoad(ctx context.Context, namespace, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	fullKey := namespace + ":" + key
+
+	c.mu.Lock()
+	if e, ok := c.items[fullKey]; ok && time.Now().Before(e.expiresAt) {
+		c.statsFor(namespace).Hits++
+		c.mu.Unlock()
+		return e.value, nil
+	}
+	c.statsFor(namespace).Misses++
+	c.mu.Unlock()
+
+	ch := c.group.DoChan(fullKey, func() (interface{}, error) {
+		value, err := load(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.set(namespace, fullKey, value)
+		return value, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		c.mu.Lock()
+		if res.Shared {
+			c.statsFor(namespace).Shared++
+		}
+		if res.Err != nil {
+			c.statsFor(namespace).Errors++
+		}
+		c.mu.Unlock()
+		return res.Val, res.Err
+	}
+}
+
+// Get mengembalikan nilai yang masih berlaku tanpa memanggil load, dipakai bersama Set
+// untuk hasil yang dibuat di luar GetOrLoad (misal hasil streaming)
+func (c *Cache) Get(namespace, key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[namespace+":"+key]
+	if !ok || time.Now().After(e.expiresAt) {
+		c.statsFor(namespace).Misses++
+		return nil, false
+	}
+	c.statsFor(namespace).Hits++
+	return e.value, true
+}
+
+func (c *Cache) Set(namespace, key string, value interface{}) {
+	c.set(namespace, namespace+":"+key, value)
+}
+
+func (c *Cache) Stats() map[string]Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make(map[string]int)
+	for _, e := range c.items {
+		entries[e.namespace]++
+	}
+
+	result := make(map[string]Stats, len(c.stats))
+	for ns, st := range c.stats {
+		snapshot := *st
+		snapshot.Entries = entries[ns]
+		result[ns] = snapshot
+	}
+	return result
+}
+
+func (c *Cache) set(namespace, key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.items) >= c.maxEntries {
+		//buang entry yang sudah kedaluwarsa dulu, jika masih penuh buang sembarang satu entry
+		for k, e := range c.items {
+			if now.After(e.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+		for k := range c.items {
+			if len(c.items) < c.maxEntries {
+				break
+			}
+			delete(c.items, k)
+		}
+	}
+
+	c.items[key] = entry{namespace: namespace, value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// dipanggil dengan mu terkunci
+func (c *Cache) statsFor(namespace string) *Stats {
+	st, ok := c.stats[namespace]
+	if !ok {
+		st = &Stats{}
+		c.stats[namespace] = st
+	}
+	return st
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	c := New(time.Minute, 100)
+	ctx := context.Background()
+	var calls int
+	load := func(value string, err error) func(context.Context) (interface{}, error) {
+		return func(context.Context) (interface{}, error) {
+			calls++
+			return value, err
+		}
+	}
+	failed := errors.New("provider gagal")
+
+	steps := []struct {
+		name      string
+		key       string
+		value     string
+		err       error
+		want      string
+		wantErr   error
+		wantCalls int
+	}{
+		{"miss memanggil load", "a", "satu", nil, "satu", nil, 1},
+		{"hit memakai cache", "a", "dua", nil, "satu", nil, 1},
+		{"key lain", "b", "tiga", nil, "tiga", nil, 2},
+		{"error tidak disimpan", "c", "", failed, "", failed, 3},
+		{"setelah error dicoba lagi", "c", "empat", nil, "empat", nil, 4},
+	}
+	for _, s := range steps {
+		got, err := c.GetOrLoad(ctx, "suggestions", s.key, load(s.value, s.err))
+		if !errors.Is(err, s.wantErr) || (s.wantErr == nil && got != s.want) || calls != s.wantCalls {
+			t.Fatalf("%s: GetOrLoad = %v, %v (load %d kali), ingin %q, %v (load %d kali)", s.name, got, err, calls, s.want, s.wantErr, s.wantCalls)
+		}
+	}
+
+	st := c.Stats()["suggestions"]
+	if st.Hits != 1 || st.Misses != 4 || st.Errors != 1 || st.Entries != 3 {
+		t.Errorf("Stats = %+v", st)
+	}
+}
+
+func TestGetOrLoadSingleFlight(t *testing.T) {
+	c := New(time.Minute, 100)
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.GetOrLoad(context.Background(), "analysis", "conv-1", func(context.Context) (interface{}, error) {
+				calls.Add(1)
+				<-release
+				return "hasil", nil
+			})
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("load dipanggil %d kali, ingin 1", calls.Load())
+	}
+	for i, r := range results {
+		if r != "hasil" {
+			t.Errorf("request %d mendapat %v", i, r)
+		}
+	}
+}
+
+func TestGetOrLoadCanceled(t *testing.T) {
+	c := New(time.Minute, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := c.GetOrLoad(ctx, "translation", "k", func(loadCtx context.Context) (interface{}, error) {
+		defer close(done)
+		<-ctx.Done()
+		if loadCtx.Err() != nil {
+			t.Error("context load ikut dibatalkan")
+		}
+		return "terjemahan", nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, ingin context.Canceled", err)
+	}
+
+	//hasil tetap disimpan untuk request berikutnya
+	<-done
+	time.Sleep(10 * time.Millisecond)
+	if v, ok := c.Get("translation", "k"); !ok || v != "terjemahan" {
+		t.Errorf("Get = %v, %v setelah request pertama dibatalkan", v, ok)
+	}
+}
+
+func TestExpiryAndEviction(t *testing.T) {
+	c := New(20*time.Millisecond, 2)
+	c.Set("ns", "a", 1)
+	c.Set("ns", "b", 2)
+	c.Set("ns", "c", 3)
+	if n := len(c.items); n != 2 {
+		t.Errorf("Entries = %d, ingin maksimal 2", n)
+	}
+	if v, ok := c.Get("ns", "c"); !ok || v != 3 {
+		t.Errorf("entry terbaru hilang: %v, %v", v, ok)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("ns", "c"); ok {
+		t.Error("entry kedaluwarsa masih dikembalikan")
+	}
+}
@@ -0,0 +1,38 @@
+// Package chat berisi adapter channel aplikasi chat (misal Telegram). Setiap adapter
+// mengurai webhook platform menjadi Inbound dan mengirim teks balasan ke chat.
+package chat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrUnauthorized dikembalikan jika request webhook tidak membawa secret yang benar
+	ErrUnauthorized = errors.New("webhook chat tidak terotorisasi")
+	ErrBadUpdate    = errors.New("update chat tidak valid")
+)
+
+// Inbound adalah pesan masuk dari aplikasi chat yang sudah dinormalisasi
+type Inbound struct {
+	UpdateID  string // unik per platform, dipakai untuk dedup retry webhook
+	ChatID    string // tujuan balasan
+	UserID    string // akun pengirim di platform
+	Username  string
+	Name      string
+	MessageID string
+	Text      string
+	Timestamp time.Time
+}
+
+// Channel adalah adapter satu platform chat
+type Channel interface {
+	Name() string
+	// ParseWebhook memverifikasi dan mengurai request webhook. Update yang bukan pesan
+	// (misal edit pesan atau status member) mengembalikan nil tanpa error.
+	ParseWebhook(r *http.Request) (*Inbound, error)
+	// Send mengirim teks ke chat dan mengembalikan ID pesan di platform
+	Send(ctx context.Context, chatID, text string) (string, error)
+}
@@ -0,0 +1,189 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Telegram = "telegram"
+
+	// batas panjang teks satu pesan Telegram, pesan lebih panjang dipecah
+	telegramMaxText = 4096
+	// header yang dikirim Telegram jika webhook didaftarkan dengan secret_token
+	telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+)
+
+// TelegramChannel memakai Telegram Bot API. BaseURL bisa diarahkan ke server palsu
+// (lihat FakeTelegram) untuk pengujian lokal.
+type TelegramChannel struct {
+	token   string
+	secret  string
+	baseURL string
+	client  *http.Client
+}
+
+func NewTelegramChannel(token, secret, baseURL string) *TelegramChannel {
+	if baseURL == "" {
+		baseURL = "https://api.telegram.org"
+	}
+	return &TelegramChannel{
+		token:   token,
+		secret:  secret,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *TelegramChannel) Name() string { return Telegram }
+
+// bagian Update Telegram yang dipakai
+type telegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	MessageID int64  `json:"message_id"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+	Caption   string `json:"caption"`
+	From      *struct {
+		ID        int64  `json:"id"`
+		IsBot     bool   `json:"is_bot"`
+		Username  string `json:"username"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	} `json:"from"`
+	Chat struct {
+		ID   int64  `json:"id"`
+		Type string `json:"type"`
+	} `json:"chat"`
+}
+
+func (t *TelegramChannel) ParseWebhook(r *http.Request) (*Inbound, error) {
+	//tanpa secret siapa pun bisa memalsukan update atas nama akun tertaut, jadi tolak semua
+	if t.secret == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(telegramSecretHeader)), []byte(t.secret)) != 1 {
+		return nil, ErrUnauthorized
+	}
+
+	var update telegramUpdate
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&update); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadUpdate, err)
+	}
+	msg := update.Message
+	//hanya pesan dari pengguna di chat pribadi yang diproses, bukan grup atau bot lain
+	if msg == nil || msg.From == nil || msg.From.IsBot || msg.Chat.Type != "private" {
+		return nil, nil
+	}
+
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+	return &Inbound{
+		UpdateID:  strconv.FormatInt(update.UpdateID, 10),
+		ChatID:    strconv.FormatInt(msg.Chat.ID, 10),
+		UserID:    strconv.FormatInt(msg.From.ID, 10),
+		Username:  msg.From.Username,
+		Name:      strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName),
+		MessageID: strconv.FormatInt(msg.MessageID, 10),
+		Text:      strings.TrimSpace(text),
+		Timestamp: time.Unix(msg.Date, 0),
+	}, nil
+}
+
+// Send mengirim teks sebagai satu atau beberapa pesan, ID yang dikembalikan adalah pesan pertama
+func (t *TelegramChannel) Send(ctx context.Context, chatID, text string) (string, error) {
+	var firstID string
+	for _, part := range splitText(text, telegramMaxText) {
+		var result struct {
+			MessageID int64 `json:"message_id"`
+		}
+		if err := t.call(ctx, "sendMessage", map[string]interface{}{"chat_id": chatID, "text": part}, &result); err != nil {
+			return firstID, err
+		}
+		if firstID == "" {
+			firstID = strconv.FormatInt(result.MessageID, 10)
+		}
+	}
+	return firstID, nil
+}
+
+// SetWebhook mendaftarkan URL webhook bot beserta secret yang dicek di ParseWebhook
+func (t *TelegramChannel) SetWebhook(ctx context.Context, url string) error {
+	params := map[string]interface{}{"url": url, "allowed_updates": []string{"message"}, "secret_token": t.secret}
+	return t.call(ctx, "setWebhook", params, nil)
+}
+
+func (t *TelegramChannel) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/bot"+t.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram %s: %w", method, stripURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram %s: %w", method, stripURL(err))
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return fmt.Errorf("telegram %s: status %d: %w", method, resp.StatusCode, err)
+	}
+	if !out.OK {
+		return fmt.Errorf("telegram %s: status %d: %s", method, resp.StatusCode, out.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(out.Result, result)
+	}
+	return nil
+}
+
+// stripURL membuang *url.Error yang pesannya memuat URL lengkap, termasuk token bot.
+// Error ini sampai ke log dan relay_error yang dikirim ke browser agent.
+func stripURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
+
+// splitText memecah teks panjang per batas rune, sebisa mungkin di baris baru
+func splitText(text string, max int) []string {
+	runes := []rune(text)
+	var parts []string
+	for len(runes) > max {
+		cut := max
+		for i := max; i > max/2; i-- {
+			if runes[i-1] == '\n' {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	return append(parts, string(runes))
+}
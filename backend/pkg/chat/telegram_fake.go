@@ -0,0 +1,135 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeMessage adalah pesan yang dikirim bot ke server palsu
+type FakeMessage struct {
+	MessageID int64     `json:"message_id"`
+	ChatID    string    `json:"chat_id"`
+	Text      string    `json:"text"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// FakeTelegram meniru sebagian Bot API (sendMessage, setWebhook) untuk pengujian lokal
+// tanpa bot sungguhan. Pasang sebagai http.Handler lalu arahkan TELEGRAM_API_URL ke sini.
+// Simulate mengirim pesan seolah-olah dari pengguna ke webhook yang terdaftar.
+type FakeTelegram struct {
+	mu         sync.Mutex
+	nextID     int64
+	nextUpdate int64
+	sent       []FakeMessage
+	webhookURL string
+	secret     string
+	client     *http.Client
+}
+
+func NewFakeTelegram() *FakeTelegram {
+	return &FakeTelegram{nextID: 1, nextUpdate: 1, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (f *FakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	//path: /bot<token>/<method>
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	_, method, ok := strings.Cut(path, "/")
+	if !ok || !strings.HasPrefix(path, "bot") {
+		fakeReply(w, http.StatusNotFound, false, "Not Found", nil)
+		return
+	}
+
+	var params map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		fakeReply(w, http.StatusBadRequest, false, "Bad Request: "+err.Error(), nil)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch method {
+	case "sendMessage":
+		text, _ := params["text"].(string)
+		if text == "" {
+			fakeReply(w, http.StatusBadRequest, false, "Bad Request: message text is empty", nil)
+			return
+		}
+		msg := FakeMessage{MessageID: f.nextID, ChatID: fmt.Sprint(params["chat_id"]), Text: text, SentAt: time.Now()}
+		f.nextID++
+		f.sent = append(f.sent, msg)
+		fakeReply(w, http.StatusOK, true, "", map[string]interface{}{"message_id": msg.MessageID, "date": msg.SentAt.Unix(), "text": text})
+	case "setWebhook":
+		f.webhookURL, _ = params["url"].(string)
+		f.secret, _ = params["secret_token"].(string)
+		fakeReply(w, http.StatusOK, true, "", true)
+	default:
+		fakeReply(w, http.StatusNotFound, false, "Not Found: method "+method, nil)
+	}
+}
+
+// Sent mengembalikan salinan pesan yang sudah dikirim bot
+func (f *FakeTelegram) Sent() []FakeMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeMessage(nil), f.sent...)
+}
+
+// Simulate mengirim Update pesan pribadi dari userID ke webhook yang didaftarkan lewat setWebhook
+func (f *FakeTelegram) Simulate(ctx context.Context, userID int64, username, text string) error {
+	f.mu.Lock()
+	url, secret := f.webhookURL, f.secret
+	update := map[string]interface{}{
+		"update_id": f.nextUpdate,
+		"message": map[string]interface{}{
+			"message_id": f.nextID,
+			"date":       time.Now().Unix(),
+			"text":       text,
+			"from":       map[string]interface{}{"id": userID, "is_bot": false, "username": username, "first_name": username},
+			"chat":       map[string]interface{}{"id": userID, "type": "private"},
+		},
+	}
+	f.nextUpdate++
+	f.nextID++
+	f.mu.Unlock()
+	if url == "" {
+		return fmt.Errorf("webhook belum didaftarkan lewat setWebhook")
+	}
+
+	body, _ := json.Marshal(update)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(telegramSecretHeader, secret)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook membalas status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func fakeReply(w http.ResponseWriter, code int, ok bool, description string, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	out := map[string]interface{}{"ok": ok}
+	if ok {
+		out["result"] = result
+	} else {
+		out["error_code"] = code
+		out["description"] = description
+	}
+	json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,120 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const privateUpdate = `{"update_id": 7, "message": {"message_id": 11, "date": 1769828400,
+	"text": "  halo admin  ", "from": {"id": 42, "is_bot": false, "username": "budi", "first_name": "Budi", "last_name": "Santoso"},
+	"chat": {"id": 42, "type": "private"}}}`
+
+func TestTelegramParseWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    string
+		wantErr error
+		wantNil bool
+	}{
+		{name: "pesan pribadi", secret: "s3cret", header: "s3cret", body: privateUpdate},
+		{name: "secret salah", secret: "s3cret", header: "salah", body: privateUpdate, wantErr: ErrUnauthorized},
+		{name: "tanpa header", secret: "s3cret", body: privateUpdate, wantErr: ErrUnauthorized},
+		{name: "secret belum diatur", body: privateUpdate, wantErr: ErrUnauthorized},
+		{name: "json rusak", secret: "s3cret", header: "s3cret", body: `{"update_id":`, wantErr: ErrBadUpdate},
+		{name: "bukan pesan", secret: "s3cret", header: "s3cret", body: `{"update_id": 8, "edited_message": {}}`, wantNil: true},
+		{
+			name: "grup", secret: "s3cret", header: "s3cret", wantNil: true,
+			body: `{"update_id": 9, "message": {"text": "halo", "from": {"id": 42}, "chat": {"id": -100, "type": "group"}}}`,
+		},
+		{
+			name: "dari bot", secret: "s3cret", header: "s3cret", wantNil: true,
+			body: `{"update_id": 10, "message": {"text": "halo", "from": {"id": 99, "is_bot": true}, "chat": {"id": 99, "type": "private"}}}`,
+		},
+		{
+			name: "tanpa pengirim", secret: "s3cret", header: "s3cret", wantNil: true,
+			body: `{"update_id": 11, "message": {"text": "halo", "chat": {"id": 42, "type": "private"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := NewTelegramChannel("123:abc", tt.secret, "")
+			r := httptest.NewRequest(http.MethodPost, "/channels/telegram/webhook", strings.NewReader(tt.body))
+			if tt.header != "" {
+				r.Header.Set(telegramSecretHeader, tt.header)
+			}
+
+			in, err := ch.ParseWebhook(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, ingin %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if tt.wantNil {
+				if in != nil {
+					t.Fatalf("pesan = %+v, ingin diabaikan", in)
+				}
+				return
+			}
+			if in == nil {
+				t.Fatal("pesan = nil")
+			}
+			if in.UpdateID != "7" || in.ChatID != "42" || in.UserID != "42" || in.MessageID != "11" {
+				t.Errorf("id = %+v", in)
+			}
+			if in.Text != "halo admin" || in.Name != "Budi Santoso" || in.Username != "budi" {
+				t.Errorf("isi = %q, nama = %q, username = %q", in.Text, in.Name, in.Username)
+			}
+		})
+	}
+}
+
+func TestTelegramCallHidesToken(t *testing.T) {
+	//port 1 tidak melayani apa pun, jadi koneksi gagal dengan *url.Error
+	ch := NewTelegramChannel("123456:RAHASIA", "s3cret", "http://127.0.0.1:1")
+	_, err := ch.Send(context.Background(), "42", "halo")
+	if err == nil {
+		t.Fatal("Send ke server mati seharusnya gagal")
+	}
+	if strings.Contains(err.Error(), "RAHASIA") {
+		t.Errorf("error memuat token bot: %v", err)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want []string
+	}{
+		{"pendek", "halo", 10, []string{"halo"}},
+		{"kosong", "", 10, []string{""}},
+		{"tepat batas", "abcdefghij", 10, []string{"abcdefghij"}},
+		{"tanpa baris baru", "abcdefghijkl", 5, []string{"abcde", "fghij", "kl"}},
+		{"di baris baru", "abcd\nefghij", 6, []string{"abcd\n", "efghij"}},
+		{"baris baru terlalu awal", "a\nbcdefghij", 6, []string{"a\nbcde", "fghij"}},
+		{"multibyte", "ééééé", 2, []string{"éé", "éé", "é"}},
+	}
+	for _, tt := range tests {
+		got := splitText(tt.text, tt.max)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("%s: splitText(%q, %d) = %q, ingin %q", tt.name, tt.text, tt.max, got, tt.want)
+		}
+		for _, part := range got {
+			if utf8.RuneCountInString(part) > tt.max {
+				t.Errorf("%s: bagian %q melebihi %d rune", tt.name, part, tt.max)
+			}
+		}
+	}
+}
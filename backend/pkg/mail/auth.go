@@ -0,0 +1,126 @@
+package mail
+
+import (
+	"strings"
+)
+
+// SenderAuthenticated memeriksa header Authentication-Results (RFC 8601) paling atas,
+// yang ditambahkan MTA terakhir sebelum email sampai ke sini. Pengirim dianggap asli
+// hanya jika authserv-id-nya ada di trusted dan salah satu hasil lulus serta selaras
+// dengan domain From: dmarc=pass, dkim=pass (header.d), atau spf=pass (smtp.mailfrom).
+// Header dari MTA lain diabaikan karena bisa ditulis sendiri oleh pengirim.
+func (m *Message) SenderAuthenticated(trusted []string) bool {
+	if len(m.AuthResults) == 0 || len(trusted) == 0 {
+		return false
+	}
+	_, fromDomain, ok := strings.Cut(m.From, "@")
+	if !ok || fromDomain == "" {
+		return false
+	}
+
+	servID, results := parseAuthResults(m.AuthResults[0])
+	if !containsFold(trusted, servID) {
+		return false
+	}
+	for _, r := range results {
+		if r.result != "pass" {
+			continue
+		}
+		switch r.method {
+		case "dmarc":
+			if domainAligned(r.props["header.from"], fromDomain) {
+				return true
+			}
+		case "dkim":
+			if domainAligned(r.props["header.d"], fromDomain) {
+				return true
+			}
+		case "spf":
+			d := r.props["smtp.mailfrom"]
+			if _, after, ok := strings.Cut(d, "@"); ok {
+				d = after
+			}
+			if domainAligned(d, fromDomain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type authResult struct {
+	method string
+	result string
+	props  map[string]string
+}
+
+// parseAuthResults mengurai "authserv-id; method=result ptype.prop=value ...; ..."
+func parseAuthResults(header string) (string, []authResult) {
+	parts := strings.Split(stripComments(header), ";")
+	servFields := strings.Fields(parts[0])
+	if len(servFields) == 0 {
+		return "", nil
+	}
+
+	var results []authResult
+	for _, part := range parts[1:] {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		method, result, ok := strings.Cut(fields[0], "=")
+		if !ok {
+			continue
+		}
+		// versi metode opsional, misal "dkim/1=pass"
+		method, _, _ = strings.Cut(method, "/")
+		r := authResult{
+			method: strings.ToLower(method),
+			result: strings.ToLower(result),
+			props:  map[string]string{},
+		}
+		for _, f := range fields[1:] {
+			if k, v, ok := strings.Cut(f, "="); ok {
+				r.props[strings.ToLower(k)] = strings.ToLower(strings.Trim(v, `"`))
+			}
+		}
+		results = append(results, r)
+	}
+	return strings.ToLower(servFields[0]), results
+}
+
+// stripComments membuang komentar "(...)" yang boleh muncul di mana saja dalam header
+func stripComments(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// domainAligned memakai penyelarasan relaxed DMARC: sama, atau salah satu subdomain yang lain
+func domainAligned(a, b string) bool {
+	a = strings.TrimSuffix(strings.ToLower(a), ".")
+	b = strings.TrimSuffix(strings.ToLower(b), ".")
+	if !strings.Contains(a, ".") || !strings.Contains(b, ".") {
+		return false
+	}
+	return a == b || strings.HasSuffix(a, "."+b) || strings.HasSuffix(b, "."+a)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
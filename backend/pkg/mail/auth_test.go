@@ -0,0 +1,34 @@
+package mail
+
+import "testing"
+
+func TestSenderAuthenticated(t *testing.T) {
+	trusted := []string{"mx.kampus.ac.id"}
+	tests := []struct {
+		name    string
+		from    string
+		results []string
+		trusted []string
+		want    bool
+	}{
+		{"dmarc pass", "budi@student.kampus.ac.id", []string{"mx.kampus.ac.id; dmarc=pass (p=reject) header.from=student.kampus.ac.id"}, trusted, true},
+		{"dkim selaras subdomain", "budi@kampus.ac.id", []string{"mx.kampus.ac.id; dkim=pass header.d=mail.kampus.ac.id header.s=s1"}, trusted, true},
+		{"spf selaras", "budi@gmail.com", []string{"MX.Kampus.ac.id; spf=pass smtp.mailfrom=budi@gmail.com"}, trusted, true},
+		{"versi metode", "budi@gmail.com", []string{"mx.kampus.ac.id 1; dkim/1=pass header.d=gmail.com"}, trusted, true},
+		{"dmarc gagal", "budi@gmail.com", []string{"mx.kampus.ac.id; dmarc=fail header.from=gmail.com; spf=softfail smtp.mailfrom=gmail.com"}, trusted, false},
+		{"dkim domain lain", "rektor@kampus.ac.id", []string{"mx.kampus.ac.id; dkim=pass header.d=penipu.example"}, trusted, false},
+		{"dmarc tanpa header.from", "budi@gmail.com", []string{"mx.kampus.ac.id; dmarc=pass"}, trusted, false},
+		{"spf domain lain", "rektor@kampus.ac.id", []string{"mx.kampus.ac.id; spf=pass smtp.mailfrom=x@penipu.example"}, trusted, false},
+		{"dkim domain publik", "budi@kampus.ac.id", []string{"mx.kampus.ac.id; dkim=pass header.d=id"}, trusted, false},
+		{"authserv tidak dipercaya", "budi@gmail.com", []string{"mx.penipu.example; dmarc=pass header.from=gmail.com"}, trusted, false},
+		{"hanya header paling atas", "budi@gmail.com", []string{"mx.kampus.ac.id; dmarc=fail header.from=gmail.com", "mx.kampus.ac.id; dmarc=pass header.from=gmail.com"}, trusted, false},
+		{"tanpa header", "budi@gmail.com", nil, trusted, false},
+		{"tanpa MTA tepercaya", "budi@gmail.com", []string{"mx.kampus.ac.id; dmarc=pass header.from=gmail.com"}, nil, false},
+	}
+	for _, tt := range tests {
+		msg := &Message{From: tt.from, AuthResults: tt.results}
+		if got := msg.SenderAuthenticated(tt.trusted); got != tt.want {
+			t.Errorf("%s: SenderAuthenticated = %v, ingin %v", tt.name, got, tt.want)
+		}
+	}
+}
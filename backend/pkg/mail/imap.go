@@ -0,0 +1,203 @@
+package mail
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrIMAP = errors.New("perintah IMAP gagal")
+
+// IMAPClient adalah klien IMAP4rev1 minimal untuk polling satu mailbox:
+// login, cari email belum dibaca, ambil isinya, lalu tandai sudah dibaca.
+type IMAPClient struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	tag     int
+	timeout time.Duration
+}
+
+// imapResponse adalah satu baris respon untagged ("* ...") beserta literal di dalamnya
+type imapResponse struct {
+	line     string
+	literals [][]byte
+}
+
+// DialIMAP membuka koneksi ke server IMAP. useTLS untuk port 993,
+// tanpa TLS hanya untuk server lokal saat pengembangan.
+func DialIMAP(addr string, useTLS bool, timeout time.Duration) (*IMAPClient, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &IMAPClient{conn: conn, r: bufio.NewReader(conn), timeout: timeout}
+	c.deadline()
+	greeting, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("%w: salam server tidak dikenal: %s", ErrIMAP, greeting)
+	}
+	return c, nil
+}
+
+func (c *IMAPClient) Login(user, password string) error {
+	_, err := c.command("LOGIN %s %s", quote(user), quote(password))
+	return err
+}
+
+func (c *IMAPClient) Select(mailbox string) error {
+	_, err := c.command("SELECT %s", quote(mailbox))
+	return err
+}
+
+// SearchUnseen mengembalikan UID email yang belum ditandai \Seen
+func (c *IMAPClient) SearchUnseen() ([]uint32, error) {
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, resp := range responses {
+		fields := strings.Fields(resp.line)
+		if len(fields) < 2 || !strings.EqualFold(fields[1], "SEARCH") {
+			continue
+		}
+		for _, f := range fields[2:] {
+			if uid, err := strconv.ParseUint(f, 10, 32); err == nil {
+				uids = append(uids, uint32(uid))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// Fetch mengambil email mentah tanpa menandainya \Seen (BODY.PEEK)
+func (c *IMAPClient) Fetch(uid uint32) ([]byte, error) {
+	responses, err := c.command("UID FETCH %d (BODY.PEEK[])", uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, resp := range responses {
+		if strings.Contains(strings.ToUpper(resp.line), "FETCH") && len(resp.literals) > 0 {
+			return resp.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: email UID %d tidak ditemukan", ErrIMAP, uid)
+}
+
+func (c *IMAPClient) MarkSeen(uid uint32) error {
+	_, err := c.command(`UID STORE %d +FLAGS.SILENT (\Seen)`, uid)
+	return err
+}
+
+// Logout mengakhiri sesi dan menutup koneksi
+func (c *IMAPClient) Logout() error {
+	_, err := c.command("LOGOUT")
+	if closeErr := c.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (c *IMAPClient) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("A%03d", c.tag)
+	c.deadline()
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var responses []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(resp.line, tag+" ") {
+			responses = append(responses, resp)
+			continue
+		}
+		status := strings.TrimPrefix(resp.line, tag+" ")
+		if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+			return nil, fmt.Errorf("%w: %s", ErrIMAP, status)
+		}
+		return responses, nil
+	}
+}
+
+// readResponse membaca satu respon lengkap, termasuk literal {n} yang bisa muncul di tengah baris
+func (c *IMAPClient) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	var line strings.Builder
+	for {
+		part, err := c.readLine()
+		if err != nil {
+			return resp, err
+		}
+		line.WriteString(part)
+
+		size, ok := literalSize(part)
+		if !ok {
+			resp.line = line.String()
+			return resp, nil
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return resp, err
+		}
+		resp.literals = append(resp.literals, literal)
+	}
+}
+
+func (c *IMAPClient) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *IMAPClient) deadline() {
+	if c.timeout > 0 {
+		c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+}
+
+// literalSize membaca penanda literal "{123}" di akhir baris
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	open := strings.LastIndexByte(line, '{')
+	if open < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(line[open+1 : len(line)-1])
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
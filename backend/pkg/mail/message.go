@@ -0,0 +1,339 @@
+// Package mail berisi komponen channel email tanpa dependensi eksternal:
+// parser MIME, klien IMAP minimal untuk polling, penerima SMTP sederhana,
+// dan pengirim SMTP untuk notifikasi keluar.
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ReplyMarker disisipkan di email keluar, teks di bawahnya dibuang saat balasan diproses
+const ReplyMarker = "##- Balas di atas baris ini -##"
+
+// Message adalah email masuk yang sudah diurai
+type Message struct {
+	MessageID  string
+	InReplyTo  string
+	References []string
+	From       string // alamat pengirim, huruf kecil
+	FromName   string
+	Subject    string
+	Date       time.Time
+	Text       string // isi teks tanpa kutipan email sebelumnya
+	AutoReply  bool   // auto-reply, bounce, atau email massal, tidak perlu diproses
+	// header Authentication-Results berurutan dari atas, paling atas dari MTA terakhir
+	AuthResults []string
+
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// ThreadIDs mengembalikan Message-ID yang dirujuk email ini, yang paling baru lebih dulu
+func (m *Message) ThreadIDs() []string {
+	var ids []string
+	if m.InReplyTo != "" {
+		ids = append(ids, m.InReplyTo)
+	}
+	for i := len(m.References) - 1; i >= 0; i-- {
+		if m.References[i] != m.InReplyTo {
+			ids = append(ids, m.References[i])
+		}
+	}
+	return ids
+}
+
+var (
+	subjectTokenRe = regexp.MustCompile(`\[#([A-Za-z0-9_-]{6,64})\]`)
+	wordDecoder    = &mime.WordDecoder{CharsetReader: charsetReader}
+)
+
+// SubjectToken adalah penanda tiket di subjek email keluar, misal "[#AbC123]"
+func SubjectToken(ticketID string) string {
+	return "[#" + ticketID + "]"
+}
+
+// FindSubjectToken mengambil ID tiket dari subjek, kosong jika tidak ada
+func FindSubjectToken(subject string) string {
+	if m := subjectTokenRe.FindStringSubmatch(subject); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Parse mengurai email mentah (RFC 5322) beserta bagian MIME-nya
+func Parse(r io.Reader) (*Message, error) {
+	raw, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("email tidak valid: %w", err)
+	}
+	h := raw.Header
+
+	msg := &Message{
+		MessageID:  trimID(h.Get("Message-Id")),
+		InReplyTo:  firstID(h.Get("In-Reply-To")),
+		References: splitIDs(h.Get("References")),
+		Subject:    decodeHeader(h.Get("Subject")),
+		AutoReply:  isAutoReply(h),
+
+		AuthResults: h["Authentication-Results"],
+	}
+	if date, err := h.Date(); err == nil {
+		msg.Date = date
+	} else {
+		msg.Date = time.Now()
+	}
+
+	addr, err := (&mail.AddressParser{WordDecoder: wordDecoder}).Parse(h.Get("From"))
+	if err != nil {
+		return nil, fmt.Errorf("alamat pengirim tidak valid: %w", err)
+	}
+	msg.From = strings.ToLower(addr.Address)
+	msg.FromName = addr.Name
+	if local, _, _ := strings.Cut(msg.From, "@"); local == "mailer-daemon" || local == "postmaster" {
+		msg.AutoReply = true
+	}
+
+	var body bodyParts
+	header := textproto.MIMEHeader(h)
+	if err := body.walk(header, raw.Body, 0); err != nil {
+		return nil, err
+	}
+	msg.Attachments = body.attachments
+
+	text := body.plain
+	if text == "" && body.html != "" {
+		text = htmlToText(body.html)
+	}
+	msg.Text = StripQuoted(text)
+	return msg, nil
+}
+
+type bodyParts struct {
+	plain       string
+	html        string
+	attachments []Attachment
+}
+
+// batas kedalaman multipart bersarang, mencegah email jahat yang sangat dalam
+const maxDepth = 10
+
+func (b *bodyParts) walk(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("struktur MIME terlalu dalam")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("gagal membaca bagian MIME: %w", err)
+			}
+			if err := b.walk(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(body, header.Get("Content-Transfer-Encoding")))
+	if err != nil {
+		return fmt.Errorf("gagal mendekode isi email: %w", err)
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	fileName := decodeHeader(dparams["filename"])
+	if fileName == "" {
+		fileName = decodeHeader(params["name"])
+	}
+
+	switch {
+	case disposition != "attachment" && fileName == "" && mediaType == "text/plain":
+		if b.plain == "" {
+			b.plain = toUTF8(data, params["charset"])
+		}
+	case disposition != "attachment" && fileName == "" && mediaType == "text/html":
+		if b.html == "" {
+			b.html = toUTF8(data, params["charset"])
+		}
+	case len(data) > 0:
+		if fileName == "" {
+			fileName = "lampiran"
+		}
+		b.attachments = append(b.attachments, Attachment{FileName: fileName, ContentType: mediaType, Data: data})
+	}
+	return nil
+}
+
+func decodeTransfer(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// hanya UTF-8 dan Latin-1 yang dikonversi (tanpa dependensi tambahan),
+// charset lain dipakai apa adanya dengan byte tidak valid dibuang
+func toUTF8(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252", "cp1252":
+		runes := make([]rune, len(data))
+		for i, c := range data {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return strings.ToValidUTF8(string(data), "")
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(toUTF8(data, charset)), nil
+}
+
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+func trimID(id string) string {
+	return strings.Trim(strings.TrimSpace(id), "<>")
+}
+
+func firstID(value string) string {
+	if ids := splitIDs(value); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+func splitIDs(value string) []string {
+	var ids []string
+	for _, field := range strings.Fields(value) {
+		if id := trimID(field); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// isAutoReply mengenali auto-reply dan email massal agar tidak membuat tiket
+// (dan tidak memicu notifikasi balik yang bisa berputar tanpa henti)
+func isAutoReply(h mail.Header) bool {
+	if v := strings.ToLower(h.Get("Auto-Submitted")); v != "" && v != "no" {
+		return true
+	}
+	switch strings.ToLower(h.Get("Precedence")) {
+	case "bulk", "junk", "list", "auto_reply":
+		return true
+	}
+	return h.Get("X-Autoreply") != "" || h.Get("X-Autorespond") != ""
+}
+
+var (
+	htmlDropRe   = regexp.MustCompile(`(?is)<(style|script|head)[^>]*>.*?</(style|script|head)>`)
+	htmlBreakRe  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6])>`)
+	htmlQuoteRe  = regexp.MustCompile(`(?is)<blockquote[^>]*>.*?</blockquote>`)
+	htmlTagRe    = regexp.MustCompile(`<[^>]+>`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+func htmlToText(s string) string {
+	s = htmlDropRe.ReplaceAllString(s, "")
+	s = htmlQuoteRe.ReplaceAllString(s, "")
+	s = htmlBreakRe.ReplaceAllString(s, "\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, " ", " ")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+}
+
+// pembuka kutipan dari klien email umum (Gmail/Outlook/Apple Mail, bahasa Inggris & Indonesia)
+// (Gmail kadang memecah "On ... wrote:" menjadi dua baris)
+var quoteHeaderRe = regexp.MustCompile(`(?m)^(On\s[^\n]+(?:\n[^\n]+)?\swrote:|Pada\s[^\n]+(?:\n[^\n]+)?\smenulis:|-{2,}\s*Original Message\s*-{2,}|-{2,}\s*Pesan Asli\s*-{2,}|From:\s[^\n]+\nSent:\s[^\n]+)[ \t]*$`)
+
+// StripQuoted membuang kutipan email sebelumnya sehingga hanya balasan baru yang tersisa
+func StripQuoted(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if i := strings.Index(text, ReplyMarker); i >= 0 {
+		text = text[:i]
+	}
+	if loc := quoteHeaderRe.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	//buang signature standar ("-- ")
+	for i, line := range kept {
+		if line == "--" {
+			kept = kept[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(kept, "\n"), "\n\n"))
+}
+
+var ErrTooLarge = errors.New("email melebihi batas ukuran")
+
+// Raw membaca email mentah dengan batas ukuran
+func Raw(r io.Reader, maxSize int64) ([]byte, error) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > maxSize {
+		return nil, ErrTooLarge
+	}
+	return buf.Bytes(), nil
+}
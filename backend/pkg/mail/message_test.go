@@ -0,0 +1,158 @@
+package mail
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func crlf(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+func TestParse(t *testing.T) {
+	raw := crlf(`Authentication-Results: mx.kampus.ac.id; dmarc=pass header.from=student.kampus.ac.id
+Authentication-Results: relay.luar.example; spf=pass smtp.mailfrom=budi@student.kampus.ac.id
+From: =?UTF-8?Q?Budi_Santoso?= <Budi@Student.Kampus.ac.id>
+Subject: =?UTF-8?B?UmU6IFsjY29udjEyM10gR2FnYWwgYmF5YXIgVUtU?=
+Message-ID: <abc@mail.example>
+In-Reply-To: <out-2@support.kampus.ac.id>
+References: <out-1@support.kampus.ac.id> <out-2@support.kampus.ac.id>
+Date: Sat, 31 Jan 2026 10:06:00 +0700
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="b1"
+
+--b1
+Content-Type: multipart/alternative; boundary="b2"
+
+--b2
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Sudah saya coba lagi, tetap gagal=2E
+Mohon dibantu.
+
+On Sat, 31 Jan 2026 at 09:00, Layanan Mahasiswa wrote:
+> Silakan coba lagi.
+--b2
+Content-Type: text/html; charset=utf-8
+
+<p>Sudah saya coba lagi</p>
+--b2--
+--b1
+Content-Type: image/png
+Content-Disposition: attachment; filename="error.png"
+Content-Transfer-Encoding: base64
+
+iVBORw0KGgo=
+--b1--
+`)
+	msg, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		field string
+		got   interface{}
+		want  interface{}
+	}{
+		{"From", msg.From, "budi@student.kampus.ac.id"},
+		{"FromName", msg.FromName, "Budi Santoso"},
+		{"Subject", msg.Subject, "Re: [#conv123] Gagal bayar UKT"},
+		{"MessageID", msg.MessageID, "abc@mail.example"},
+		{"ThreadIDs", msg.ThreadIDs(), []string{"out-2@support.kampus.ac.id", "out-1@support.kampus.ac.id"}},
+		{"Text", msg.Text, "Sudah saya coba lagi, tetap gagal.\nMohon dibantu."},
+		{"AutoReply", msg.AutoReply, false},
+		{"AuthResults", len(msg.AuthResults), 2},
+		{"Attachments", len(msg.Attachments), 1},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %#v, ingin %#v", c.field, c.got, c.want)
+		}
+	}
+	if len(msg.Attachments) == 1 {
+		if a := msg.Attachments[0]; a.FileName != "error.png" || a.ContentType != "image/png" || len(a.Data) != 8 {
+			t.Errorf("lampiran = %s %s %d byte", a.FileName, a.ContentType, len(a.Data))
+		}
+	}
+	if !strings.HasPrefix(msg.AuthResults[0], "mx.kampus.ac.id;") {
+		t.Errorf("Authentication-Results paling atas harus pertama, dapat %q", msg.AuthResults[0])
+	}
+}
+
+func TestParseHTMLOnly(t *testing.T) {
+	raw := crlf(`From: budi@kampus.ac.id
+Subject: Tolong
+Content-Type: text/html; charset=utf-8
+
+<html><head><style>p{}</style></head><body><p>Halo&nbsp;admin,</p><p>KRS saya hilang.</p><blockquote>kutipan lama</blockquote></body></html>
+`)
+	msg, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Halo admin,\nKRS saya hilang."; msg.Text != want {
+		t.Errorf("Text = %q, ingin %q", msg.Text, want)
+	}
+}
+
+func TestParseAutoReply(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"biasa", "From: budi@kampus.ac.id", false},
+		{"auto-submitted", "From: budi@kampus.ac.id\nAuto-Submitted: auto-replied", true},
+		{"auto-submitted no", "From: budi@kampus.ac.id\nAuto-Submitted: no", false},
+		{"precedence bulk", "From: budi@kampus.ac.id\nPrecedence: bulk", true},
+		{"bounce", "From: MAILER-DAEMON@kampus.ac.id", true},
+	}
+	for _, tt := range tests {
+		msg, err := Parse(strings.NewReader(crlf(tt.header + "\nSubject: x\n\nisi\n")))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if msg.AutoReply != tt.want {
+			t.Errorf("%s: AutoReply = %v, ingin %v", tt.name, msg.AutoReply, tt.want)
+		}
+	}
+}
+
+func TestStripQuoted(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"marker balasan", "Terima kasih\n" + ReplyMarker + "\nBalasan agent", "Terima kasih"},
+		{"kutipan Gmail dua baris", "Oke sudah bisa\n\nOn Sat, 31 Jan 2026 at 09:00, Layanan\nMahasiswa <cs@kampus.ac.id> wrote:\n> lama", "Oke sudah bisa"},
+		{"kutipan bahasa Indonesia", "Baik\nPada Sab, 31 Jan 2026, Layanan menulis:\n> lama", "Baik"},
+		{"baris > dibuang", "Jawaban\n> kutipan\nlanjut", "Jawaban\nlanjut"},
+		{"signature", "Isi\n-- \nBudi\nNIM 123", "Isi"},
+	}
+	for _, tt := range tests {
+		if got := StripQuoted(tt.text); got != tt.want {
+			t.Errorf("%s: StripQuoted = %q, ingin %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindSubjectToken(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"Re: [#AbC123_x] Gagal bayar", "AbC123_x"},
+		{SubjectToken("conv-123456"), "conv-123456"},
+		{"Re: [#abc] terlalu pendek", ""},
+		{"tanpa token", ""},
+	}
+	for _, tt := range tests {
+		if got := FindSubjectToken(tt.subject); got != tt.want {
+			t.Errorf("FindSubjectToken(%q) = %q, ingin %q", tt.subject, got, tt.want)
+		}
+	}
+}
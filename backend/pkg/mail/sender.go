@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Outgoing adalah email keluar dengan isi teks dan HTML (multipart/alternative)
+type Outgoing struct {
+	From       string
+	FromName   string
+	To         string
+	ReplyTo    string
+	Subject    string
+	MessageID  string // tanpa kurung sudut, dibuat otomatis jika kosong
+	InReplyTo  string
+	References []string
+	Text       string
+	HTML       string
+}
+
+// NewMessageID membuat Message-ID unik untuk domain pengirim
+func NewMessageID(domain string) string {
+	var b [12]byte
+	rand.Read(b[:])
+	return fmt.Sprintf("%d.%s@%s", time.Now().Unix(), hex.EncodeToString(b[:]), domain)
+}
+
+// Bytes menyusun email lengkap (header + body) siap dikirim lewat SMTP
+func (m *Outgoing) Bytes() ([]byte, error) {
+	if m.MessageID == "" {
+		_, domain, _ := strings.Cut(m.From, "@")
+		m.MessageID = NewMessageID(domain)
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+		}
+	}
+	header("From", (&mail.Address{Name: m.FromName, Address: m.From}).String())
+	header("To", m.To)
+	header("Reply-To", m.ReplyTo)
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+m.MessageID+">")
+	if m.InReplyTo != "" {
+		header("In-Reply-To", "<"+m.InReplyTo+">")
+	}
+	if len(m.References) > 0 {
+		header("References", "<"+strings.Join(m.References, "> <")+">")
+	}
+	header("MIME-Version", "1.0")
+	//notifikasi otomatis: auto-reply dari klien mahasiswa tidak boleh dibalas lagi
+	header("Auto-Submitted", "auto-generated")
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", `multipart/alternative; boundary="`+mw.Boundary()+`"`)
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n"))); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SMTPSender mengirim email lewat relay SMTP (STARTTLS dipakai otomatis jika didukung server)
+type SMTPSender struct {
+	Addr     string // host:port, misal smtp.kampus.ac.id:587
+	Username string
+	Password string
+}
+
+func (s *SMTPSender) Send(m *Outgoing) error {
+	data, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return fmt.Errorf("alamat tujuan tidak valid: %w", err)
+	}
+	return smtp.SendMail(s.Addr, auth, m.From, []string{to.Address}, data)
+}
@@ -0,0 +1,218 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DeliverFunc menerima satu email dari server SMTP. Error dibalas 451 sehingga
+// server pengirim mencoba lagi nanti, jadi kembalikan nil untuk email yang sengaja diabaikan.
+type DeliverFunc func(ctx context.Context, from string, to []string, data []byte) error
+
+// SMTPServer adalah penerima SMTP minimal (tanpa AUTH/STARTTLS) untuk email masuk.
+// Dipasang di belakang MTA/relay (misal Postfix atau layanan inbound email) atau
+// langsung ke server lokal saat pengembangan.
+type SMTPServer struct {
+	Addr       string
+	Domain     string   // nama host di salam 220
+	MaxSize    int64    // batas ukuran email (byte)
+	Recipients []string // alamat tujuan yang diterima, kosong = semua
+	Timeout    time.Duration
+	Deliver    DeliverFunc
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+var ErrServerClosed = errors.New("server SMTP sudah ditutup")
+
+func (s *SMTPServer) ListenAndServe() error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+func (s *SMTPServer) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// Close berhenti menerima koneksi baru dan menunggu sesi yang sedang berjalan selesai
+func (s *SMTPServer) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	ln := s.listener
+	s.mu.Unlock()
+
+	var err error
+	if ln != nil {
+		err = ln.Close()
+	}
+	s.wg.Wait()
+	return err
+}
+
+type smtpSession struct {
+	mail bool // MAIL FROM sudah diterima, pengirim boleh kosong ("<>" untuk bounce)
+	from string
+	to   []string
+}
+
+func (s *SMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(code int, msg string) {
+		s.deadline(conn)
+		tp.PrintfLine("%d %s", code, msg)
+	}
+
+	reply(220, s.domain()+" ESMTP siap")
+	var session smtpSession
+	for {
+		s.deadline(conn)
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			session = smtpSession{}
+			reply(250, s.domain())
+		case "EHLO":
+			session = smtpSession{}
+			s.deadline(conn)
+			tp.PrintfLine("250-%s", s.domain())
+			tp.PrintfLine("250-SIZE %d", s.MaxSize)
+			tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			addr, ok := pathArg(arg, "FROM:")
+			if !ok {
+				reply(501, "Sintaks: MAIL FROM:<alamat>")
+				continue
+			}
+			session = smtpSession{mail: true, from: addr}
+			reply(250, "OK")
+		case "RCPT":
+			addr, ok := pathArg(arg, "TO:")
+			switch {
+			case !ok:
+				reply(501, "Sintaks: RCPT TO:<alamat>")
+			case !session.mail:
+				reply(503, "MAIL dulu")
+			case !s.accepts(addr):
+				reply(550, "Alamat tujuan tidak dikenal")
+			default:
+				session.to = append(session.to, addr)
+				reply(250, "OK")
+			}
+		case "DATA":
+			if len(session.to) == 0 {
+				reply(503, "RCPT dulu")
+				continue
+			}
+			reply(354, "Akhiri dengan <CRLF>.<CRLF>")
+			s.deadline(conn)
+			dot := tp.DotReader()
+			data, err := Raw(dot, s.MaxSize)
+			if errors.Is(err, ErrTooLarge) {
+				//sisa data harus tetap dibaca supaya sesi bisa dilanjutkan
+				io.Copy(io.Discard, dot)
+				reply(552, "Email melebihi batas ukuran")
+				session = smtpSession{}
+				continue
+			}
+			if err != nil {
+				return
+			}
+			if err := s.Deliver(context.Background(), session.from, session.to, data); err != nil {
+				log.Printf("gagal memproses email dari %s: %v", session.from, err)
+				reply(451, "Gagal memproses email, coba lagi nanti")
+			} else {
+				reply(250, "OK diterima")
+			}
+			session = smtpSession{}
+		case "RSET":
+			session = smtpSession{}
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "VRFY":
+			reply(252, "Tidak bisa memverifikasi alamat")
+		case "QUIT":
+			reply(221, "Sampai jumpa")
+			return
+		default:
+			reply(502, "Perintah tidak didukung")
+		}
+	}
+}
+
+func (s *SMTPServer) domain() string {
+	if s.Domain == "" {
+		return "localhost"
+	}
+	return s.Domain
+}
+
+func (s *SMTPServer) accepts(addr string) bool {
+	if len(s.Recipients) == 0 {
+		return true
+	}
+	for _, r := range s.Recipients {
+		if strings.EqualFold(r, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SMTPServer) deadline(conn net.Conn) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+}
+
+// pathArg membaca "FROM:<a@b.c> SIZE=123" menjadi "a@b.c"
+func pathArg(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if path, _, _ = strings.Cut(path, " "); !strings.HasPrefix(path, "<") || !strings.HasSuffix(path, ">") {
+		return "", false
+	}
+	return strings.ToLower(path[1 : len(path)-1]), true
+}
@@ -0,0 +1,171 @@
+package pii
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// jenis data pribadi yang dikenali
+type Type string
+
+const (
+	Email       Type = "EMAIL"
+	NIK         Type = "NIK"
+	Card        Type = "CARD"
+	BankAccount Type = "BANK_ACCOUNT"
+	NIM         Type = "NIM"
+	Phone       Type = "PHONE"
+	Address     Type = "ADDRESS"
+)
+
+// urutan deteksi penting: pola yang lebih spesifik diproses lebih dulu
+var AllTypes = []Type{Email, NIK, Card, BankAccount, NIM, Phone, Address}
+
+type detector struct {
+	pattern *regexp.Regexp
+	group   int               // submatch yang berisi nilai PII (0 = seluruh match)
+	valid   func(string) bool // validasi tambahan (checksum/struktur), nil = selalu valid
+}
+
+var detectors = map[Type]detector{
+	Email: {pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+	NIK:   {pattern: regexp.MustCompile(`\b\d{16}\b`), valid: validNIK},
+	Card:  {pattern: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), valid: validLuhn},
+	BankAccount: {
+		// nama bank opsional di antara kata kunci dan nomor, misal "rekening BCA 1234567890"
+		pattern: regexp.MustCompile(`(?i)\b(?:no\.?\s*)?(?:rek(?:ening)?|account|acc)\.?(?:\s+(?:bank\s+)?[a-z]{2,15})?\s*(?:no\.?|nomor)?\s*:?\s*(\d[\d\- ]{6,18}\d)`),
+		group:   1,
+	},
+	NIM: {
+		// NIM bersegmen seperti "A11.2019.12345" atau angka utuh
+		pattern: regexp.MustCompile(`(?i)\bNIM\b\.?\s*(?:saya|sy|:|adalah)?\s*:?\s*([A-Za-z]?\d{1,4}(?:[.\-/]\d{2,6}){1,3}|[A-Za-z]?\d{6,15})`),
+		group:   1,
+	},
+	Phone: {pattern: regexp.MustCompile(`(?:\+62|\b62|\b0)[\s\-]?8\d{1,2}[\s\-]?\d{3,4}[\s\-]?\d{3,5}\b`), valid: validPhone},
+	Address: {
+		pattern: regexp.MustCompile(`(?i)\b(?:jl|jln|jalan|gg|gang)\.?\s+[A-Za-z0-9 .'\-/]{2,60}?(?:\s*,?\s*no\.?\s*\d+[A-Za-z]?)?(?:,|\n|$)`),
+	},
+}
+
+// Redactor menyimpan kebijakan redaksi yang berlaku untuk satu deployment
+type Redactor struct {
+	enabled bool
+	types   []Type
+}
+
+// NewRedactor membuat redactor; types kosong berarti semua jenis PII diredaksi
+func NewRedactor(enabled bool, types []string) *Redactor {
+	r := &Redactor{enabled: enabled}
+	if len(types) == 0 {
+		r.types = AllTypes
+		return r
+	}
+
+	wanted := make(map[Type]bool)
+	for _, t := range types {
+		wanted[Type(strings.ToUpper(strings.TrimSpace(t)))] = true
+	}
+	for _, t := range AllTypes {
+		if wanted[t] {
+			r.types = append(r.types, t)
+		}
+	}
+	return r
+}
+
+func (r *Redactor) Enabled() bool {
+	return r != nil && r.enabled
+}
+
+// Session mengelompokkan beberapa teks dalam satu panggilan LLM agar nilai yang
+// sama selalu mendapat placeholder yang sama dan bisa dikembalikan di output.
+type Session struct {
+	redactor      *Redactor
+	byValue       map[string]string // nilai asli -> placeholder
+	byPlaceholder map[string]string // placeholder -> nilai asli
+	counts        map[Type]int
+}
+
+func (r *Redactor) NewSession() *Session {
+	return &Session{
+		redactor:      r,
+		byValue:       make(map[string]string),
+		byPlaceholder: make(map[string]string),
+		counts:        make(map[Type]int),
+	}
+}
+
+// Redact mengganti PII dengan placeholder seperti [PHONE_1]
+func (s *Session) Redact(text string) string {
+	if !s.redactor.Enabled() {
+		return text
+	}
+
+	for _, t := range s.redactor.types {
+		d := detectors[t]
+		text = replaceSubmatch(d.pattern, text, d.group, func(value string) string {
+			if d.valid != nil && !d.valid(value) {
+				return value
+			}
+			return s.placeholder(t, value)
+		})
+	}
+	return text
+}
+
+// Restore mengembalikan nilai asli pada teks keluaran LLM
+func (s *Session) Restore(text string) string {
+	for placeholder, value := range s.byPlaceholder {
+		text = strings.ReplaceAll(text, placeholder, value)
+	}
+	return text
+}
+
+// Counts mengembalikan jumlah nilai unik yang diredaksi per jenis (untuk audit, tanpa nilai asli)
+func (s *Session) Counts() map[string]int {
+	counts := make(map[string]int, len(s.counts))
+	for t, n := range s.counts {
+		counts[string(t)] = n
+	}
+	return counts
+}
+
+func (s *Session) placeholder(t Type, value string) string {
+	key := string(t) + ":" + value
+	if p, ok := s.byValue[key]; ok {
+		return p
+	}
+	s.counts[t]++
+	p := fmt.Sprintf("[%s_%d]", t, s.counts[t])
+	s.byValue[key] = p
+	s.byPlaceholder[p] = value
+	return p
+}
+
+// mengganti hanya bagian submatch tertentu, sisa match (misal kata "NIM") dipertahankan
+func replaceSubmatch(re *regexp.Regexp, text string, group int, repl func(string) string) string {
+	matches := re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		value := text[start:end]
+		// alamat: jangan ikut meredaksi pemisah di akhir match
+		trimmed := strings.TrimRight(value, ",\n")
+		end = start + len(trimmed)
+
+		b.WriteString(text[last:start])
+		b.WriteString(repl(trimmed))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package pii
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		text  string
+		want  string
+	}{
+		{
+			name: "email dan telepon",
+			text: "Hubungi budi@mail.com atau 081234567890",
+			want: "Hubungi [EMAIL_1] atau [PHONE_1]",
+		},
+		{
+			name: "nilai sama mendapat placeholder sama",
+			text: "budi@mail.com, sekali lagi budi@mail.com",
+			want: "[EMAIL_1], sekali lagi [EMAIL_1]",
+		},
+		{
+			name: "NIK valid diredaksi, angka acak tidak",
+			text: "NIK 3201011505900001, kode 9901011505900001",
+			want: "NIK [NIK_1], kode 9901011505900001",
+		},
+		{
+			name: "kartu hanya jika lolos Luhn",
+			text: "kartu 4111 1111 1111 1111 dan 4111 1111 1111 1112",
+			want: "kartu [CARD_1] dan 4111 1111 1111 1112",
+		},
+		{
+			name: "NIM mempertahankan label",
+			text: "NIM saya 1301194123",
+			want: "NIM saya [NIM_1]",
+		},
+		{
+			name: "NIM bersegmen",
+			text: "NIM: A11.2019.12345, kelas B",
+			want: "NIM: [NIM_1], kelas B",
+		},
+		{
+			name: "rekening langsung diikuti nomor",
+			text: "rekening 1234567890 atas nama Budi",
+			want: "rekening [BANK_ACCOUNT_1] atas nama Budi",
+		},
+		{
+			name: "rekening dengan nama bank",
+			text: "sudah transfer dari rekening BCA 1234567890",
+			want: "sudah transfer dari rekening BCA [BANK_ACCOUNT_1]",
+		},
+		{
+			name: "no rek dengan bank dan nomor bersegmen",
+			text: "no rek mandiri: 123-456-7890",
+			want: "no rek mandiri: [BANK_ACCOUNT_1]",
+		},
+		{
+			name: "rekening bank dengan kata nomor",
+			text: "Rekening Bank BNI nomor 0123456789",
+			want: "Rekening Bank BNI nomor [BANK_ACCOUNT_1]",
+		},
+		{
+			name: "alamat jalan dengan nomor",
+			text: "Kirim ke Jl. Merdeka No. 10, Bandung",
+			want: "Kirim ke [ADDRESS_1], Bandung",
+		},
+		{
+			name: "alamat gang di akhir teks",
+			text: "kos saya di Gang Mawar 3",
+			want: "kos saya di [ADDRESS_1]",
+		},
+		{
+			name:  "hanya jenis yang dipilih",
+			types: []string{"email"},
+			text:  "budi@mail.com 081234567890",
+			want:  "[EMAIL_1] 081234567890",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewRedactor(true, tt.types).NewSession()
+			got := s.Redact(tt.text)
+			if got != tt.want {
+				t.Fatalf("Redact = %q, ingin %q", got, tt.want)
+			}
+			if restored := s.Restore(got); restored != tt.text {
+				t.Errorf("Restore = %q, ingin %q", restored, tt.text)
+			}
+		})
+	}
+}
+
+func TestRedactDisabled(t *testing.T) {
+	text := "budi@mail.com 081234567890"
+	if got := NewRedactor(false, nil).NewSession().Redact(text); got != text {
+		t.Errorf("redaksi nonaktif mengubah teks: %q", got)
+	}
+}
+
+func TestRestoreOutput(t *testing.T) {
+	s := NewRedactor(true, nil).NewSession()
+	s.Redact("email a@kampus.ac.id dan b@kampus.ac.id")
+
+	got := s.Restore("Kami akan membalas ke [EMAIL_2], salinan ke [EMAIL_1]. [EMAIL_3] tidak dikenal.")
+	want := "Kami akan membalas ke b@kampus.ac.id, salinan ke a@kampus.ac.id. [EMAIL_3] tidak dikenal."
+	if got != want {
+		t.Errorf("Restore = %q, ingin %q", got, want)
+	}
+	if counts := s.Counts(); !reflect.DeepEqual(counts, map[string]int{"EMAIL": 2}) {
+		t.Errorf("Counts = %v", counts)
+	}
+}
@@ -0,0 +1,81 @@
+package pii
+
+import (
+	"strconv"
+	"strings"
+)
+
+// kode provinsi Dukcapil yang valid sebagai dua digit pertama NIK
+var nikProvinces = map[int]bool{
+	11: true, 12: true, 13: true, 14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 21: true,
+	31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+	51: true, 52: true, 53: true,
+	61: true, 62: true, 63: true, 64: true, 65: true,
+	71: true, 72: true, 73: true, 74: true, 75: true, 76: true,
+	81: true, 82: true, 91: true, 92: true, 93: true, 94: true, 95: true, 96: true, 97: true,
+}
+
+// validNIK memeriksa struktur NIK: PPKKCC DDMMYY NNNN.
+// Tanggal lahir perempuan ditambah 40, dan nomor urut tidak boleh 0000.
+func validNIK(s string) bool {
+	if len(s) != 16 {
+		return false
+	}
+	province, _ := strconv.Atoi(s[0:2])
+	day, _ := strconv.Atoi(s[6:8])
+	month, _ := strconv.Atoi(s[8:10])
+	serial, _ := strconv.Atoi(s[12:16])
+
+	if !nikProvinces[province] {
+		return false
+	}
+	if day > 40 {
+		day -= 40
+	}
+	return day >= 1 && day <= 31 && month >= 1 && month <= 12 && serial > 0
+}
+
+// validLuhn memeriksa checksum Luhn untuk nomor kartu debit/kredit
+func validLuhn(s string) bool {
+	digits := onlyDigits(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validPhone memeriksa nomor seluler Indonesia: 08xx / +628xx dengan 10-13 digit nasional
+func validPhone(s string) bool {
+	digits := onlyDigits(s)
+	switch {
+	case strings.HasPrefix(digits, "62"):
+		digits = "0" + digits[2:]
+	case !strings.HasPrefix(digits, "0"):
+		return false
+	}
+	return strings.HasPrefix(digits, "08") && len(digits) >= 10 && len(digits) <= 13
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
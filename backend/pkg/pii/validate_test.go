@@ -0,0 +1,61 @@
+package pii
+
+import "testing"
+
+func TestValidNIK(t *testing.T) {
+	tests := []struct {
+		nik  string
+		want bool
+	}{
+		{"3201011505900001", true},
+		{"3201015505900001", true},  // perempuan, tanggal +40
+		{"9901011505900001", false}, // kode provinsi tidak ada
+		{"3201013205900001", false}, // tanggal 32
+		{"3201011513900001", false}, // bulan 13
+		{"3201011505900000", false}, // nomor urut 0000
+		{"320101150590001", false},  // 15 digit
+	}
+	for _, tt := range tests {
+		if got := validNIK(tt.nik); got != tt.want {
+			t.Errorf("validNIK(%s) = %v, ingin %v", tt.nik, got, tt.want)
+		}
+	}
+}
+
+func TestValidLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{"4111111111111111", true},
+		{"4111 1111 1111 1111", true},
+		{"5500-0000-0000-0004", true},
+		{"4111111111111112", false},
+		{"424242424242", false},         // terlalu pendek
+		{"41111111111111111111", false}, // terlalu panjang
+	}
+	for _, tt := range tests {
+		if got := validLuhn(tt.number); got != tt.want {
+			t.Errorf("validLuhn(%s) = %v, ingin %v", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestValidPhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  bool
+	}{
+		{"081234567890", true},
+		{"+62 812-3456-7890", true},
+		{"6281234567890", true},
+		{"0812345", false},
+		{"0211234567", false}, // telepon rumah
+		{"81234567890", false},
+	}
+	for _, tt := range tests {
+		if got := validPhone(tt.phone); got != tt.want {
+			t.Errorf("validPhone(%s) = %v, ingin %v", tt.phone, got, tt.want)
+		}
+	}
+}
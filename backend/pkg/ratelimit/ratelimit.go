@@ -0,0 +1,63 @@
+// Package ratelimit berisi pembatas laju sederhana di memori. Batas berlaku per instance
+// server, cukup untuk meredam spam dari endpoint publik seperti widget chat.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// Limiter mengizinkan paling banyak limit kejadian per key dalam setiap jendela waktu tetap
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	entries   map[string]*window
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func New(limit int, per time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: per, entries: make(map[string]*window), now: time.Now}
+}
+
+// Allow mencatat satu kejadian untuk key. Jika batas sudah tercapai, kejadian tidak dicatat
+// dan retryAfter berisi sisa waktu sampai jendela berikutnya.
+func (l *Limiter) Allow(key string) (ok bool, retryAfter time.Duration) {
+	if l == nil || l.limit <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	w := l.entries[key]
+	if w == nil || now.Sub(w.start) >= l.window {
+		w = &window{start: now}
+		l.entries[key] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// sweep membuang jendela yang sudah lewat agar map tidak tumbuh terus
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, w := range l.entries {
+		if now.Sub(w.start) >= l.window {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	start := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	l := New(2, time.Hour)
+	now := start
+	l.now = func() time.Time { return now }
+
+	steps := []struct {
+		name       string
+		at         time.Duration
+		key        string
+		ok         bool
+		retryAfter time.Duration
+	}{
+		{"pertama", 0, "a", true, 0},
+		{"kedua", 10 * time.Minute, "a", true, 0},
+		{"batas tercapai", 20 * time.Minute, "a", false, 40 * time.Minute},
+		{"key lain terpisah", 20 * time.Minute, "b", true, 0},
+		{"masih di jendela yang sama", 59 * time.Minute, "a", false, time.Minute},
+		{"jendela baru", time.Hour, "a", true, 0},
+	}
+	for _, s := range steps {
+		now = start.Add(s.at)
+		ok, retryAfter := l.Allow(s.key)
+		if ok != s.ok || retryAfter != s.retryAfter {
+			t.Errorf("%s: Allow = %v, %v, ingin %v, %v", s.name, ok, retryAfter, s.ok, s.retryAfter)
+		}
+	}
+}
+
+func TestAllowUnlimited(t *testing.T) {
+	var nilLimiter *Limiter
+	for _, l := range []*Limiter{nilLimiter, New(0, time.Minute)} {
+		for i := 0; i < 100; i++ {
+			if ok, _ := l.Allow("a"); !ok {
+				t.Fatal("limiter tanpa batas menolak kejadian")
+			}
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	start := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	l := New(1, time.Minute)
+	now := start
+	l.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b", "c"} {
+		l.Allow(key)
+	}
+	now = start.Add(2 * time.Minute)
+	l.Allow("d")
+	if len(l.entries) != 1 {
+		t.Errorf("jendela kedaluwarsa tidak dibuang, tersisa %d entri", len(l.entries))
+	}
+}
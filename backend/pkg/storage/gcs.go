@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// GCSStore menyimpan file di bucket Cloud Storage, unduhan memakai signed URL V4
+type GCSStore struct {
+	client *gcs.Client
+	bucket *gcs.BucketHandle
+}
+
+func NewGCSStore(ctx context.Context, bucket string, opts ...option.ClientOption) (*GCSStore, error) {
+	if bucket == "" {
+		return nil, errors.New("STORAGE_GCS_BUCKET wajib diisi untuk storage gcs")
+	}
+	client, err := gcs.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &GCSStore{client: client, bucket: client.Bucket(bucket)}, nil
+}
+
+func (s *GCSStore) Name() string {
+	return "gcs"
+}
+
+func (s *GCSStore) Put(ctx context.Context, key, contentType string, r io.Reader) error {
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *GCSStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := s.bucket.Object(key).NewReader(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+func (s *GCSStore) Delete(ctx context.Context, key string) error {
+	err := s.bucket.Object(key).Delete(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *GCSStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return s.bucket.SignedURL(key, &gcs.SignedURLOptions{
+		Method:  "GET",
+		Expires: time.Now().Add(ttl),
+		Scheme:  gcs.SigningSchemeV4,
+	})
+}
+
+func (s *GCSStore) Close() error {
+	return s.client.Close()
+}
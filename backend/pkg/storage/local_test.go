@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorePath(t *testing.T) {
+	s := &LocalStore{Dir: "/data/uploads"}
+	tests := []struct {
+		key     string
+		want    string
+		invalid bool
+	}{
+		{key: "conv-1/att_01/bukti.png", want: "/data/uploads/conv-1/att_01/bukti.png"},
+		{key: "/conv-1/bukti.png", want: "/data/uploads/conv-1/bukti.png"},
+		{key: "conv-1//bukti.png", want: "/data/uploads/conv-1/bukti.png"},
+		{key: "../../etc/passwd", invalid: true},
+		{key: "conv-1/../../rahasia", invalid: true},
+		{key: "conv-1/..", invalid: true},
+		{key: "..", invalid: true},
+		{key: "", invalid: true},
+		{key: "/", invalid: true},
+	}
+	for _, tt := range tests {
+		got, err := s.path(tt.key)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("path(%q) = %q, ingin ditolak", tt.key, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("path(%q) error: %v", tt.key, err)
+			continue
+		}
+		if got != filepath.FromSlash(tt.want) {
+			t.Errorf("path(%q) = %q, ingin %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("objek tidak ditemukan")
+
+// Store adalah penyimpanan file (lampiran) yang bisa diganti: filesystem lokal atau Cloud Storage
+type Store interface {
+	Name() string
+	Put(ctx context.Context, key, contentType string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL mengembalikan link unduhan langsung yang berlaku selama ttl.
+	// Store yang tidak mendukung mengembalikan "" tanpa error, unduhan lewat API.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}